@@ -13,16 +13,51 @@ import (
 
 // Account represents a username-password keypair linking to a private key in the accounts database.
 type Account struct {
+	SchemaVersion uint64 `json:"schema_version"` // Schema version the account record was written under
+
 	Name string `json:"name"` // Name
 
 	PasswordHash []byte `json:"password_hash"` // Password hash
 
 	Address common.Address `json:"address"` // Address
 
+	Role string `json:"role,omitempty"` // Role (RoleUser if empty)
+
+	Frozen       bool      `json:"frozen,omitempty"`        // Whether sends from the account have been frozen by an administrator
+	FreezeReason string    `json:"freeze_reason,omitempty"` // Reason the account was frozen
+	FrozenBy     string    `json:"frozen_by,omitempty"`     // Username of the administrator that froze the account
+	FrozenAt     time.Time `json:"frozen_at,omitempty"`     // Time the account was frozen
+
+	PasswordResetRequired bool `json:"password_reset_required,omitempty"` // Whether an administrator requires the password to be reset before the account can be used
+
+	SubAddresses []*SubAddress `json:"sub_addresses,omitempty"` // Additional named receiving addresses
+
+	KeyEncryption *KeyEncryption `json:"key_encryption,omitempty"` // Private key encryption metadata (nil if the private key is not held by the server)
+
+	WatchOnly bool `json:"watch_only,omitempty"` // Whether the account was added with an existing address, and can't sign with it
+
+	Recoverable bool `json:"recoverable,omitempty"` // Whether the private key is derived from a recovery phrase
+
+	TwoFactor *TwoFactor `json:"two_factor,omitempty"` // TOTP two-factor authentication state (nil if not enrolled)
+
+	Contacts []*Contact `json:"contacts,omitempty"` // Address book
+
+	HiddenFromDirectory bool `json:"hidden_from_directory,omitempty"` // Whether the account's owner has hidden it from the directory
+
+	SpendingLimits *SpendingLimits `json:"spending_limits,omitempty"` // Self-imposed limits on outgoing transactions (nil if unlimited)
+
+	Email string `json:"email,omitempty"` // Verified email address security notices are sent to (empty if none)
+
+	EmailVerification *EmailVerification `json:"email_verification,omitempty"` // Email address awaiting verification (nil if none)
+
+	KnownDeviceKeys []string `json:"known_device_keys,omitempty"` // Keys of the devices tokens have been issued to (hashes of their source address and user agent)
+
+	DeleteAfter time.Time `json:"delete_after"` // Time after which the account is purged (zero if not pending deletion)
+
 	LastFaucetClaimTime   time.Time  `json:"last_claim_time"`   // Last claim time
 	LastFaucetClaimAmount *big.Float `json:"last_claim_amount"` // Last claim amount
 
-	Tokens    []string `json:"tokens"`     // Account tokens
+	Tokens    []*Token `json:"tokens"`     // Account tokens
 	FcmTokens []string `json:"fcm_tokens"` // Account Firebase Cloud Messaging tokens
 }
 
@@ -30,9 +65,11 @@ type Account struct {
 type jsonAccount struct {
 	Name string `json:"name"` // Name
 
-	PasswordHash []byte `json:"password_hash"` // Password hash
-
 	HexAddress string `json:"address"` // Address
+
+	WatchOnly bool `json:"watch_only"` // Whether the account is watch-only
+
+	Frozen bool `json:"frozen"` // Whether sends from the account are frozen
 }
 
 /* BEGIN EXPORTED METHODS */
@@ -50,12 +87,13 @@ func AccountFromBytes(b []byte) (*Account, error) {
 	return &account, nil // No error occurred, return read value
 }
 
-// String serializes a given account to a JSON string.
+// String serializes a given account to a JSON string, holding only its public details (e.g. never its password hash).
 func (account *Account) String() string {
 	jsonAccount := jsonAccount{
-		Name:         account.Name,             // Set name
-		PasswordHash: account.PasswordHash,     // Set password hash
-		HexAddress:   account.Address.String(), // Set hex address
+		Name:       account.Name,             // Set name
+		HexAddress: account.Address.String(), // Set hex address
+		WatchOnly:  account.WatchOnly,        // Set watch-only
+		Frozen:     account.Frozen,           // Set frozen
 	} // Initialize JSON account instance
 
 	marshaledVal, _ := json.MarshalIndent(jsonAccount, "", "  ") // Marshal
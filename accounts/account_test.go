@@ -4,6 +4,7 @@ package accounts
 
 import (
 	"bytes"
+	"strings"
 	"testing"
 
 	"github.com/SummerCash/go-summercash/common"
@@ -39,4 +40,16 @@ func TestBytesAccount(t *testing.T) {
 	}
 }
 
+// TestStringAccount tests that String() only serializes an account's public details.
+func TestStringAccount(t *testing.T) {
+	account := &Account{
+		Name:         "test",                          // Set name
+		PasswordHash: crypto.Salt([]byte("password")), // Set password
+	}
+
+	if serialized := account.String(); strings.Contains(serialized, "password_hash") || !strings.Contains(serialized, `"name": "test"`) { // Check exposes password hash
+		t.Fatalf("unexpected public account %s", serialized) // Panic
+	}
+}
+
 /* END EXPORTED METHODS TESTS */
@@ -4,18 +4,14 @@ package accounts
 
 import (
 	"bytes"
-	"crypto/ecdsa"
-	"crypto/elliptic"
-	rand "crypto/rand"
-	"encoding/hex"
+	"encoding/json"
 	"errors"
-	"fmt"
 	"math/big"
 	"os"
 	"path/filepath"
 	"time"
 
-	"github.com/boltdb/bolt"
+	"github.com/SummerCash/summercash-wallet-server/storage"
 	"github.com/juju/loggo"
 
 	"github.com/SummerCash/go-summercash/accounts"
@@ -34,19 +30,38 @@ var (
 
 	// ErrPasswordInvalid is an error definition describing an invalid password value.
 	ErrPasswordInvalid = errors.New("invalid password")
+
+	// ErrPushTokenAlreadyExists is an error definition describing an attempt to register a duplicate push token.
+	ErrPushTokenAlreadyExists = errors.New("token already exists")
+
+	// ErrSameBackend is an error definition describing an attempt to migrate the accounts database to the backend it is already stored in.
+	ErrSameBackend = errors.New("source and destination storage backends are the same")
+
+	// ErrDestinationExists is an error definition describing an attempt to migrate the accounts database over an existing database.
+	ErrDestinationExists = errors.New("destination accounts database already exists")
 )
 
 var (
 	// accountsBucket is the accounts bucket key definition.
 	accountsBucket = []byte("accounts")
 
+	// addressesBucket is the address => username index bucket key definition.
+	addressesBucket = []byte("addresses")
+
 	// logger is the db package logger.
 	logger = getDBLogger()
 )
 
-// DB is a data type representing a link to a working accounts boltdb instance.
+// Backend is the storage backend that the accounts database is opened with (one of storage.BackendBolt or storage.BackendSQLite).
+var Backend = storage.BackendBolt
+
+// DB is a data type representing a link to a working accounts database instance.
 type DB struct {
-	DB *bolt.DB // DB represents the currently opened db.
+	store storage.Store // Store backing the currently opened db
+
+	source string // Source address failed logins are attributed to (see FromSource)
+
+	userAgent string // User agent of the client the database is used on behalf of (see FromClient)
 }
 
 /* BEGIN EXPORTED METHODS */
@@ -55,67 +70,17 @@ type DB struct {
 func OpenDB() (*DB, error) {
 	logger.Infof("opening db instance") // Log open db
 
-	err := common.CreateDirIfDoesNotExit(common.DBDir) // Make database directory
+	db, err := openStore(Backend) // Open DB
 
 	if err != nil { // Check for errors
 		return &DB{}, err // Return found error
 	}
 
-	database, err := bolt.Open(filepath.FromSlash(fmt.Sprintf("%s/smc_db.db", common.DBDir)), 0644, &bolt.Options{Timeout: 5 * time.Second}) // Open DB with timeout
+	_, err = db.Migrate(false) // Apply any pending schema migrations
 
 	if err != nil { // Check for errors
-		return &DB{}, err // Return found error
-	}
-
-	db := &DB{
-		DB: database, // Set DB
-	} // Initialize DB
+		db.CloseDB() // Release db lock
 
-	err = db.DB.Update(func(tx *bolt.Tx) error {
-		if tx.Bucket(accountsBucket) == nil { // Check first account
-			return errors.New("must make faucet account") // Return must make error
-		}
-
-		return nil // No error occurred, return nil
-	}) // Create faucet account
-
-	if err != nil && err.Error() == "must make faucet account" { // Check must make faucet account
-		var privateKey *ecdsa.PrivateKey // Initialize private key buffer
-
-		privateKey, err = ecdsa.GenerateKey(elliptic.P521(), rand.Reader) // Generate private key
-
-		if err != nil { // Check for errors
-			return &DB{}, err // Return found error
-		}
-
-		err = common.CreateDirIfDoesNotExit(fmt.Sprintf("%s/faucet/keystore", common.DataDir)) // Create faucet keystore dir
-
-		if err != nil { // Check for errors
-			return &DB{}, err // Return found error
-		}
-
-		keystoreFile, err := os.OpenFile(filepath.FromSlash(fmt.Sprintf("%s/faucet/keystore/privateKey.key", common.DataDir)), os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666) // Open keystore dir
-
-		if err != nil { // Check for errors
-			return &DB{}, err // Return found error
-		}
-
-		defer keystoreFile.Close() // Close keystore file
-
-		_, err = keystoreFile.WriteString(privateKey.X.String() + ":" + privateKey.Y.String()) // Write pwd
-
-		if err != nil { // Check for errors
-			return &DB{}, err // Return found error
-		}
-
-		_, err = db.CreateNewAccount("faucet", privateKey.X.String()+privateKey.Y.String()) // Create faucet account
-
-		if err != nil { // Check for errors
-			return &DB{}, err // Return found error
-		}
-	}
-
-	if err != nil { // Check for errors
 		return &DB{}, err // Return found error
 	}
 
@@ -124,105 +89,93 @@ func OpenDB() (*DB, error) {
 
 // CloseDB closes the db.
 func (db *DB) CloseDB() error {
-	return db.DB.Close() // Close db
+	return db.store.Close() // Close db
 }
 
-// AddNewAccount adds a new account to the list of accounts in the working database.
-func (db *DB) AddNewAccount(name string, password string, address string) (*Account, error) {
-	parsedAddress, err := summercashCommon.StringToAddress(address) // Parse hex address
+// DBPath gets the path of the accounts database file for a given storage backend.
+func DBPath(backend string) string {
+	if backend == storage.BackendSQLite { // Check is SQLite
+		return filepath.Join(common.DBDir, "smc_db.sqlite") // Return SQLite path
+	}
 
-	if err != nil { // Check for errors
-		return &Account{}, err // Return found error
+	return filepath.Join(common.DBDir, "smc_db.db") // Return bolt path
+}
+
+// MigrateBackend copies the accounts database from a given storage backend into an empty database of another storage backend.
+// Neither database may be open while migrating. The source database is left untouched.
+func MigrateBackend(from string, to string) error {
+	if from == to { // Check same backend
+		return ErrSameBackend // Return error
 	}
 
-	account := &Account{
-		Name:         name,                          // Set name
-		PasswordHash: crypto.Salt([]byte(password)), // Set password hash
-		Address:      parsedAddress,                 // Set address
+	if _, err := os.Stat(DBPath(to)); err == nil { // Check destination already exists
+		return ErrDestinationExists // Return error
 	}
 
-	err = db.CreateAccountsBucketIfNotExist() // Create accounts bucket
+	src, err := openStore(from) // Open source
 
 	if err != nil { // Check for errors
-		return &Account{}, err // Return found error
+		return err // Return found error
 	}
 
-	err = db.DB.Update(func(tx *bolt.Tx) error {
-		accountsBucket := tx.Bucket(accountsBucket) // Get accounts bucket
-
-		if alreadyExists := accountsBucket.Get(crypto.Sha3([]byte(name))); alreadyExists != nil { // Check already exists
-			return ErrAccountAlreadyExists // Return error
-		}
-
-		return accountsBucket.Put(crypto.Sha3([]byte(name)), account.Bytes()) // Put account
-	}) // Add new account to DB
+	defer src.CloseDB() // Close source
 
-	if err != nil { // Check for errors
-		return &Account{}, err // Return found error
+	if _, err = src.Migrate(false); err != nil { // Bring source up to date before copying
+		return err // Return found error
 	}
 
-	return account, nil // Return address
-}
-
-// IssueAccountToken issues a new account token.
-func (db *DB) IssueAccountToken(username, password string) (string, error) {
-	account, err := db.QueryAccountByUsername(username) // Query account
+	dst, err := openStore(to) // Open destination
 
 	if err != nil { // Check for errors
-		return "", err // Return found error
+		return err // Return found error
 	}
 
-	if !db.Auth(username, password) { // Check should not issue token
-		return "", ErrPasswordInvalid // Invalid
+	if err = storage.Copy(dst.store, src.store); err == nil { // Copy db
+		err = dst.store.Check() // Check copy
 	}
 
-	token, err := ecdsa.GenerateKey(elliptic.P521(), rand.Reader) // Generate key
+	dst.CloseDB() // Close destination
 
 	if err != nil { // Check for errors
-		return "", err // Return found error
-	}
-
-	(*account).Tokens = append(account.Tokens, hex.EncodeToString(crypto.Sha3(append(token.X.Bytes(), token.Y.Bytes()...)))) // Add token to account
-
-	err = db.DB.Update(func(tx *bolt.Tx) error {
-		accountsBucket := tx.Bucket(accountsBucket) // Get accounts bucket
+		os.Remove(DBPath(to)) // Remove partial copy
 
-		return accountsBucket.Put(crypto.Sha3([]byte(account.Name)), account.Bytes()) // Update account
-	})
-
-	if err != nil { // Check for errors
-		return "", err // Return found error
+		return err // Return found error
 	}
 
-	return hex.EncodeToString(crypto.Sha3(append(token.X.Bytes(), token.Y.Bytes()...))), nil // Return token
+	logger.Infof("migrated accounts db from %s to %s", DBPath(from), DBPath(to)) // Log migrated
+
+	return nil // No error occurred, return nil
 }
 
-// ValidateAccountToken checks whether or not a given token is valid.
-func (db *DB) ValidateAccountToken(account *Account, token string) bool {
-	for _, currentToken := range account.Tokens { // Iterate through account tokens
-		if currentToken == token { // Check token matches
-			return true // Valid
-		}
-	}
+// AddNewAccount adds a new, watch-only account with an existing address to the list of accounts in the working database.
+// The server never holds the address's private key, so transactions must be signed elsewhere (see transactions.SubmitSignedTransaction).
+// The username is normalized, and must satisfy the username policy (see ValidateUsername).
+func (db *DB) AddNewAccount(name string, password string, address string) (*Account, error) {
+	return db.addAccount(name, password, address, false) // Add account
+}
 
-	return false // Invalid token
+// AddReservedAccount adds a new account with a reserved username (e.g. the faucet account) to the working database.
+func (db *DB) AddReservedAccount(name string, password string, address string) (*Account, error) {
+	return db.addAccount(name, password, address, true) // Add account
 }
 
 // MakeFaucetClaim makes a faucet claim for a given account.
 func (db *DB) MakeFaucetClaim(account *Account, amount *big.Float) error {
+	if account.PendingDeletion() { // Check pending deletion
+		return ErrAccountPendingDeletion // Return error
+	}
+
 	err := db.CreateAccountsBucketIfNotExist() // Create accounts bucket
 
 	if err != nil { // Check for errors
 		return err // Return found error
 	}
 
-	return db.DB.Update(func(tx *bolt.Tx) error {
-		accountsBucket := tx.Bucket(accountsBucket) // Get accounts bucket
-
+	return db.store.Update(func(tx storage.Tx) error {
 		(*account).LastFaucetClaimTime = time.Now() // Set last claim time
 		(*account).LastFaucetClaimAmount = amount   // Set claim amount
 
-		return accountsBucket.Put(crypto.Sha3([]byte(account.Name)), account.Bytes()) // Put account
+		return putAccount(tx, account) // Put account
 	}) // Add new account to DB
 }
 
@@ -261,22 +214,21 @@ func (db *DB) GetUserTransactions(username string) ([]*types.Transaction, error)
 }
 
 // CreateNewAccount creates a new account with a given name and password.
+// The account's private key is encrypted at rest under a key derived from its password.
 // Returns the new account's address and an error (if applicable).
 func (db *DB) CreateNewAccount(name string, password string) (*Account, error) {
-	account, err := accounts.NewAccount() // Create new account
-
-	if err != nil { // Check for errors
+	if err := ValidateUsername(name); err != nil { // Validate username before generating a key pair
 		return &Account{}, err // Return found error
 	}
 
-	err = account.WriteToMemory() // Write account to persistent memory
+	account, err := accounts.NewAccount() // Create new account
 
 	if err != nil { // Check for errors
 		return &Account{}, err // Return found error
 	}
 
 	accountInstance := &Account{
-		Name:         name,                          // Set name
+		Name:         NormalizeUsername(name),       // Set name
 		PasswordHash: crypto.Salt([]byte(password)), // Set password hash
 		Address:      account.Address,               // Set address
 	}
@@ -287,14 +239,16 @@ func (db *DB) CreateNewAccount(name string, password string) (*Account, error) {
 		return &Account{}, err // Return found error
 	}
 
-	err = db.DB.Update(func(tx *bolt.Tx) error {
-		accountsBucket := tx.Bucket(accountsBucket) // Get accounts bucket
+	err = db.store.Update(func(tx storage.Tx) error {
+		if err := checkUsernameAvailable(tx, accountInstance.Name, false); err != nil { // Check username available
+			return err // Return found error
+		}
 
-		if alreadyExists := accountsBucket.Get(crypto.Sha3([]byte(name))); alreadyExists != nil { // Check already exists
-			return ErrAccountAlreadyExists // Return error
+		if err := accountInstance.encryptPrivateKey(account.PrivateKey, password); err != nil { // Encrypt private key
+			return err // Return found error
 		}
 
-		return accountsBucket.Put(crypto.Sha3([]byte(name)), accountInstance.Bytes()) // Put account
+		return putAccount(tx, accountInstance) // Put account
 	}) // Add new account to DB
 
 	if err != nil { // Check for errors
@@ -304,63 +258,148 @@ func (db *DB) CreateNewAccount(name string, password string) (*Account, error) {
 	return accountInstance, nil // Return address
 }
 
-// Auth checks that a given user can be authenticated.
+// Auth checks that a given user can be authenticated by password.
 func (db *DB) Auth(name string, password string) bool {
+	return db.Authenticate(name, password) == nil // Authenticate
+}
+
+// AuthScoped checks that a given user can be authenticated by either their password, or an access token carrying a given scope.
+func (db *DB) AuthScoped(name string, secret string, scope string) bool {
+	return db.AuthenticateScoped(name, secret, scope) == nil // Authenticate
+}
+
+// Authenticate authenticates a given user by password.
+// Returns ErrAccountLockedOut if the user, or the source of the request, has been locked out after too many failed logins.
+// Every attempt is recorded in the audit log.
+func (db *DB) Authenticate(name string, password string) (err error) {
+	defer func() { db.RecordAuditEvent(name, AuditActionLogin, "", err) }() // Record attempt
+
+	account, err := db.QueryAccountByUsername(name) // Query by username
+
+	if err != nil { // Check for errors
+		if err == ErrAccountDoesNotExist { // Check guessed username
+			db.recordLoginFailure("") // Attribute failure to source
+		}
+
+		return err // Return found error
+	}
+
+	return db.verifyPassword(account, password) // Verify salt
+}
+
+// AuthenticateScoped authenticates a given user by either their password, or an access token carrying a given scope.
+// Returns ErrAccountLockedOut if the user, or the source of the request, has been locked out after too many failed logins.
+func (db *DB) AuthenticateScoped(name string, secret string, scope string) error {
 	account, err := db.QueryAccountByUsername(name) // Query by username
 
 	if err != nil { // Check for errors
-		return false // Return not valid
+		if err == ErrAccountDoesNotExist { // Check guessed username
+			db.recordLoginFailure("") // Attribute failure to source
+		}
+
+		return err // Return found error
+	}
+
+	if db.ValidateAccountTokenScope(account, secret, scope) { // Check is token (checked first, so that tokens are never counted as failed passwords)
+		return nil // Valid
 	}
 
-	return crypto.VerifySalted(account.PasswordHash, password) || db.ValidateAccountToken(account, password) // Verify salt / token
+	return db.verifyPassword(account, secret) // Verify salt
 }
 
-// DeleteAccount deletes an account from the working DB.
-func (db *DB) DeleteAccount(name string, password string) error {
-	err := db.CreateAccountsBucketIfNotExist() // Create accounts bucket
+// DeleteAccount schedules the deletion of an account from the working DB after DeletionGracePeriod, during which the account
+// cannot be used, but can be restored (see RestoreAccount).
+// The deletion must be authorized by the account's password, or by a token carrying the admin scope,
+// and by a second factor if the account has enabled two-factor authentication.
+func (db *DB) DeleteAccount(name string, password string, secondFactor string) (err error) {
+	defer func() { db.RecordAuditEvent(name, OperationDeleteAccount, "", err) }() // Record attempt
+
+	err = db.CreateAccountsBucketIfNotExist() // Create accounts bucket
 
 	if err != nil { // Check for errors
 		return err // Return found error
 	}
 
-	if !db.Auth(name, password) { // Auth
-		return ErrPasswordInvalid // Return error
+	if err = db.AuthenticateScoped(name, password, ScopeAdmin); err != nil { // Auth
+		return err // Return found error
 	}
 
-	return db.DB.Update(func(tx *bolt.Tx) error {
-		accountsBucket := tx.Bucket(accountsBucket) // Get accounts bucket
+	account, err := db.QueryAccountByUsername(name) // Query by username
 
-		return accountsBucket.Delete(crypto.Sha3([]byte(name))) // Delete account
-	}) // Update account info
+	if err != nil { // Check for errors
+		return err // Return found error
+	}
+
+	if err = db.verifySecondFactor(account, OperationDeleteAccount, secondFactor); err != nil { // Verify second factor
+		return err // Return found error
+	}
+
+	return db.scheduleDeletion(account.Name) // Schedule deletion
 }
 
 // ResetAccountPassword resets an accounts password.
-func (db *DB) ResetAccountPassword(name string, oldPassword string, newPassword string) error {
+// If revokeTokens is true, every token issued to the account is revoked as well.
+// A reset forced by ForcePasswordReset must change the password: resetting it to the current password is refused with ErrPasswordUnchanged.
+func (db *DB) ResetAccountPassword(name string, oldPassword string, newPassword string, revokeTokens bool) (err error) {
+	defer func() { db.RecordAuditEvent(name, AuditActionResetPassword, "", err) }() // Record attempt
+
 	account, err := db.QueryAccountByUsername(name) // Query by username
 
 	if err != nil { // Check for errors
 		return err // Return found error
 	}
 
-	valid := crypto.VerifySalted(account.PasswordHash, oldPassword) // Verify salt
+	if err = db.verifyPassword(account, oldPassword); err != nil && err != ErrPasswordResetRequired { // Verify salt
+		return err // Return found error
+	}
 
-	if valid != true { // Check for errors
-		return ErrPasswordInvalid // Return found error
+	if account.PasswordResetRequired && newPassword == oldPassword { // Check forced reset wouldn't rotate password
+		return ErrPasswordUnchanged // Return error
 	}
 
-	(*account).PasswordHash = crypto.Salt([]byte(newPassword)) // Set salt
+	var wrappedKey []byte // Init wrapped key buffer
 
-	err = db.CreateAccountsBucketIfNotExist() // Create accounts bucket
+	if account.KeyEncryption != nil { // Check has encrypted private key
+		wrappedKey = account.KeyEncryption.PasswordWrappedKey // Remember key wrapped under old password
 
-	if err != nil { // Check for errors
-		return err // Return found error
+		dataKey, err := account.unwrapDataKey(oldPassword) // Unwrap data key
+
+		if err != nil { // Check for errors
+			return err // Return found error
+		}
+
+		if err = account.wrapDataKey(dataKey, newPassword); err != nil { // Re-wrap data key under new password
+			return err // Return found error
+		}
 	}
 
-	return db.DB.Update(func(tx *bolt.Tx) error {
-		accountsBucket := tx.Bucket(accountsBucket) // Get accounts bucket
+	passwordHash := crypto.Salt([]byte(newPassword)) // Hash new password
 
-		return accountsBucket.Put(crypto.Sha3([]byte(name)), account.Bytes()) // Put account
-	}) // Update account info
+	_, err = db.updateAccount(name, func(current *Account) error {
+		if !bytes.Equal(current.PasswordHash, account.PasswordHash) { // Check password changed since it was verified
+			return ErrPasswordInvalid // Return error
+		}
+
+		if current.KeyEncryption == nil && wrappedKey != nil || current.KeyEncryption != nil && !bytes.Equal(current.KeyEncryption.PasswordWrappedKey, wrappedKey) { // Check private key re-encrypted since it was unwrapped
+			return ErrPasswordInvalid // Return error
+		}
+
+		if current.PasswordResetRequired && newPassword == oldPassword { // Check reset forced since the password was verified
+			return ErrPasswordUnchanged // Return error
+		}
+
+		(*current).KeyEncryption = account.KeyEncryption // Set re-wrapped key
+		(*current).PasswordHash = passwordHash           // Set salt
+		(*current).PasswordResetRequired = false         // Reset complete
+
+		if revokeTokens { // Check should revoke tokens
+			(*current).Tokens = []*Token{} // Revoke all tokens
+		}
+
+		return nil // No error occurred, return nil
+	}) // Update account, so that changes made since it was read aren't overwritten
+
+	return err // Return error
 }
 
 // QueryAccountByUsername queries the database for an account with a given username.
@@ -373,15 +412,15 @@ func (db *DB) QueryAccountByUsername(name string) (*Account, error) {
 		return &Account{}, err // Return found error
 	}
 
-	err = db.DB.View(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket(accountsBucket) // Get accounts bucket
+	err = db.store.View(func(tx storage.Tx) error {
+		key := accountKey(tx, name) // Resolve account key
 
-		accountBytes := bucket.Get(crypto.Sha3([]byte(name))) // Get account at hash
-
-		if accountBytes == nil { // Check no account at hash
+		if key == nil { // Check no account with username
 			return ErrAccountDoesNotExist // Return error
 		}
 
+		accountBytes := tx.Bucket(accountsBucket).Get(key) // Get account at key
+
 		accountBuffer, err = AccountFromBytes(accountBytes) // Deserialize account bytes
 
 		return err // Return error
@@ -395,6 +434,7 @@ func (db *DB) QueryAccountByUsername(name string) (*Account, error) {
 }
 
 // QueryAccountByAddress queries the database for an account with a given address.
+// Lookups are resolved through the address index, rather than by scanning the accounts bucket.
 func (db *DB) QueryAccountByAddress(address summercashCommon.Address) (*Account, error) {
 	var accountBuffer *Account // Initialize account buffer
 
@@ -404,22 +444,22 @@ func (db *DB) QueryAccountByAddress(address summercashCommon.Address) (*Account,
 		return &Account{}, err // Return found error
 	}
 
-	err = db.DB.View(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket(accountsBucket) // Get accounts bucket
+	err = db.store.View(func(tx storage.Tx) error {
+		name := tx.Bucket(addressesBucket).Get(address.Bytes()) // Get username at address
 
-		c := bucket.Cursor() // Get cursor
-
-		for _, accountBytes := c.First(); accountBytes != nil; _, accountBytes = c.Next() { // Iterate
-			account, err := AccountFromBytes(accountBytes) // Deserialize account bytes
+		if name == nil { // Check no username at address
+			return ErrAccountDoesNotExist // Account does not exist
+		}
 
-			if bytes.Equal(account.Address.Bytes(), address.Bytes()) && err == nil { // Check addresses equivalent
-				accountBuffer = account // Set account
+		accountBytes := tx.Bucket(accountsBucket).Get(crypto.Sha3(name)) // Get account at hash
 
-				return nil // No error occurred, return nil
-			}
+		if accountBytes == nil { // Check no account at hash
+			return ErrAccountDoesNotExist // Account does not exist
 		}
 
-		return ErrAccountDoesNotExist // Account does not exist
+		accountBuffer, err = AccountFromBytes(accountBytes) // Deserialize account bytes
+
+		return err // Return error
 	}) // Read account
 
 	if err != nil { // Check for errors
@@ -429,19 +469,381 @@ func (db *DB) QueryAccountByAddress(address summercashCommon.Address) (*Account,
 	return accountBuffer, nil // Return read account
 }
 
-// CreateAccountsBucketIfNotExist creates the accounts bucket if it doesn't already exist.
+// QueryAllAccounts queries the database for every decodable account.
+func (db *DB) QueryAllAccounts() ([]*Account, error) {
+	var accountsBuffer []*Account // Initialize accounts buffer
+
+	err := db.CreateAccountsBucketIfNotExist() // Create accounts bucket
+
+	if err != nil { // Check for errors
+		return nil, err // Return found error
+	}
+
+	err = db.store.View(func(tx storage.Tx) error {
+		return forEachAccount(tx.Bucket(accountsBucket), func(_ []byte, account *Account) error {
+			accountsBuffer = append(accountsBuffer, account) // Append account
+
+			return nil // No error occurred, return nil
+		}) // Collect accounts
+	}) // Read accounts
+
+	if err != nil { // Check for errors
+		return nil, err // Return found error
+	}
+
+	return accountsBuffer, nil // Return read accounts
+}
+
+// AddAccountPushToken registers a given FCM push token with the account with a given username.
+func (db *DB) AddAccountPushToken(name string, fcmToken string) error {
+	err := db.CreateAccountsBucketIfNotExist() // Create accounts bucket
+
+	if err != nil { // Check for errors
+		return err // Return found error
+	}
+
+	return db.store.Update(func(tx storage.Tx) error {
+		key := accountKey(tx, name) // Resolve account key
+
+		if key == nil { // Check no account with username
+			return ErrAccountDoesNotExist // Return error
+		}
+
+		account, err := AccountFromBytes(tx.Bucket(accountsBucket).Get(key)) // Deserialize account bytes
+
+		if err != nil { // Check for errors
+			return err // Return found error
+		}
+
+		for _, token := range account.FcmTokens { // Iterate through account tokens
+			if token == fcmToken { // Check token already exists
+				return ErrPushTokenAlreadyExists // Return error
+			}
+		}
+
+		(*account).FcmTokens = append(account.FcmTokens, fcmToken) // Append fcm token
+
+		return putAccount(tx, account) // Put account
+	}) // Update account info
+}
+
+// RebuildAddressIndex rebuilds the address index from the contents of the accounts bucket.
+func (db *DB) RebuildAddressIndex() error {
+	err := db.CreateAccountsBucketIfNotExist() // Create accounts bucket
+
+	if err != nil { // Check for errors
+		return err // Return found error
+	}
+
+	return db.store.Update(func(tx storage.Tx) error {
+		return rebuildAddressIndex(tx) // Rebuild index
+	}) // Rebuild index
+}
+
+// CreateAccountsBucketIfNotExist creates the accounts, address index, username skeleton index, login attempts and audit buckets if they don't already exist.
 func (db *DB) CreateAccountsBucketIfNotExist() error {
-	return db.DB.Update(func(tx *bolt.Tx) error {
-		_, err := tx.CreateBucketIfNotExists(accountsBucket) // Create bucket
+	return db.store.Update(func(tx storage.Tx) error {
+		for _, bucket := range [][]byte{accountsBucket, addressesBucket, usernameSkeletonsBucket, loginAttemptsBucket, auditBucket} { // Iterate through buckets
+			if _, err := tx.CreateBucketIfNotExists(bucket); err != nil { // Create bucket
+				return err // Return found error
+			}
+		}
 
-		return err // Return error
-	}) // Create bucket
+		return nil // No error occurred, return nil
+	}) // Create buckets
 }
 
 /* END EXPORTED METHODS */
 
 /* BEGIN INTERNAL METHODS */
 
+// addAccount adds a new account with a given username, password and address to the working database.
+// Reserved usernames are only accepted if allowReserved is set.
+func (db *DB) addAccount(name string, password string, address string, allowReserved bool) (*Account, error) {
+	parsedAddress, err := summercashCommon.StringToAddress(address) // Parse hex address
+
+	if err != nil { // Check for errors
+		return &Account{}, err // Return found error
+	}
+
+	account := &Account{
+		Name:         NormalizeUsername(name),       // Set name
+		PasswordHash: crypto.Salt([]byte(password)), // Set password hash
+		Address:      parsedAddress,                 // Set address
+		WatchOnly:    true,                          // Set watch-only
+	}
+
+	err = db.CreateAccountsBucketIfNotExist() // Create accounts bucket
+
+	if err != nil { // Check for errors
+		return &Account{}, err // Return found error
+	}
+
+	err = db.store.Update(func(tx storage.Tx) error {
+		if err := checkUsernameAvailable(tx, account.Name, allowReserved); err != nil { // Check username available
+			return err // Return found error
+		}
+
+		if tx.Bucket(addressesBucket).Get(account.Address.Bytes()) != nil { // Check address taken
+			return ErrAddressAlreadyRegistered // Return error
+		}
+
+		return putAccount(tx, account) // Put account
+	}) // Add new account to DB
+
+	if err != nil { // Check for errors
+		return &Account{}, err // Return found error
+	}
+
+	return account, nil // Return account
+}
+
+// verifyPassword verifies a given password against an account's password hash.
+// If the password is valid, but was hashed with an outdated algorithm or parameters, it is transparently rehashed.
+// Likewise, a valid password is used to encrypt the account's private key if it is still stored in plaintext.
+// Failed verifications are recorded, and no verification is attempted while the account or source is locked out.
+// Valid passwords of accounts pending deletion verify as ErrAccountPendingDeletion.
+func (db *DB) verifyPassword(account *Account, password string) error {
+	if err := db.checkLockout(account.Name); err != nil { // Check locked out
+		return err // Return found error
+	}
+
+	if !crypto.VerifySalted(account.PasswordHash, password) { // Check invalid password
+		db.recordLoginFailure(account.Name) // Record failure
+
+		return ErrPasswordInvalid // Invalid
+	}
+
+	db.resetLoginFailures(account.Name) // Forget previous failures
+
+	if account.PendingDeletion() { // Check pending deletion
+		return ErrAccountPendingDeletion // Return error
+	}
+
+	if crypto.NeedsRehash(account.PasswordHash) { // Check outdated hash
+		(*account).PasswordHash = crypto.Salt([]byte(password)) // Rehash
+
+		err := db.store.Update(func(tx storage.Tx) error {
+			return putAccount(tx, account) // Update account
+		}) // Persist new hash
+
+		if err != nil { // Check for errors
+			logger.Errorf("failed to persist rehashed password for account %s: %s", account.Name, err.Error()) // Log error
+		}
+	}
+
+	if account.KeyEncryption == nil && !account.WatchOnly { // Check private key not encrypted
+		if err := db.encryptLegacyPrivateKey(account, password); err != nil { // Encrypt private key
+			logger.Errorf("failed to encrypt legacy private key of account %s: %s", account.Name, err.Error()) // Log error
+		}
+	}
+
+	if account.PasswordResetRequired { // Check reset required
+		return ErrPasswordResetRequired // Return error
+	}
+
+	return nil // Valid
+}
+
+// openStore opens the local DB file of a given storage backend without running any migrations.
+func openStore(backend string) (*DB, error) {
+	err := common.CreateDirIfDoesNotExit(common.DBDir) // Make database directory
+
+	if err != nil { // Check for errors
+		return &DB{}, err // Return found error
+	}
+
+	store, err := storage.Open(backend, DBPath(backend)) // Open DB
+
+	if err != nil { // Check for errors
+		return &DB{}, err // Return found error
+	}
+
+	return &DB{
+		store: store, // Set store
+	}, nil // Return initialized DB
+}
+
+// forEachAccount calls a given function for each decodable account in the accounts bucket.
+// Keys are copied before iteration, so that the function may safely modify the bucket.
+func forEachAccount(bucket storage.Bucket, fn func(key []byte, account *Account) error) error {
+	var keys [][]byte // Init keys buffer
+
+	err := bucket.ForEach(func(key, _ []byte) error {
+		keys = append(keys, append([]byte{}, key...)) // Copy key
+
+		return nil // No error occurred, return nil
+	}) // Collect keys
+
+	if err != nil { // Check for errors
+		return err // Return found error
+	}
+
+	for _, key := range keys { // Iterate through keys
+		account, err := AccountFromBytes(bucket.Get(key)) // Deserialize account
+
+		if err != nil { // Check for errors
+			logger.Errorf("skipping undecodable account during migration: %s", err.Error()) // Log error
+
+			continue // Skip account
+		}
+
+		if err = fn(key, account); err != nil { // Apply
+			return err // Return found error
+		}
+	}
+
+	return nil // No error occurred, return nil
+}
+
+// rebuildAddressIndex rebuilds the address index from the contents of the accounts bucket in a given transaction.
+func rebuildAddressIndex(tx storage.Tx) error {
+	err := tx.DeleteBucket(addressesBucket) // Clear index
+
+	if err != nil && err != storage.ErrBucketNotFound { // Check for errors
+		return err // Return found error
+	}
+
+	index, err := tx.CreateBucketIfNotExists(addressesBucket) // Recreate index
+
+	if err != nil { // Check for errors
+		return err // Return found error
+	}
+
+	accounts := tx.Bucket(accountsBucket) // Get accounts bucket
+
+	if accounts == nil { // Check no accounts yet
+		return nil // Nothing to index
+	}
+
+	return accounts.ForEach(func(_, accountBytes []byte) error {
+		var account struct {
+			Name         string                   `json:"name"`
+			Address      summercashCommon.Address `json:"address"`
+			SubAddresses []*SubAddress            `json:"sub_addresses"`
+		} // Only decode the indexed fields, so that legacy records can be indexed before they are migrated
+
+		err := json.Unmarshal(accountBytes, &account) // Deserialize account bytes
+
+		if err != nil { // Check for errors
+			logger.Errorf("skipping undecodable account while rebuilding address index: %s", err.Error()) // Log error
+
+			return nil // Skip account
+		}
+
+		for _, address := range (&Account{Address: account.Address, SubAddresses: account.SubAddresses}).Addresses() { // Iterate through addresses
+			if err := index.Put(address.Bytes(), []byte(account.Name)); err != nil { // Index address
+				return err // Return found error
+			}
+		}
+
+		return nil // No error occurred, return nil
+	}) // Index all accounts
+}
+
+// putAccount writes a given account to the accounts bucket, and updates the address index accordingly.
+// Addresses indexed under another account are never taken over: the account is refused with ErrAddressAlreadyRegistered instead.
+func putAccount(tx storage.Tx, account *Account) error {
+	index := tx.Bucket(addressesBucket) // Get index bucket
+
+	for _, address := range account.Addresses() { // Iterate through addresses
+		if owner := index.Get(address.Bytes()); owner != nil && string(owner) != account.Name { // Check address belongs to another account
+			return ErrAddressAlreadyRegistered // Return error
+		}
+	}
+
+	(*account).SchemaVersion = CurrentSchemaVersion() // Stamp record with the schema it was written under
+
+	err := tx.Bucket(accountsBucket).Put(crypto.Sha3([]byte(account.Name)), account.Bytes()) // Put account
+
+	if err != nil { // Check for errors
+		return err // Return found error
+	}
+
+	for _, address := range account.Addresses() { // Iterate through addresses
+		if err = index.Put(address.Bytes(), []byte(account.Name)); err != nil { // Index address
+			return err // Return found error
+		}
+	}
+
+	if err = indexDirectoryEntry(tx, account); err != nil { // List in directory
+		return err // Return found error
+	}
+
+	return claimUsernameSkeleton(tx, account.Name) // Index username skeleton
+}
+
+// updateAccount reads the account with a given username, applies a given update to it, and writes it back, in a single transaction.
+// Nothing is written if the update returns an error.
+func (db *DB) updateAccount(name string, update func(account *Account) error) (*Account, error) {
+	err := db.CreateAccountsBucketIfNotExist() // Create accounts bucket
+
+	if err != nil { // Check for errors
+		return nil, err // Return found error
+	}
+
+	var account *Account // Init account buffer
+
+	err = db.store.Update(func(tx storage.Tx) error {
+		key := accountKey(tx, name) // Resolve account key
+
+		if key == nil { // Check no account with username
+			return ErrAccountDoesNotExist // Return error
+		}
+
+		account, err = AccountFromBytes(tx.Bucket(accountsBucket).Get(key)) // Deserialize account bytes
+
+		if err != nil { // Check for errors
+			return err // Return found error
+		}
+
+		if err = update(account); err != nil { // Apply update
+			return err // Return found error
+		}
+
+		return putAccount(tx, account) // Put account
+	}) // Update account
+
+	if err != nil { // Check for errors
+		return nil, err // Return found error
+	}
+
+	return account, nil // Return updated account
+}
+
+// deleteAccount removes a given account from the accounts bucket, along with its address index entry.
+func deleteAccount(tx storage.Tx, account *Account) error {
+	err := tx.Bucket(accountsBucket).Delete(crypto.Sha3([]byte(account.Name))) // Delete account
+
+	if err != nil { // Check for errors
+		return err // Return found error
+	}
+
+	index := tx.Bucket(addressesBucket) // Get index bucket
+
+	for _, address := range account.Addresses() { // Iterate through addresses
+		if name := index.Get(address.Bytes()); name != nil && string(name) == account.Name { // Check index entry belongs to account
+			if err = index.Delete(address.Bytes()); err != nil { // Remove index entry
+				return err // Return found error
+			}
+		}
+	}
+
+	if err = removeDirectoryEntry(tx, account); err != nil { // Remove from directory
+		return err // Return found error
+	}
+
+	skeletons := tx.Bucket(usernameSkeletonsBucket) // Get skeleton index bucket
+
+	skeleton := []byte(usernameSkeleton(NormalizeUsername(account.Name))) // Get skeleton
+
+	if name := skeletons.Get(skeleton); name != nil && string(name) == account.Name { // Check skeleton belongs to account
+		return skeletons.Delete(skeleton) // Release skeleton
+	}
+
+	return nil // No error occurred, return nil
+}
+
 // getDBLogger gets the db package logger, and sets the levels of said logger.
 func getDBLogger() loggo.Logger {
 	logger := loggo.GetLogger("DB") // Get logger
@@ -3,9 +3,20 @@
 package accounts
 
 import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/SummerCash/summercash-wallet-server/storage"
 	"github.com/juju/loggo"
+
+	summercashCommon "github.com/SummerCash/go-summercash/common"
+	"github.com/SummerCash/go-summercash/config"
+	"github.com/SummerCash/summercash-wallet-server/common"
+	"github.com/SummerCash/summercash-wallet-server/crypto"
 )
 
 /* BEGIN EXPORTED METHODS TESTS */
@@ -19,6 +30,264 @@ func TestOpenDB(t *testing.T) {
 	}
 }
 
+// TestQueryAccountByAddress tests the functionality of the QueryAccountByAddress() helper method.
+func TestQueryAccountByAddress(t *testing.T) {
+	purgeDeletedAccountsImmediately(t) // Skip deletion grace period
+
+	db := newTestDB(t) // Open test db
+
+	defer db.CloseDB() // Close db
+
+	account, err := db.AddNewAccount("test", "test", testAddress(0).String()) // Add account
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	resolvedAccount, err := db.QueryAccountByAddress(account.Address) // Query account
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	if resolvedAccount.Name != "test" { // Check resolved wrong account
+		t.Fatalf("resolved account %s, expected test", resolvedAccount.Name) // Panic
+	}
+
+	if _, err = db.AddNewAccount("mallory", "test", account.Address.String()); err != ErrAddressAlreadyRegistered { // Add account claiming the same address
+		t.Fatalf("expected ErrAddressAlreadyRegistered, got %v", err) // Panic
+	}
+
+	err = db.store.Update(func(tx storage.Tx) error {
+		return putAccount(tx, &Account{Name: "mallory", Address: account.Address}) // Put account claiming the same address
+	}) // Write account directly
+
+	if err != ErrAddressAlreadyRegistered { // Check address taken over
+		t.Fatalf("expected ErrAddressAlreadyRegistered, got %v", err) // Panic
+	}
+
+	if resolvedAccount, err = db.QueryAccountByAddress(account.Address); err != nil || resolvedAccount.Name != "test" { // Check still resolves to the original account
+		t.Fatalf("expected address to still resolve to test (%v)", err) // Panic
+	}
+
+	err = db.DeleteAccount("test", "test", "") // Delete account
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	if _, err = db.QueryAccountByAddress(account.Address); err != ErrAccountDoesNotExist { // Check still indexed
+		t.Fatal("deleted account should not be resolvable by address") // Panic
+	}
+}
+
+// TestResetAccountPasswordConcurrent tests that ResetAccountPassword() doesn't revert changes made to the account while the password is being verified.
+func TestResetAccountPasswordConcurrent(t *testing.T) {
+	setTestDataDir(t) // Use temp data dir
+
+	if err := (&config.ChainConfig{}).WriteToMemory(); err != nil { // Write chain config, which account chains are initialized from
+		t.Fatal(err) // Panic
+	}
+
+	db := newTestDB(t) // Open test db
+
+	defer db.CloseDB() // Close db
+
+	if _, err := db.CreateNewAccount("test", "test"); err != nil { // Create account
+		t.Fatal(err) // Panic
+	}
+
+	defer func(n int) { crypto.DefaultScryptN = n }(crypto.DefaultScryptN) // Reset scrypt cost
+
+	crypto.DefaultScryptN = 1 << 16 // Make re-wrapping the private key slow enough for the account to change meanwhile
+
+	reset := make(chan error, 1) // Init reset result channel
+
+	go func() { reset <- db.ResetAccountPassword("test", "test", "new", false) }() // Reset password
+
+	time.Sleep(50 * time.Millisecond) // Let the reset read the account
+
+	if err := db.FreezeAccount("admin", "test", "investigating"); err != nil { // Freeze account during reset
+		t.Fatal(err) // Panic
+	}
+
+	if err := <-reset; err != nil { // Wait for reset
+		t.Fatal(err) // Panic
+	}
+
+	if err := db.CheckAccountNotFrozen("test"); err != ErrAccountFrozen { // Check freeze reverted
+		t.Fatalf("expected ErrAccountFrozen, got %v", err) // Panic
+	}
+
+	if err := db.Authenticate("test", "new"); err != nil { // Log in with new password
+		t.Fatal(err) // Panic
+	}
+}
+
+// TestRebuildAddressIndex tests the functionality of the RebuildAddressIndex() helper method.
+func TestRebuildAddressIndex(t *testing.T) {
+	db := newTestDB(t) // Open test db
+
+	defer db.CloseDB() // Close db
+
+	account, err := db.AddNewAccount("test", "test", testAddress(0).String()) // Add account
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	err = db.store.Update(func(tx storage.Tx) error {
+		return tx.DeleteBucket(addressesBucket) // Drop index
+	}) // Simulate db created before index existed
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	err = db.RebuildAddressIndex() // Rebuild index
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	if _, err = db.QueryAccountByAddress(account.Address); err != nil { // Check not indexed
+		t.Fatal(err) // Panic
+	}
+}
+
+// TestMigrateBackend tests the functionality of the MigrateBackend() helper method.
+func TestMigrateBackend(t *testing.T) {
+	setTestDataDir(t) // Use temp data dir
+
+	dbDir := common.DBDir // Get current db dir
+
+	common.DBDir = filepath.Join(common.DataDir, "db") // Set db dir
+
+	defer func() { common.DBDir = dbDir }() // Restore db dir
+
+	db, err := OpenDB() // Open bolt db
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	if _, err = db.AddNewAccount("test", "test", testAddress(0).String()); err != nil { // Add account
+		t.Fatal(err) // Panic
+	}
+
+	db.CloseDB() // Close db
+
+	if err = MigrateBackend(storage.BackendBolt, storage.BackendSQLite); err != nil { // Migrate
+		t.Fatal(err) // Panic
+	}
+
+	if err = MigrateBackend(storage.BackendBolt, storage.BackendSQLite); err != ErrDestinationExists { // Migrate again
+		t.Fatalf("expected ErrDestinationExists, got %v", err) // Panic
+	}
+
+	Backend = storage.BackendSQLite // Use SQLite
+
+	defer func() { Backend = storage.BackendBolt }() // Restore backend
+
+	db, err = OpenDB() // Open SQLite db
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	defer db.CloseDB() // Close db
+
+	if _, err = db.QueryAccountByAddress(testAddress(0)); err != nil { // Check account migrated
+		t.Fatal(err) // Panic
+	}
+
+	if !db.Auth("test", "test") { // Check password migrated
+		t.Fatal("migrated account should authenticate") // Panic
+	}
+}
+
+// TestAuthRehash tests that Auth() transparently rehashes outdated password hashes, in a database holding a mix of hashes.
+func TestAuthRehash(t *testing.T) {
+	db := newTestDB(t) // Open test db
+
+	defer db.CloseDB() // Close db
+
+	defer func(hasher crypto.PasswordHasher) { crypto.DefaultPasswordHasher = hasher }(crypto.DefaultPasswordHasher) // Restore hasher
+
+	if _, err := db.AddNewAccount("legacy", "test", testAddress(0).String()); err != nil { // Add account with bcrypt hash
+		t.Fatal(err) // Panic
+	}
+
+	crypto.DefaultPasswordHasher = &crypto.Argon2idHasher{Time: 1, Memory: 1024, Threads: 1, SaltLength: 16, KeyLength: 32} // Upgrade hasher
+
+	if _, err := db.AddNewAccount("current", "test", testAddress(1).String()); err != nil { // Add account with argon2id hash
+		t.Fatal(err) // Panic
+	}
+
+	current, _ := db.QueryAccountByUsername("current") // Query argon2id account
+
+	for _, name := range []string{"legacy", "current"} { // Iterate through accounts
+		if db.Auth(name, "wrong") { // Check wrong password accepted
+			t.Fatalf("wrong password accepted for %s", name) // Panic
+		}
+
+		if !db.Auth(name, "test") { // Check password rejected
+			t.Fatalf("password rejected for %s", name) // Panic
+		}
+
+		account, _ := db.QueryAccountByUsername(name) // Query account
+
+		if crypto.NeedsRehash(account.PasswordHash) { // Check not rehashed
+			t.Fatalf("password hash of %s should have been upgraded", name) // Panic
+		}
+
+		if !db.Auth(name, "test") { // Check password rejected after rehash
+			t.Fatalf("password rejected for %s after rehash", name) // Panic
+		}
+	}
+
+	if updated, _ := db.QueryAccountByUsername("current"); string(updated.PasswordHash) != string(current.PasswordHash) { // Check rehashed needlessly
+		t.Fatal("up to date password hash should not be rehashed") // Panic
+	}
+}
+
+// BenchmarkQueryAccountByAddress benchmarks the QueryAccountByAddress() helper method against databases of increasing size.
+func BenchmarkQueryAccountByAddress(b *testing.B) {
+	for _, numAccounts := range []int{100, 1000, 10000} { // Iterate through db sizes
+		b.Run(fmt.Sprintf("accounts=%d", numAccounts), func(b *testing.B) {
+			db := newTestDB(b) // Open test db
+
+			defer db.CloseDB() // Close db
+
+			err := db.store.Update(func(tx storage.Tx) error {
+				for i := 0; i < numAccounts; i++ { // Add accounts
+					err := putAccount(tx, &Account{Name: fmt.Sprintf("user%d", i), Address: testAddress(i)}) // Put account
+
+					if err != nil { // Check for errors
+						return err // Return found error
+					}
+				}
+
+				return nil // No error occurred, return nil
+			}) // Populate db
+
+			if err != nil { // Check for errors
+				b.Fatal(err) // Panic
+			}
+
+			target := testAddress(numAccounts / 2) // Get address of account in middle of db
+
+			b.ResetTimer() // Don't count setup
+
+			for i := 0; i < b.N; i++ { // Run benchmark
+				if _, err := db.QueryAccountByAddress(target); err != nil { // Query account
+					b.Fatal(err) // Panic
+				}
+			}
+		})
+	}
+}
+
 /* END EXPORTED METHODS TESTS */
 
 /* BEGIN INTERNAL METHODS TESTS */
@@ -33,3 +302,72 @@ func TestGetDBLogger(t *testing.T) {
 }
 
 /* END INTERNAL METHODS TESTS */
+
+/* BEGIN TEST HELPERS */
+
+// newTestDB opens an empty accounts database in a temporary directory.
+// The directory is removed once the test completes.
+func newTestDB(tb testing.TB) *DB {
+	dir, err := ioutil.TempDir("", "smc_wallet_test") // Make temp dir
+
+	if err != nil { // Check for errors
+		tb.Fatal(err) // Panic
+	}
+
+	tb.Cleanup(func() { os.RemoveAll(dir) }) // Remove temp dir after test
+
+	store, err := storage.OpenBoltStore(filepath.Join(dir, "smc_db.db")) // Open db
+
+	if err != nil { // Check for errors
+		tb.Fatal(err) // Panic
+	}
+
+	store.SetNoSync(true) // Skip fsync; test dbs are disposable
+
+	db := &DB{store: store} // Initialize db
+
+	if err = db.CreateAccountsBucketIfNotExist(); err != nil { // Create buckets
+		tb.Fatal(err) // Panic
+	}
+
+	return db // Return db
+}
+
+// setTestDataDir points the wallet and go-summercash data directories at a temporary directory for the duration of a test.
+func setTestDataDir(tb testing.TB) {
+	dir, err := ioutil.TempDir("", "smc_wallet_data_test") // Make temp dir
+
+	if err != nil { // Check for errors
+		tb.Fatal(err) // Panic
+	}
+
+	dataDir, summercashDataDir := common.DataDir, summercashCommon.DataDir // Get current data dirs
+
+	common.DataDir, summercashCommon.DataDir = dir, dir // Set data dirs
+
+	tb.Cleanup(func() {
+		common.DataDir, summercashCommon.DataDir = dataDir, summercashDataDir // Restore data dirs
+
+		os.RemoveAll(dir) // Remove temp dir
+	}) // Restore data dirs after test
+}
+
+// testAddress deterministically derives a unique test address from a given seed.
+func testAddress(seed int) summercashCommon.Address {
+	var address summercashCommon.Address // Init address buffer
+
+	copy(address[:], append([]byte("0x"), crypto.Sha3([]byte(fmt.Sprintf("%d", seed)))...)) // Copy hash
+
+	return address // Return address
+}
+
+// purgeDeletedAccountsImmediately disables the deletion grace period for the duration of a test.
+func purgeDeletedAccountsImmediately(tb testing.TB) {
+	gracePeriod := DeletionGracePeriod // Get current grace period
+
+	DeletionGracePeriod = 0 // Disable grace period
+
+	tb.Cleanup(func() { DeletionGracePeriod = gracePeriod }) // Restore grace period after test
+}
+
+/* END TEST HELPERS */
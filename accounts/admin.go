@@ -0,0 +1,349 @@
+// Package accounts defines account-related helper methods and types.
+// The accounts database, for example, is defined in this package.
+package accounts
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/SummerCash/summercash-wallet-server/storage"
+)
+
+const (
+	// RoleUser is the role of an ordinary account.
+	RoleUser = "user"
+
+	// RoleAdmin is the role of an account permitted to use the admin API.
+	RoleAdmin = "admin"
+)
+
+var (
+	// ErrNotAdmin is an error definition describing an attempt to use the admin API with an account lacking the admin role.
+	ErrNotAdmin = errors.New("account is not an administrator")
+
+	// ErrRoleInvalid is an error definition describing an unknown role.
+	ErrRoleInvalid = errors.New("invalid role")
+
+	// ErrPasswordResetRequired is an error definition describing a login to an account whose password must be reset first.
+	ErrPasswordResetRequired = errors.New("password must be reset before the account can be used")
+
+	// ErrPasswordUnchanged is an error definition describing a forced password reset to the password being replaced.
+	ErrPasswordUnchanged = errors.New("new password must differ from the current password")
+
+	// ErrAdminAccountDoesNotExist is an error definition describing a configured admin username that doesn't belong to an account.
+	ErrAdminAccountDoesNotExist = errors.New("no account exists with the configured admin username")
+)
+
+var (
+	// AdminUsernames are the usernames of the accounts granted the admin role on startup (see BootstrapAdmins).
+	AdminUsernames []string
+
+	// DefaultAccountSearchLimit is the number of accounts returned by an account search that doesn't specify a limit.
+	DefaultAccountSearchLimit = 100
+
+	// MaxAccountSearchLimit is the maximum number of accounts returned by a single account search.
+	MaxAccountSearchLimit = 1000
+)
+
+// AccountFilter represents the criteria an account search matches accounts against. Empty criteria match any account.
+type AccountFilter struct {
+	Query string `json:"query"` // Case-insensitive username substring, or hex address prefix
+
+	Role string `json:"role"` // Role
+
+	FrozenOnly bool `json:"frozen_only"` // Whether or not to only match frozen accounts
+
+	Limit int `json:"limit"` // Maximum number of accounts (DefaultAccountSearchLimit if zero)
+}
+
+// AccountStats represents a summary of the accounts in the working database.
+type AccountStats struct {
+	Accounts int `json:"accounts"` // Number of accounts
+
+	Admins int `json:"admins"` // Number of accounts with the admin role
+
+	Frozen int `json:"frozen"` // Number of frozen accounts
+
+	WatchOnly int `json:"watch_only"` // Number of watch-only accounts
+
+	TwoFactor int `json:"two_factor"` // Number of accounts with two-factor authentication enabled
+
+	PendingDeletion int `json:"pending_deletion"` // Number of accounts pending deletion
+
+	PasswordResetRequired int `json:"password_reset_required"` // Number of accounts awaiting a forced password reset
+
+	ActiveSessions int `json:"active_sessions"` // Number of sessions holding an unexpired token
+}
+
+/* BEGIN EXPORTED METHODS */
+
+// RoleName gets the role of an account. Accounts created before roles were introduced are ordinary users.
+func (account *Account) RoleName() string {
+	if account.Role == "" { // Check no role
+		return RoleUser // Default to user
+	}
+
+	return account.Role // Return role
+}
+
+// IsAdmin checks whether or not an account has the admin role.
+func (account *Account) IsAdmin() bool {
+	return account.RoleName() == RoleAdmin // Check is admin
+}
+
+// AuthenticateAdmin authenticates an account with a password (or an access token carrying the admin scope), and checks that it has the admin role.
+func (db *DB) AuthenticateAdmin(name string, secret string) error {
+	if err := db.AuthenticateScoped(name, secret, ScopeAdmin); err != nil { // Authenticate
+		return err // Return found error
+	}
+
+	account, err := db.QueryAccountByUsername(name) // Query account
+
+	if err != nil { // Check for errors
+		return err // Return found error
+	}
+
+	if !account.IsAdmin() { // Check not admin
+		return ErrNotAdmin // Return error
+	}
+
+	return nil // Valid
+}
+
+// SetAccountRole sets the role of the account with a given username on behalf of a given actor.
+func (db *DB) SetAccountRole(actor string, name string, role string) (err error) {
+	defer func() { db.RecordAuditEvent(name, AuditActionSetRole, role+" by "+actor, err) }() // Record attempt
+
+	if role != RoleUser && role != RoleAdmin { // Check unknown role
+		return ErrRoleInvalid // Return error
+	}
+
+	_, err = db.updateAccount(name, func(account *Account) error {
+		(*account).Role = role // Set role
+
+		return nil // No error occurred, return nil
+	}) // Set role
+
+	return err // Return error
+}
+
+// BootstrapAdmins grants the admin role to each account in AdminUsernames that doesn't already have it, and revokes it from every other admin.
+// AdminUsernames is the only source of the admin role, so an admin dropped from it becomes an ordinary user again.
+// Every username in AdminUsernames must belong to an existing account: an unregistered username is refused (rather than granted the role once registered).
+func (db *DB) BootstrapAdmins() error {
+	admins := make(map[string]bool) // Init admin account names
+
+	for _, name := range AdminUsernames { // Iterate through admin usernames
+		if name = strings.TrimSpace(name); name == "" { // Check empty
+			continue // Skip
+		}
+
+		account, err := db.QueryAccountByUsername(name) // Query account
+
+		if err == ErrAccountDoesNotExist { // Check no account
+			logger.Criticalf("not granting the admin role to %s: no account exists with the given username", name) // Log refusal
+
+			return ErrAdminAccountDoesNotExist // Return error
+		} else if err != nil { // Check for errors
+			return err // Return found error
+		}
+
+		admins[account.Name] = true // Set admin
+
+		if account.IsAdmin() { // Check already admin
+			continue // Skip
+		}
+
+		if err = db.SetAccountRole("config", account.Name, RoleAdmin); err != nil { // Grant admin role
+			return err // Return found error
+		}
+
+		logger.Infof("granted the admin role to account %s", account.Name) // Log grant
+	}
+
+	allAccounts, err := db.QueryAllAccounts() // Query accounts
+
+	if err != nil { // Check for errors
+		return err // Return found error
+	}
+
+	for _, account := range allAccounts { // Iterate through accounts
+		if !account.IsAdmin() || admins[account.Name] { // Check not a dropped admin
+			continue // Skip
+		}
+
+		if err = db.SetAccountRole("config", account.Name, RoleUser); err != nil { // Revoke admin role
+			return err // Return found error
+		}
+
+		logger.Infof("revoked the admin role from account %s", account.Name) // Log revocation
+	}
+
+	return nil // No error occurred, return nil
+}
+
+// SearchAccounts finds the accounts matching a given filter, sorted by username.
+func (db *DB) SearchAccounts(filter *AccountFilter) ([]*Account, error) {
+	limit := filter.Limit // Get limit
+
+	if limit <= 0 { // Check no limit
+		limit = DefaultAccountSearchLimit // Set default limit
+	} else if limit > MaxAccountSearchLimit { // Check limit too large
+		limit = MaxAccountSearchLimit // Cap limit
+	}
+
+	allAccounts, err := db.QueryAllAccounts() // Query accounts
+
+	if err != nil { // Check for errors
+		return nil, err // Return found error
+	}
+
+	matches := []*Account{} // Init matches buffer
+
+	for _, account := range allAccounts { // Iterate through accounts
+		if filter.matches(account) { // Check matches
+			matches = append(matches, account) // Append match
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool { return matches[i].Name < matches[j].Name }) // Sort by username
+
+	if len(matches) > limit { // Check too many matches
+		matches = matches[:limit] // Limit matches
+	}
+
+	return matches, nil // Return matches
+}
+
+// ForcePasswordReset requires the account with a given username to reset its password before it can be used again, on behalf of a given actor.
+// Every token issued to the account is revoked. The account's owner resets the password with the current password (see ResetAccountPassword),
+// or with the account's recovery phrase (see RecoverAccount).
+func (db *DB) ForcePasswordReset(actor string, name string) (err error) {
+	defer func() { db.RecordAuditEvent(name, AuditActionForcePasswordReset, "by "+actor, err) }() // Record attempt
+
+	_, err = db.updateAccount(name, func(account *Account) error {
+		(*account).PasswordResetRequired = true // Require reset
+		(*account).Tokens = []*Token{}          // Revoke all tokens
+
+		return nil // No error occurred, return nil
+	}) // Force password reset
+
+	return err // Return error
+}
+
+// RevokeAccountTokens revokes every token issued to the account with a given username on behalf of a given actor, ending all of its sessions.
+// Returns the number of tokens revoked.
+func (db *DB) RevokeAccountTokens(actor string, name string) (revoked int, err error) {
+	defer func() { db.RecordAuditEvent(name, AuditActionRevokeTokens, "by "+actor, err) }() // Record attempt
+
+	_, err = db.updateAccount(name, func(account *Account) error {
+		revoked = account.removeTokens(func(token *Token) bool { return true }) // Revoke all tokens
+
+		return nil // No error occurred, return nil
+	}) // Revoke tokens
+
+	return revoked, err // Return revoked count
+}
+
+// QueryAccountStats summarizes the accounts in the working database.
+func (db *DB) QueryAccountStats() (*AccountStats, error) {
+	err := db.CreateAccountsBucketIfNotExist() // Create accounts bucket
+
+	if err != nil { // Check for errors
+		return nil, err // Return found error
+	}
+
+	stats := &AccountStats{} // Init stats
+
+	err = db.store.View(func(tx storage.Tx) error {
+		return forEachAccount(tx.Bucket(accountsBucket), func(_ []byte, account *Account) error {
+			stats.add(account) // Count account
+
+			return nil // No error occurred, return nil
+		}) // Count accounts
+	}) // Read accounts
+
+	if err != nil { // Check for errors
+		return nil, err // Return found error
+	}
+
+	return stats, nil // Return stats
+}
+
+/* END EXPORTED METHODS */
+
+/* BEGIN INTERNAL METHODS */
+
+// isAdminUsername checks whether a given username is, or is confusable with, one of the configured admin usernames.
+func isAdminUsername(name string) bool {
+	skeleton := usernameSkeleton(NormalizeUsername(name)) // Get skeleton
+
+	for _, admin := range AdminUsernames { // Iterate through admin usernames
+		if strings.TrimSpace(admin) != "" && skeleton == usernameSkeleton(NormalizeUsername(admin)) { // Check confusable
+			return true // Admin
+		}
+	}
+
+	return false // Not admin
+}
+
+// matches checks whether or not a given account matches the filter.
+func (filter *AccountFilter) matches(account *Account) bool {
+	if filter.Role != "" && account.RoleName() != filter.Role { // Check role mismatch
+		return false // No match
+	}
+
+	if filter.FrozenOnly && !account.Frozen { // Check not frozen
+		return false // No match
+	}
+
+	query := strings.ToLower(strings.TrimSpace(filter.Query)) // Normalize query
+
+	if query == "" { // Check no query
+		return true // Match
+	}
+
+	return strings.Contains(account.Name, query) || strings.HasPrefix(strings.ToLower(account.Address.String()), query) // Match username or address
+}
+
+// add counts a given account in the stats.
+func (stats *AccountStats) add(account *Account) {
+	(*stats).Accounts++ // Count account
+
+	if account.IsAdmin() { // Check admin
+		(*stats).Admins++ // Count admin
+	}
+
+	if account.Frozen { // Check frozen
+		(*stats).Frozen++ // Count frozen
+	}
+
+	if account.WatchOnly { // Check watch-only
+		(*stats).WatchOnly++ // Count watch-only
+	}
+
+	if account.TwoFactorEnabled() { // Check two-factor enabled
+		(*stats).TwoFactor++ // Count two-factor
+	}
+
+	if account.PendingDeletion() { // Check pending deletion
+		(*stats).PendingDeletion++ // Count pending deletion
+	}
+
+	if account.PasswordResetRequired { // Check awaiting reset
+		(*stats).PasswordResetRequired++ // Count awaiting reset
+	}
+
+	sessions := make(map[string]bool) // Init session set
+
+	for _, token := range account.Tokens { // Iterate through tokens
+		if !token.Expired() { // Check active
+			sessions[token.SessionID] = true // Add session
+		}
+	}
+
+	(*stats).ActiveSessions += len(sessions) // Count sessions
+}
+
+/* END INTERNAL METHODS */
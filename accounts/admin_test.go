@@ -0,0 +1,147 @@
+// Package accounts defines account-related helper methods and types.
+// The accounts database, for example, is defined in this package.
+package accounts
+
+import (
+	"testing"
+)
+
+/* BEGIN EXPORTED METHODS TESTS */
+
+// TestAuthenticateAdmin tests that BootstrapAdmins() grants the admin role, and that only admins pass AuthenticateAdmin().
+func TestAuthenticateAdmin(t *testing.T) {
+	db := newTestDB(t) // Open test db
+
+	defer db.CloseDB() // Close db
+
+	for i, name := range []string{"alice", "bob"} { // Iterate through usernames
+		if _, err := db.AddNewAccount(name, "test", testAddress(i).String()); err != nil { // Add account
+			t.Fatal(err) // Panic
+		}
+	}
+
+	defer func() { AdminUsernames = nil }() // Reset admin usernames
+
+	AdminUsernames = []string{"Alice", "carol"} // Set admin usernames (carol doesn't exist)
+
+	if err := db.BootstrapAdmins(); err != ErrAdminAccountDoesNotExist { // Bootstrap admins with an unregistered username
+		t.Fatalf("expected ErrAdminAccountDoesNotExist, got %v", err) // Panic
+	}
+
+	if _, err := db.AddNewAccount("Car0l", "test", testAddress(2).String()); err != ErrUsernameReserved { // Register confusable admin username
+		t.Fatalf("expected ErrUsernameReserved, got %v", err) // Panic
+	}
+
+	AdminUsernames = []string{"Alice", "bob"} // Set admin usernames
+
+	if err := db.BootstrapAdmins(); err != nil { // Bootstrap admins
+		t.Fatal(err) // Panic
+	}
+
+	AdminUsernames = []string{" Alice"} // Drop bob
+
+	if err := db.BootstrapAdmins(); err != nil { // Bootstrap admins
+		t.Fatal(err) // Panic
+	}
+
+	if err := db.AuthenticateAdmin("alice", "test"); err != nil { // Authenticate admin
+		t.Fatal(err) // Panic
+	}
+
+	if err := db.AuthenticateAdmin("alice", "wrong"); err != ErrPasswordInvalid { // Authenticate admin with wrong password
+		t.Fatalf("expected ErrPasswordInvalid, got %v", err) // Panic
+	}
+
+	if err := db.AuthenticateAdmin("bob", "test"); err != ErrNotAdmin { // Authenticate ordinary user
+		t.Fatalf("expected ErrNotAdmin, got %v", err) // Panic
+	}
+
+	issued, err := db.IssueAccountToken("alice", "test", "test", nil) // Issue token without admin scope
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	if err = db.AuthenticateAdmin("alice", issued.AccessToken); err == nil { // Authenticate admin with unscoped token
+		t.Fatal("expected admin authentication with a token lacking the admin scope to fail") // Panic
+	}
+
+	if err = db.SetAccountRole("alice", "bob", "superuser"); err != ErrRoleInvalid { // Set unknown role
+		t.Fatalf("expected ErrRoleInvalid, got %v", err) // Panic
+	}
+
+	stats, err := db.QueryAccountStats() // Query stats
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	if stats.Accounts != 2 || stats.Admins != 1 || stats.ActiveSessions != 1 { // Check wrong stats
+		t.Fatalf("unexpected stats %+v", *stats) // Panic
+	}
+
+	matches, err := db.SearchAccounts(&AccountFilter{Role: RoleUser}) // Search users
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	if len(matches) != 1 || matches[0].Name != "bob" { // Check wrong matches
+		t.Fatalf("expected only bob to have the user role, got %d matches", len(matches)) // Panic
+	}
+
+	if matches, err = db.SearchAccounts(&AccountFilter{Query: testAddress(0).String()[:8]}); err != nil || len(matches) != 1 || matches[0].Name != "alice" { // Search by address prefix
+		t.Fatalf("expected address prefix search to find alice, got %d matches (%v)", len(matches), err) // Panic
+	}
+}
+
+// TestForcePasswordReset tests that ForcePasswordReset() revokes tokens and refuses logins until the password is reset.
+func TestForcePasswordReset(t *testing.T) {
+	db := newTestDB(t) // Open test db
+
+	defer db.CloseDB() // Close db
+
+	if _, err := db.AddNewAccount("test", "test", testAddress(0).String()); err != nil { // Add account
+		t.Fatal(err) // Panic
+	}
+
+	issued, err := db.IssueAccountToken("test", "test", "test", nil) // Issue token
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	if err = db.ForcePasswordReset("admin", "test"); err != nil { // Force reset
+		t.Fatal(err) // Panic
+	}
+
+	if err = db.AuthenticateScoped("test", issued.AccessToken, ScopeRead); err == nil { // Authenticate with revoked token
+		t.Fatal("expected revoked token to be refused") // Panic
+	}
+
+	if err = db.Authenticate("test", "test"); err != ErrPasswordResetRequired { // Log in before reset
+		t.Fatalf("expected ErrPasswordResetRequired, got %v", err) // Panic
+	}
+
+	if err = db.ResetAccountPassword("test", "test", "test", true); err != ErrPasswordUnchanged { // Reset to the same password
+		t.Fatalf("expected ErrPasswordUnchanged, got %v", err) // Panic
+	}
+
+	if err = db.ResetAccountPassword("test", "test", "new", true); err != nil { // Reset password
+		t.Fatal(err) // Panic
+	}
+
+	if err = db.Authenticate("test", "new"); err != nil { // Log in after reset
+		t.Fatal(err) // Panic
+	}
+
+	if _, err = db.IssueAccountToken("test", "new", "test", nil); err != nil { // Issue token
+		t.Fatal(err) // Panic
+	}
+
+	if revoked, err := db.RevokeAccountTokens("admin", "test"); err != nil || revoked != 2 { // Revoke tokens
+		t.Fatalf("expected 2 revoked tokens, got %d (%v)", revoked, err) // Panic
+	}
+}
+
+/* END EXPORTED METHODS TESTS */
@@ -0,0 +1,250 @@
+// Package accounts defines account-related helper methods and types.
+// The accounts database, for example, is defined in this package.
+package accounts
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"sync/atomic"
+	"time"
+
+	"github.com/SummerCash/summercash-wallet-server/storage"
+)
+
+const (
+	// AuditActionLogin is the audit action of authenticating with a password.
+	AuditActionLogin = "login"
+
+	// AuditActionIssueToken is the audit action of issuing an access and refresh token pair.
+	AuditActionIssueToken = "issue_token"
+
+	// AuditActionResetPassword is the audit action of resetting an account's password.
+	AuditActionResetPassword = "reset_password"
+
+	// AuditActionRecoverAccount is the audit action of recovering an account with its recovery phrase.
+	AuditActionRecoverAccount = "recover_account"
+
+	// AuditActionEnableTwoFactor is the audit action of confirming a two-factor enrollment.
+	AuditActionEnableTwoFactor = "enable_two_factor"
+
+	// AuditActionDisableTwoFactor is the audit action of disabling two-factor authentication.
+	AuditActionDisableTwoFactor = "disable_two_factor"
+
+	// AuditActionRegenerateBackupCodes is the audit action of replacing an account's two-factor backup codes.
+	AuditActionRegenerateBackupCodes = "regenerate_backup_codes"
+
+	// AuditActionUnlockAccount is the audit action of lifting an account's failed login lockout.
+	AuditActionUnlockAccount = "unlock_account"
+
+	// AuditActionRestoreAccount is the audit action of cancelling an account's scheduled deletion.
+	AuditActionRestoreAccount = "restore_account"
+
+	// AuditActionPurgeAccount is the audit action of purging an account after its deletion grace period.
+	AuditActionPurgeAccount = "purge_account"
+
+	// AuditActionSubmitTransaction is the audit action of submitting a transaction signed outside of the server.
+	AuditActionSubmitTransaction = "submit_transaction"
+
+	// AuditActionSetRole is the audit action of an administrator setting an account's role.
+	AuditActionSetRole = "set_role"
+
+	// AuditActionFreezeAccount is the audit action of an administrator freezing an account.
+	AuditActionFreezeAccount = "freeze_account"
+
+	// AuditActionUnfreezeAccount is the audit action of an administrator lifting an account's freeze.
+	AuditActionUnfreezeAccount = "unfreeze_account"
+
+	// AuditActionForcePasswordReset is the audit action of an administrator requiring an account's password to be reset.
+	AuditActionForcePasswordReset = "force_password_reset"
+
+	// AuditActionRevokeTokens is the audit action of an administrator revoking every token issued to an account.
+	AuditActionRevokeTokens = "revoke_tokens"
+
+	// AuditActionSetSpendingLimit is the audit action of setting one of an account's spending limits.
+	AuditActionSetSpendingLimit = "set_spending_limit"
+
+	// AuditActionSetEmail is the audit action of setting (or removing) an account's email address.
+	AuditActionSetEmail = "set_email"
+
+	// AuditActionVerifyEmail is the audit action of verifying an account's email address.
+	AuditActionVerifyEmail = "verify_email"
+
+	// AuditActionFaucetClaim is the audit action of claiming from the faucet.
+	AuditActionFaucetClaim = "faucet_claim"
+)
+
+const (
+	// AuditOutcomeSuccess is the outcome of an audited action that succeeded.
+	AuditOutcomeSuccess = "success"
+
+	// AuditOutcomeFailure is the outcome of an audited action that failed.
+	AuditOutcomeFailure = "failure"
+)
+
+// DefaultAuditQueryLimit is the number of events returned by an audit query that doesn't specify a limit.
+var DefaultAuditQueryLimit = 100
+
+// MaxAuditQueryLimit is the maximum number of events returned by a single audit query.
+var MaxAuditQueryLimit = 1000
+
+var (
+	// auditBucket is the time-ordered audit event bucket key definition.
+	auditBucket = []byte("audit")
+
+	// auditSequence disambiguates the keys of audit events recorded in the same nanosecond.
+	auditSequence uint64
+)
+
+// AuditEvent represents a single security-relevant action recorded in the audit log.
+// Sensitive operations (see OperationSend, OperationExportKey and OperationDeleteAccount) are recorded under their operation names.
+type AuditEvent struct {
+	Time time.Time `json:"time"` // Time the action was taken
+
+	Username string `json:"username"` // Username the action was taken for
+
+	Action string `json:"action"` // Action
+
+	Source string `json:"source,omitempty"` // Source address the action was requested from
+
+	Outcome string `json:"outcome"` // Outcome (AuditOutcomeSuccess or AuditOutcomeFailure)
+
+	Detail string `json:"detail,omitempty"` // Action-specific detail (e.g. a token's device)
+
+	Error string `json:"error,omitempty"` // Error the action failed with
+}
+
+// AuditFilter represents the criteria an audit query matches events against. Empty criteria match any event.
+type AuditFilter struct {
+	Username string `json:"username"` // Username
+
+	Action string `json:"action"` // Action
+
+	Source string `json:"source"` // Source address
+
+	Outcome string `json:"outcome"` // Outcome
+
+	Since time.Time `json:"since"` // Earliest event time
+	Until time.Time `json:"until"` // Latest event time
+
+	Limit int `json:"limit"` // Maximum number of events (DefaultAuditQueryLimit if zero)
+}
+
+/* BEGIN EXPORTED METHODS */
+
+// RecordAuditEvent appends an event for a given username and action to the audit log, attributing it to the source address of the working database handle.
+// The outcome of the event is derived from a given error (nil meaning success). Failing to record an event is logged, but not returned.
+func (db *DB) RecordAuditEvent(username string, action string, detail string, err error) {
+	event := &AuditEvent{
+		Time:     time.Now().UTC(),            // Set time
+		Username: NormalizeUsername(username), // Set username
+		Action:   action,                      // Set action
+		Source:   db.source,                   // Set source
+		Outcome:  AuditOutcomeSuccess,         // Set outcome
+		Detail:   detail,                      // Set detail
+	} // Init event
+
+	if err != nil { // Check failed
+		(*event).Outcome = AuditOutcomeFailure // Set outcome
+		(*event).Error = err.Error()           // Set error
+	}
+
+	err = db.store.Update(func(tx storage.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(auditBucket) // Create audit bucket
+
+		if err != nil { // Check for errors
+			return err // Return found error
+		}
+
+		marshaledEvent, err := json.Marshal(event) // Marshal event
+
+		if err != nil { // Check for errors
+			return err // Return found error
+		}
+
+		return bucket.Put(auditKey(event.Time), marshaledEvent) // Append event
+	}) // Append event
+
+	if err != nil { // Check for errors
+		logger.Errorf("failed to record %s audit event for account %s: %s", action, username, err.Error()) // Log error
+	}
+}
+
+// QueryAuditEvents gets the most recent audit events matching a given filter, newest first.
+func (db *DB) QueryAuditEvents(filter *AuditFilter) ([]*AuditEvent, error) {
+	limit := filter.Limit // Get limit
+
+	if limit <= 0 { // Check no limit
+		limit = DefaultAuditQueryLimit // Set default limit
+	} else if limit > MaxAuditQueryLimit { // Check limit too high
+		limit = MaxAuditQueryLimit // Cap limit
+	}
+
+	events := []*AuditEvent{} // Init events buffer
+
+	err := db.store.View(func(tx storage.Tx) error {
+		bucket := tx.Bucket(auditBucket) // Get audit bucket
+
+		if bucket == nil { // Check nothing recorded
+			return nil // No events
+		}
+
+		return bucket.ForEach(func(key []byte, value []byte) error {
+			eventTime := time.Unix(0, int64(binary.BigEndian.Uint64(key))) // Get event time from key
+
+			if (!filter.Since.IsZero() && eventTime.Before(filter.Since)) || (!filter.Until.IsZero() && eventTime.After(filter.Until)) { // Check out of range
+				return nil // Skip without decoding
+			}
+
+			event := &AuditEvent{} // Init event buffer
+
+			if err := json.Unmarshal(value, event); err != nil { // Decode event
+				return err // Return found error
+			}
+
+			if filter.matches(event) { // Check matches
+				events = append(events, event) // Append event
+			}
+
+			return nil // Continue
+		}) // Collect matching events, oldest first
+	}) // Query events
+
+	if err != nil { // Check for errors
+		return nil, err // Return found error
+	}
+
+	if len(events) > limit { // Check too many events
+		events = events[len(events)-limit:] // Keep most recent events
+	}
+
+	for i, j := 0, len(events)-1; i < j; i, j = i+1, j-1 { // Reverse events
+		events[i], events[j] = events[j], events[i] // Swap
+	}
+
+	return events, nil // Return events
+}
+
+/* END EXPORTED METHODS */
+
+/* BEGIN INTERNAL METHODS */
+
+// matches checks whether or not a given event matches the non-time criteria of a filter.
+func (filter *AuditFilter) matches(event *AuditEvent) bool {
+	return (filter.Username == "" || NormalizeUsername(filter.Username) == event.Username) && // Check username
+		(filter.Action == "" || filter.Action == event.Action) && // Check action
+		(filter.Source == "" || filter.Source == event.Source) && // Check source
+		(filter.Outcome == "" || filter.Outcome == event.Outcome) // Check outcome
+}
+
+// auditKey generates the key of an audit event recorded at a given time.
+// Keys sort in the order events were recorded in.
+func auditKey(t time.Time) []byte {
+	key := make([]byte, 16) // Init key buffer
+
+	binary.BigEndian.PutUint64(key, uint64(t.UnixNano()))                    // Set time
+	binary.BigEndian.PutUint64(key[8:], atomic.AddUint64(&auditSequence, 1)) // Set sequence
+
+	return key // Return key
+}
+
+/* END INTERNAL METHODS */
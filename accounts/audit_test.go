@@ -0,0 +1,73 @@
+// Package accounts defines account-related helper methods and types.
+// The accounts database, for example, is defined in this package.
+package accounts
+
+import (
+	"testing"
+	"time"
+)
+
+/* BEGIN EXPORTED METHODS TESTS */
+
+// TestQueryAuditEvents tests that security-relevant actions are recorded in the audit log, and that QueryAuditEvents() filters them.
+func TestQueryAuditEvents(t *testing.T) {
+	db := newTestDB(t) // Open test db
+
+	defer db.CloseDB() // Close db
+
+	if _, err := db.AddNewAccount("test", "test", testAddress(0).String()); err != nil { // Add account
+		t.Fatal(err) // Panic
+	}
+
+	source := db.FromSource("127.0.0.1") // Get source handle
+
+	if err := source.Authenticate("test", "wrong"); err != ErrPasswordInvalid { // Fail login
+		t.Fatalf("expected ErrPasswordInvalid, got %v", err) // Panic
+	}
+
+	if err := source.Authenticate("test", "test"); err != nil { // Log in
+		t.Fatal(err) // Panic
+	}
+
+	if _, err := db.IssueAccountToken("test", "test", "phone", nil); err != nil { // Issue token
+		t.Fatal(err) // Panic
+	}
+
+	events, err := db.QueryAuditEvents(&AuditFilter{Username: "TEST"}) // Query account events
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	if len(events) != 3 { // Check event count
+		t.Fatalf("expected 3 events, got %d", len(events)) // Panic
+	}
+
+	if events[0].Action != AuditActionIssueToken || events[0].Detail != "phone" || events[0].Source != "" { // Check newest first
+		t.Fatalf("unexpected newest event %+v", events[0]) // Panic
+	}
+
+	if events[2].Outcome != AuditOutcomeFailure || events[2].Source != "127.0.0.1" || events[2].Error != ErrPasswordInvalid.Error() { // Check failed login
+		t.Fatalf("unexpected oldest event %+v", events[2]) // Panic
+	}
+
+	for _, filter := range []*AuditFilter{
+		{Action: AuditActionLogin, Outcome: AuditOutcomeSuccess}, // Successful logins
+		{Source: "127.0.0.1", Limit: 1},                          // Limited
+		{Since: events[0].Time},                                  // Since
+	} {
+		if events, err = db.QueryAuditEvents(filter); err != nil { // Query events
+			t.Fatal(err) // Panic
+		}
+
+		if len(events) != 1 { // Check event count
+			t.Fatalf("expected 1 event for filter %+v, got %d", filter, len(events)) // Panic
+		}
+	}
+
+	if events, err = db.QueryAuditEvents(&AuditFilter{Until: time.Now().Add(-time.Hour)}); err != nil || len(events) != 0 { // Query before any event
+		t.Fatalf("expected no events, got %d (%v)", len(events), err) // Panic
+	}
+}
+
+/* END EXPORTED METHODS TESTS */
@@ -0,0 +1,260 @@
+// Package accounts defines account-related helper methods and types.
+// The accounts database, for example, is defined in this package.
+package accounts
+
+import (
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/SummerCash/go-summercash/common"
+)
+
+var (
+	// ErrContactLabelInvalid is an error definition describing a contact label that is empty, too long, or that could be mistaken for an address.
+	ErrContactLabelInvalid = errors.New("contact label must be between 1 and 64 characters long, and must not contain 0x")
+
+	// ErrContactTargetInvalid is an error definition describing a contact that doesn't name exactly one of an address or a username.
+	ErrContactTargetInvalid = errors.New("contact must have exactly one of an address or a username")
+
+	// ErrContactAlreadyExists is an error definition describing a contact label that is already taken.
+	ErrContactAlreadyExists = errors.New("contact with label already exists")
+
+	// ErrContactDoesNotExist is an error definition describing a contact label that could not be found.
+	ErrContactDoesNotExist = errors.New("contact does not exist")
+
+	// ErrTooManyContacts is an error definition describing an address book that has reached MaxContacts.
+	ErrTooManyContacts = errors.New("too many contacts")
+)
+
+var (
+	// MaxContacts is the maximum number of contacts an account may store.
+	MaxContacts = 500
+
+	// maxContactLabelLength is the maximum length of a contact label, in characters.
+	maxContactLabelLength = 64
+)
+
+// Contact represents an entry in an account's address book.
+type Contact struct {
+	Label string `json:"label"` // Label (unique per account, case-insensitively)
+
+	Address string `json:"address,omitempty"` // Hex address (if the contact is an address)
+
+	Username string `json:"username,omitempty"` // Username (if the contact is an account)
+
+	Note string `json:"note,omitempty"` // Free-form note
+
+	CreatedAt time.Time `json:"created_at"` // Creation time
+	UpdatedAt time.Time `json:"updated_at"` // Last update time
+}
+
+/* BEGIN EXPORTED METHODS */
+
+// ListContacts gets the address book of the account with a given username.
+func (db *DB) ListContacts(name string) ([]*Contact, error) {
+	account, err := db.QueryAccountByUsername(name) // Query account
+
+	if err != nil { // Check for errors
+		return nil, err // Return found error
+	}
+
+	if account.Contacts == nil { // Check no contacts
+		return []*Contact{}, nil // Return empty address book
+	}
+
+	return account.Contacts, nil // Return contacts
+}
+
+// AddContact adds a given contact to the address book of the account with a given username.
+func (db *DB) AddContact(name string, contact *Contact) (*Contact, error) {
+	if err := db.validateContact(contact); err != nil { // Validate contact
+		return nil, err // Return found error
+	}
+
+	_, err := db.updateAccount(name, func(account *Account) error {
+		if account.findContact(contact.Label) != -1 { // Check label taken
+			return ErrContactAlreadyExists // Return error
+		}
+
+		if len(account.Contacts) >= MaxContacts { // Check address book full
+			return ErrTooManyContacts // Return error
+		}
+
+		(*contact).CreatedAt = time.Now()        // Set creation time
+		(*contact).UpdatedAt = contact.CreatedAt // Set update time
+
+		(*account).Contacts = append(account.Contacts, contact) // Append contact
+
+		return nil // No error occurred, return nil
+	}) // Add contact
+
+	if err != nil { // Check for errors
+		return nil, err // Return found error
+	}
+
+	return contact, nil // Return added contact
+}
+
+// UpdateContact replaces the contact with a given label in the address book of the account with a given username.
+// The contact may be relabeled.
+func (db *DB) UpdateContact(name string, label string, contact *Contact) (*Contact, error) {
+	if err := db.validateContact(contact); err != nil { // Validate contact
+		return nil, err // Return found error
+	}
+
+	_, err := db.updateAccount(name, func(account *Account) error {
+		i := account.findContact(label) // Find contact
+
+		if i == -1 { // Check no contact with label
+			return ErrContactDoesNotExist // Return error
+		}
+
+		if j := account.findContact(contact.Label); j != -1 && j != i { // Check new label taken by another contact
+			return ErrContactAlreadyExists // Return error
+		}
+
+		(*contact).CreatedAt = account.Contacts[i].CreatedAt // Keep creation time
+		(*contact).UpdatedAt = time.Now()                    // Set update time
+
+		account.Contacts[i] = contact // Replace contact
+
+		return nil // No error occurred, return nil
+	}) // Update contact
+
+	if err != nil { // Check for errors
+		return nil, err // Return found error
+	}
+
+	return contact, nil // Return updated contact
+}
+
+// RemoveContact removes the contact with a given label from the address book of the account with a given username.
+func (db *DB) RemoveContact(name string, label string) error {
+	_, err := db.updateAccount(name, func(account *Account) error {
+		i := account.findContact(label) // Find contact
+
+		if i == -1 { // Check no contact with label
+			return ErrContactDoesNotExist // Return error
+		}
+
+		(*account).Contacts = append(account.Contacts[:i], account.Contacts[i+1:]...) // Remove contact
+
+		return nil // No error occurred, return nil
+	}) // Remove contact
+
+	return err // Return error (if any)
+}
+
+// ResolveRecipient resolves the recipient of a transaction sent by the account with a given username to an address.
+// The recipient may be a hex address, a label in the sender's address book, or a username. Labels take precedence over usernames.
+func (db *DB) ResolveRecipient(name string, recipient string) (common.Address, error) {
+	if strings.Contains(recipient, "0x") { // Check is address
+		return common.StringToAddress(recipient) // Parse address
+	}
+
+	if sender, err := db.QueryAccountByUsername(name); err == nil { // Check sender exists
+		if i := sender.findContact(recipient); i != -1 { // Check is contact label
+			return db.resolveContact(sender.Contacts[i]) // Resolve contact
+		}
+	}
+
+	recipientAccount, err := db.QueryAccountByUsername(recipient) // Query account
+
+	if err != nil { // Check for errors
+		return common.Address{}, err // Return found error
+	}
+
+	return recipientAccount.Address, nil // Return address
+}
+
+// ContactLabels gets the labels of the contacts in the address book of the account with a given username, keyed by the addresses they resolve to.
+// Contacts naming accounts that no longer exist are skipped.
+func (db *DB) ContactLabels(name string) (map[common.Address]string, error) {
+	contacts, err := db.ListContacts(name) // List contacts
+
+	if err != nil { // Check for errors
+		return nil, err // Return found error
+	}
+
+	labels := make(map[common.Address]string) // Init labels buffer
+
+	for _, contact := range contacts { // Iterate through contacts
+		if address, err := db.resolveContact(contact); err == nil { // Check resolvable
+			labels[address] = contact.Label // Set label
+		}
+	}
+
+	return labels, nil // Return labels
+}
+
+/* END EXPORTED METHODS */
+
+/* BEGIN INTERNAL METHODS */
+
+// validateContact checks that a contact has a valid label, and names exactly one existing account or valid address.
+// The contact's address and username are normalized.
+func (db *DB) validateContact(contact *Contact) error {
+	(*contact).Label = strings.TrimSpace(contact.Label) // Trim label
+
+	if contact.Label == "" || utf8.RuneCountInString(contact.Label) > maxContactLabelLength || strings.Contains(contact.Label, "0x") { // Check invalid label
+		return ErrContactLabelInvalid // Return error
+	}
+
+	if (contact.Address == "") == (contact.Username == "") { // Check not exactly one target
+		return ErrContactTargetInvalid // Return error
+	}
+
+	if contact.Address != "" { // Check is address
+		address, err := common.StringToAddress(contact.Address) // Parse address
+
+		if err != nil { // Check for errors
+			return err // Return found error
+		}
+
+		(*contact).Address = address.String() // Normalize address
+
+		return nil // Valid
+	}
+
+	account, err := db.QueryAccountByUsername(contact.Username) // Query account
+
+	if err != nil { // Check for errors
+		return err // Return found error
+	}
+
+	(*contact).Username = account.Name // Normalize username
+
+	return nil // Valid
+}
+
+// resolveContact resolves a contact to the address it names.
+func (db *DB) resolveContact(contact *Contact) (common.Address, error) {
+	if contact.Address != "" { // Check is address
+		return common.StringToAddress(contact.Address) // Parse address
+	}
+
+	account, err := db.QueryAccountByUsername(contact.Username) // Query account
+
+	if err != nil { // Check for errors
+		return common.Address{}, err // Return found error
+	}
+
+	return account.Address, nil // Return address
+}
+
+// findContact gets the index of the contact with a given label (compared case-insensitively) in an account's address book, or -1 if there is none.
+func (account *Account) findContact(label string) int {
+	label = strings.TrimSpace(label) // Trim label
+
+	for i, contact := range account.Contacts { // Iterate through contacts
+		if strings.EqualFold(contact.Label, label) { // Check matches
+			return i // Return index
+		}
+	}
+
+	return -1 // No contact with label
+}
+
+/* END INTERNAL METHODS */
@@ -0,0 +1,104 @@
+// Package accounts defines account-related helper methods and types.
+// The accounts database, for example, is defined in this package.
+package accounts
+
+import "testing"
+
+/* BEGIN EXPORTED METHODS TESTS */
+
+// TestContacts tests the functionality of the AddContact(), UpdateContact() and RemoveContact() helper methods.
+func TestContacts(t *testing.T) {
+	db := newTestDB(t) // Open test db
+
+	defer db.CloseDB() // Close db
+
+	for i, name := range []string{"alice", "bob"} { // Iterate through usernames
+		if _, err := db.AddNewAccount(name, "test", testAddress(i).String()); err != nil { // Add account
+			t.Fatal(err) // Panic
+		}
+	}
+
+	for contact, expected := range map[*Contact]error{
+		{Label: "", Username: "bob"}:                       ErrContactLabelInvalid,  // Empty label
+		{Label: "0xbob", Username: "bob"}:                  ErrContactLabelInvalid,  // Address-like label
+		{Label: "bob", Username: "bob", Address: "0x0000"}: ErrContactTargetInvalid, // Both targets
+		{Label: "bob"}:                      ErrContactTargetInvalid, // No target
+		{Label: "carol", Username: "carol"}: ErrAccountDoesNotExist,  // Unknown username
+	} {
+		if _, err := db.AddContact("alice", contact); err != expected { // Add invalid contact
+			t.Errorf("expected %v for contact %+v, got %v", expected, contact, err) // Log error
+		}
+	}
+
+	if _, err := db.AddContact("alice", &Contact{Label: "Savings", Address: testAddress(2).String(), Note: "cold wallet"}); err != nil { // Add address contact
+		t.Fatal(err) // Panic
+	}
+
+	if _, err := db.AddContact("alice", &Contact{Label: "savings", Username: "bob"}); err != ErrContactAlreadyExists { // Add duplicate label
+		t.Fatalf("expected ErrContactAlreadyExists, got %v", err) // Panic
+	}
+
+	if _, err := db.UpdateContact("alice", "SAVINGS", &Contact{Label: "bob", Username: "BOB"}); err != nil { // Relabel as username contact
+		t.Fatal(err) // Panic
+	}
+
+	contacts, err := db.ListContacts("alice") // List contacts
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	if len(contacts) != 1 || contacts[0].Label != "bob" || contacts[0].Username != "bob" || contacts[0].CreatedAt.IsZero() { // Check updated
+		t.Fatalf("unexpected contacts %+v", contacts) // Panic
+	}
+
+	if err = db.RemoveContact("alice", "bob"); err != nil { // Remove contact
+		t.Fatal(err) // Panic
+	}
+
+	if err = db.RemoveContact("alice", "bob"); err != ErrContactDoesNotExist { // Remove removed contact
+		t.Fatalf("expected ErrContactDoesNotExist, got %v", err) // Panic
+	}
+}
+
+// TestResolveRecipient tests the functionality of the ResolveRecipient() helper method.
+func TestResolveRecipient(t *testing.T) {
+	db := newTestDB(t) // Open test db
+
+	defer db.CloseDB() // Close db
+
+	for i, name := range []string{"alice", "bob"} { // Iterate through usernames
+		if _, err := db.AddNewAccount(name, "test", testAddress(i).String()); err != nil { // Add account
+			t.Fatal(err) // Panic
+		}
+	}
+
+	if _, err := db.AddContact("alice", &Contact{Label: "bob", Address: testAddress(2).String()}); err != nil { // Add contact shadowing username
+		t.Fatal(err) // Panic
+	}
+
+	for recipient, expected := range map[string]string{
+		testAddress(3).String(): testAddress(3).String(), // Address
+		"Bob":                   testAddress(2).String(), // Contact label (takes precedence over username)
+	} {
+		if address, err := db.ResolveRecipient("alice", recipient); err != nil || address.String() != expected { // Resolve recipient
+			t.Errorf("expected %s to resolve to %s, got %s (%v)", recipient, expected, address.String(), err) // Log error
+		}
+	}
+
+	if address, err := db.ResolveRecipient("bob", "bob"); err != nil || address != testAddress(1) { // Resolve username without contact
+		t.Fatalf("expected username to resolve to its address, got %s (%v)", address.String(), err) // Panic
+	}
+
+	labels, err := db.ContactLabels("alice") // Get contact labels
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	if labels[testAddress(2)] != "bob" { // Check labeled
+		t.Fatalf("expected address to be labeled bob, got %s", labels[testAddress(2)]) // Panic
+	}
+}
+
+/* END EXPORTED METHODS TESTS */
@@ -0,0 +1,324 @@
+// Package accounts defines account-related helper methods and types.
+// The accounts database, for example, is defined in this package.
+package accounts
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	summercashCommon "github.com/SummerCash/go-summercash/common"
+
+	"github.com/SummerCash/summercash-wallet-server/common"
+	"github.com/SummerCash/summercash-wallet-server/crypto"
+	"github.com/SummerCash/summercash-wallet-server/storage"
+)
+
+var (
+	// ErrAccountPendingDeletion is an error definition describing an attempt to use an account that has been scheduled for deletion.
+	ErrAccountPendingDeletion = errors.New("account is pending deletion; restore it to continue using it")
+
+	// ErrAccountNotPendingDeletion is an error definition describing an attempt to restore an account that has not been scheduled for deletion.
+	ErrAccountNotPendingDeletion = errors.New("account is not pending deletion")
+)
+
+var (
+	// DeletionGracePeriod is the duration for which a deleted account can be restored before it is purged.
+	// If zero, deleted accounts are purged immediately.
+	DeletionGracePeriod = 30 * 24 * time.Hour
+
+	// ArchiveDeletedKeystores determines whether or not the keystores of purged accounts are archived (along with the account record
+	// wrapping their data key) in the data/deleted_keystores directory, rather than destroyed.
+	ArchiveDeletedKeystores = true
+)
+
+// deletedAccountArchive represents the archive of a purged account.
+type deletedAccountArchive struct {
+	Account *Account `json:"account"` // Account record (holding the key encryption metadata)
+
+	Keystores map[string][]byte `json:"keystores"` // Keystore file name => keystore contents
+
+	PurgedAt time.Time `json:"purged_at"` // Purge time
+}
+
+/* BEGIN EXPORTED METHODS */
+
+// PendingDeletion checks whether or not an account has been scheduled for deletion.
+func (account *Account) PendingDeletion() bool {
+	return !account.DeleteAfter.IsZero() // Check has deletion time
+}
+
+// RestoreAccount cancels the scheduled deletion of the account with a given username, given its password.
+// Tokens revoked when the deletion was scheduled are not restored.
+func (db *DB) RestoreAccount(name string, password string) (err error) {
+	defer func() { db.RecordAuditEvent(name, AuditActionRestoreAccount, "", err) }() // Record attempt
+
+	account, err := db.QueryAccountByUsername(name) // Query account
+
+	if err != nil { // Check for errors
+		return err // Return found error
+	}
+
+	if !account.PendingDeletion() { // Check not pending deletion
+		return ErrAccountNotPendingDeletion // Return error
+	}
+
+	if err = db.verifyPassword(account, password); err != ErrAccountPendingDeletion { // Verify salt (pending accounts only verify as pending)
+		return err // Return found error
+	}
+
+	_, err = db.updateAccount(name, func(account *Account) error {
+		if !account.PendingDeletion() { // Check restored concurrently
+			return ErrAccountNotPendingDeletion // Return error
+		}
+
+		(*account).DeleteAfter = time.Time{} // Cancel deletion
+
+		return nil // No error occurred, return nil
+	}) // Cancel deletion
+
+	if err != nil { // Check for errors
+		return err // Return found error
+	}
+
+	logger.Infof("restored account %s", account.Name) // Log restore
+
+	return nil // No error occurred, return nil
+}
+
+// PurgeDeletedAccounts removes every account whose deletion grace period has passed from the working database,
+// archiving or destroying its keystore (see ArchiveDeletedKeystores). Returns the number of accounts purged.
+func (db *DB) PurgeDeletedAccounts() (int, error) {
+	accounts, err := db.QueryAllAccounts() // Query accounts
+
+	if err != nil { // Check for errors
+		return 0, err // Return found error
+	}
+
+	purged := 0 // Init purged counter
+
+	for _, account := range accounts { // Iterate through accounts
+		if !account.PendingDeletion() || time.Now().Before(account.DeleteAfter) { // Check not due
+			continue // Skip
+		}
+
+		err = db.purgeAccount(account) // Purge account
+
+		if err == ErrAccountNotPendingDeletion { // Check restored since queried
+			continue // Skip
+		}
+
+		db.RecordAuditEvent(account.Name, AuditActionPurgeAccount, "", err) // Record purge
+
+		if err != nil { // Check for errors
+			return purged, err // Return found error
+		}
+
+		purged++ // Increment counter
+	}
+
+	return purged, nil // Return purged count
+}
+
+// StartIntermittentDeletionPurge purges accounts whose deletion grace period has passed from the working database at a given interval.
+// This method blocks, and should be started in its own goroutine.
+func (db *DB) StartIntermittentDeletionPurge(interval time.Duration) {
+	ticker := time.NewTicker(interval) // Init ticker
+
+	defer ticker.Stop() // Stop ticker
+
+	for range ticker.C { // Wait for tick
+		purged, err := db.PurgeDeletedAccounts() // Purge accounts
+
+		if err != nil { // Check for errors
+			logger.Errorf("errored while purging deleted accounts: %s", err.Error()) // Log error
+
+			continue // Try again next tick
+		}
+
+		if purged > 0 { // Check purged any
+			logger.Infof("purged %d deleted accounts", purged) // Log purge
+		}
+	}
+}
+
+/* END EXPORTED METHODS */
+
+/* BEGIN INTERNAL METHODS */
+
+// scheduleDeletion schedules the deletion of the account with a given username after the deletion grace period,
+// revoking every token issued to the account. Accounts are purged immediately if there is no grace period.
+func (db *DB) scheduleDeletion(name string) error {
+	if DeletionGracePeriod <= 0 { // Check no grace period
+		account, err := db.QueryAccountByUsername(name) // Query account
+
+		if err != nil { // Check for errors
+			return err // Return found error
+		}
+
+		return db.purgeAccount(account) // Purge account
+	}
+
+	account, err := db.updateAccount(name, func(account *Account) error {
+		if account.PendingDeletion() { // Check already scheduled
+			return ErrAccountPendingDeletion // Return error
+		}
+
+		(*account).DeleteAfter = time.Now().Add(DeletionGracePeriod) // Schedule deletion
+		(*account).Tokens = []*Token{}                               // Revoke all tokens
+
+		return nil // No error occurred, return nil
+	}) // Schedule deletion
+
+	if err != nil { // Check for errors
+		return err // Return found error
+	}
+
+	logger.Infof("scheduled deletion of account %s after %s", account.Name, account.DeleteAfter.Format(time.RFC3339)) // Log schedule
+
+	return nil // No error occurred, return nil
+}
+
+// purgeAccount removes an account from the working database, and archives or destroys its keystore files.
+// Archives are written before the account is removed, so that a failed archive never leaves the key unrecoverable.
+func (db *DB) purgeAccount(account *Account) error {
+	keystorePaths := accountKeystorePaths(account) // Get keystore paths
+
+	if ArchiveDeletedKeystores && len(keystorePaths) > 0 { // Check should archive
+		if err := archiveDeletedAccount(account, keystorePaths); err != nil { // Archive account
+			return err // Return found error
+		}
+	}
+
+	err := db.store.Update(func(tx storage.Tx) error {
+		key := accountKey(tx, account.Name) // Resolve account key
+
+		if key == nil { // Check already removed
+			return ErrAccountDoesNotExist // Return error
+		}
+
+		current, err := AccountFromBytes(tx.Bucket(accountsBucket).Get(key)) // Deserialize account bytes
+
+		if err != nil { // Check for errors
+			return err // Return found error
+		}
+
+		if !current.DeleteAfter.Equal(account.DeleteAfter) { // Check restored (or rescheduled) since queried
+			return ErrAccountNotPendingDeletion // Return error
+		}
+
+		return deleteAccount(tx, account) // Delete account
+	}) // Delete account
+
+	if err != nil { // Check for errors
+		return err // Return found error
+	}
+
+	for _, path := range keystorePaths { // Iterate through keystores
+		if ArchiveDeletedKeystores { // Check archived
+			err = os.Remove(path) // Remove keystore
+		} else {
+			err = destroyFile(path) // Destroy keystore
+		}
+
+		if err != nil { // Check for errors
+			logger.Errorf("failed to remove keystore %s of purged account %s: %s", path, account.Name, err.Error()) // Log error
+		}
+	}
+
+	logger.Infof("purged account %s", account.Name) // Log purge
+
+	return nil // No error occurred, return nil
+}
+
+// accountKeystorePaths gets the paths of the encrypted keystores of every address of an account, and of its plaintext legacy keystore, that exist on disk.
+func accountKeystorePaths(account *Account) []string {
+	var paths []string // Init paths buffer
+
+	candidates := []string{filepath.FromSlash(fmt.Sprintf("%s/keystore/account_%s.json", summercashCommon.DataDir, account.Address.String()))} // Init candidates with legacy keystore
+
+	for _, address := range account.Addresses() { // Iterate through addresses
+		candidates = append(candidates, KeystorePath(address)) // Append encrypted keystore
+	}
+
+	for _, path := range candidates {
+		if _, err := os.Stat(path); err == nil { // Check exists
+			paths = append(paths, path) // Append path
+		}
+	}
+
+	return paths // Return paths
+}
+
+// archiveDeletedAccount writes an account record, along with the contents of the given keystore files, to the deleted keystores directory.
+func archiveDeletedAccount(account *Account, keystorePaths []string) error {
+	archive := &deletedAccountArchive{
+		Account:   account,                 // Set account
+		Keystores: make(map[string][]byte), // Init keystores
+		PurgedAt:  time.Now().UTC(),        // Set purge time
+	} // Init archive
+
+	for _, path := range keystorePaths { // Iterate through keystores
+		keystoreBytes, err := ioutil.ReadFile(path) // Read keystore
+
+		if err != nil { // Check for errors
+			return err // Return found error
+		}
+
+		(*archive).Keystores[filepath.Base(filepath.Dir(path))+"/"+filepath.Base(path)] = keystoreBytes // Set keystore
+	}
+
+	archiveBytes, err := json.MarshalIndent(archive, "", "  ") // Marshal archive
+
+	if err != nil { // Check for errors
+		return err // Return found error
+	}
+
+	archiveDir := filepath.Join(common.DataDir, "deleted_keystores") // Get archive dir
+
+	if err = common.CreateDirIfDoesNotExit(archiveDir); err != nil { // Create archive dir
+		return err // Return found error
+	}
+
+	return ioutil.WriteFile(filepath.Join(archiveDir, fmt.Sprintf("account_%s_%s_%d.json", account.Name, account.Address.String(), archive.PurgedAt.Unix())), archiveBytes, 0600) // Write archive
+}
+
+// destroyFile overwrites a file with random bytes before removing it.
+// Overwriting is best-effort: journaling and copy-on-write filesystems, and flash storage, may retain the original contents.
+func destroyFile(path string) error {
+	info, err := os.Stat(path) // Get file info
+
+	if err != nil { // Check for errors
+		return err // Return found error
+	}
+
+	noise, err := crypto.RandomBytes(int(info.Size())) // Generate noise
+
+	if err != nil { // Check for errors
+		return err // Return found error
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY, 0600) // Open file
+
+	if err != nil { // Check for errors
+		return err // Return found error
+	}
+
+	if _, err = file.Write(noise); err == nil { // Overwrite file
+		err = file.Sync() // Flush overwrite
+	}
+
+	file.Close() // Close file
+
+	if err != nil { // Check for errors
+		return err // Return found error
+	}
+
+	return os.Remove(path) // Remove file
+}
+
+/* END INTERNAL METHODS */
@@ -0,0 +1,131 @@
+// Package accounts defines account-related helper methods and types.
+// The accounts database, for example, is defined in this package.
+package accounts
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/SummerCash/summercash-wallet-server/common"
+	"github.com/SummerCash/summercash-wallet-server/storage"
+)
+
+/* BEGIN EXPORTED METHODS TESTS */
+
+// TestDeleteAccountGracePeriod tests that deleted accounts can't be used, can be restored, and are purged once their grace period has passed.
+func TestDeleteAccountGracePeriod(t *testing.T) {
+	setTestDataDir(t) // Use temp data dir
+
+	db := newTestDB(t) // Open test db
+
+	defer db.CloseDB() // Close db
+
+	privateKey, err := ecdsa.GenerateKey(elliptic.P521(), rand.Reader) // Generate private key
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	account, err := db.AddNewAccount("test", "test", testAddress(0).String()) // Add account
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	if err = account.encryptPrivateKey(privateKey, "test"); err != nil { // Encrypt private key
+		t.Fatal(err) // Panic
+	}
+
+	if err = db.store.Update(func(tx storage.Tx) error { return putAccount(tx, account) }); err != nil { // Persist account
+		t.Fatal(err) // Panic
+	}
+
+	if err = db.DeleteAccount("test", "test", ""); err != nil { // Delete account
+		t.Fatal(err) // Panic
+	}
+
+	if err = db.Authenticate("test", "test"); err != ErrAccountPendingDeletion { // Log in while pending deletion
+		t.Fatalf("expected ErrAccountPendingDeletion, got %v", err) // Panic
+	}
+
+	if _, err = db.UnlockPrivateKey("test", "test", OperationSend, ""); err != ErrAccountPendingDeletion { // Send while pending deletion
+		t.Fatalf("expected ErrAccountPendingDeletion, got %v", err) // Panic
+	}
+
+	if err = db.RestoreAccount("test", "wrong"); err != ErrPasswordInvalid { // Restore with wrong password
+		t.Fatalf("expected ErrPasswordInvalid, got %v", err) // Panic
+	}
+
+	if err = db.RestoreAccount("test", "test"); err != nil { // Restore
+		t.Fatal(err) // Panic
+	}
+
+	if err = db.Authenticate("test", "test"); err != nil { // Log in after restore
+		t.Fatal(err) // Panic
+	}
+
+	if err = db.DeleteAccount("test", "test", ""); err != nil { // Delete account again
+		t.Fatal(err) // Panic
+	}
+
+	if purged, err := db.PurgeDeletedAccounts(); err != nil || purged != 0 { // Purge within grace period
+		t.Fatalf("expected no accounts purged within grace period, got %d (%v)", purged, err) // Panic
+	}
+
+	_, err = db.updateAccount("test", func(account *Account) error {
+		(*account).DeleteAfter = time.Now().Add(-time.Second) // Expire grace period
+
+		return nil // No error occurred, return nil
+	}) // Expire grace period
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	if purged, err := db.PurgeDeletedAccounts(); err != nil || purged != 1 { // Purge after grace period
+		t.Fatalf("expected 1 account purged, got %d (%v)", purged, err) // Panic
+	}
+
+	if _, err = db.QueryAccountByUsername("test"); err != ErrAccountDoesNotExist { // Check removed
+		t.Fatalf("expected ErrAccountDoesNotExist, got %v", err) // Panic
+	}
+
+	if _, err = os.Stat(KeystorePath(account.Address)); !os.IsNotExist(err) { // Check keystore removed
+		t.Fatal("purged account keystore should have been removed") // Panic
+	}
+
+	if archives, _ := ioutil.ReadDir(filepath.Join(common.DataDir, "deleted_keystores")); len(archives) != 1 { // Check archived
+		t.Fatalf("expected 1 archived keystore, got %d", len(archives)) // Panic
+	}
+}
+
+/* END EXPORTED METHODS TESTS */
+
+/* BEGIN INTERNAL METHODS TESTS */
+
+// TestDestroyFile tests the functionality of the destroyFile() helper method.
+func TestDestroyFile(t *testing.T) {
+	setTestDataDir(t) // Use temp data dir
+
+	path := filepath.Join(common.DataDir, "keystore.json") // Get path
+
+	if err := ioutil.WriteFile(path, []byte("secret"), 0600); err != nil { // Write file
+		t.Fatal(err) // Panic
+	}
+
+	if err := destroyFile(path); err != nil { // Destroy file
+		t.Fatal(err) // Panic
+	}
+
+	if _, err := os.Stat(path); !os.IsNotExist(err) { // Check removed
+		t.Fatal("destroyed file should have been removed") // Panic
+	}
+}
+
+/* END INTERNAL METHODS TESTS */
@@ -0,0 +1,192 @@
+// Package accounts defines account-related helper methods and types.
+// The accounts database, for example, is defined in this package.
+package accounts
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	summercashCommon "github.com/SummerCash/go-summercash/common"
+	"github.com/SummerCash/summercash-wallet-server/storage"
+)
+
+var (
+	// DefaultDirectoryPageSize is the number of accounts returned by a directory search that doesn't specify a limit.
+	DefaultDirectoryPageSize = 20
+
+	// MaxDirectoryPageSize is the maximum number of accounts returned by a single directory search.
+	MaxDirectoryPageSize = 100
+)
+
+var (
+	// directoryBucket is the sorted username => address index bucket key definition, holding only discoverable accounts.
+	directoryBucket = []byte("directory")
+
+	// errDirectoryPageFull is an error used to stop iterating over the directory once a page has been filled.
+	errDirectoryPageFull = errors.New("directory page full")
+)
+
+// DirectoryEntry represents a single discoverable account in the directory.
+type DirectoryEntry struct {
+	Username string `json:"username"` // Username
+
+	Address summercashCommon.Address `json:"address"` // Primary address
+}
+
+// DirectoryPage represents a single page of directory search results.
+type DirectoryPage struct {
+	Entries []*DirectoryEntry `json:"entries"` // Matching accounts, sorted by username
+
+	NextCursor string `json:"next_cursor,omitempty"` // Cursor of the next page (empty if this is the last page)
+}
+
+/* BEGIN EXPORTED METHODS */
+
+// Discoverable checks whether or not an account is listed in the directory.
+// Accounts are discoverable unless their owner has hidden them, and stop being listed while pending deletion.
+func (account *Account) Discoverable() bool {
+	return !account.HiddenFromDirectory && !account.PendingDeletion() // Check discoverable
+}
+
+// SetDiscoverable lists or hides the account with a given username in the directory, given its password (or an access token carrying the send scope).
+func (db *DB) SetDiscoverable(name string, secret string, discoverable bool) error {
+	if err := db.AuthenticateScoped(name, secret, ScopeSend); err != nil { // Authenticate
+		return err // Return found error
+	}
+
+	_, err := db.updateAccount(name, func(account *Account) error {
+		(*account).HiddenFromDirectory = !discoverable // Set discoverable
+
+		return nil // No error occurred, return nil
+	}) // Update account
+
+	return err // Return error
+}
+
+// SearchDirectory lists the discoverable accounts whose usernames start with a given prefix, in username order.
+// Results are paged: a search returns at most limit accounts (DefaultDirectoryPageSize if zero), along with a cursor
+// that, given to the next search with the same prefix, continues after the last account returned.
+func (db *DB) SearchDirectory(prefix string, cursor string, limit int) (*DirectoryPage, error) {
+	if limit <= 0 { // Check no limit
+		limit = DefaultDirectoryPageSize // Set default limit
+	} else if limit > MaxDirectoryPageSize { // Check limit too large
+		limit = MaxDirectoryPageSize // Cap limit
+	}
+
+	prefix = NormalizeUsername(prefix) // Normalize prefix
+
+	start := prefix // Start at prefix
+
+	if cursor = NormalizeUsername(cursor); cursor >= start { // Check cursor after prefix
+		start = cursor + "\x00" // Start after cursor
+	}
+
+	page := &DirectoryPage{Entries: []*DirectoryEntry{}} // Init page
+
+	err := db.store.View(func(tx storage.Tx) error {
+		directory := tx.Bucket(directoryBucket) // Get directory bucket
+
+		if directory == nil { // Check no directory yet
+			return nil // Nothing to list
+		}
+
+		return directory.ForEachFrom([]byte(start), func(key []byte, value []byte) error {
+			if !strings.HasPrefix(string(key), prefix) { // Check past prefix
+				return errDirectoryPageFull // Stop
+			}
+
+			if len(page.Entries) == limit { // Check page full, with more to come
+				(*page).NextCursor = page.Entries[limit-1].Username // Set cursor
+
+				return errDirectoryPageFull // Stop
+			}
+
+			entry := &DirectoryEntry{Username: string(key)} // Init entry
+
+			copy(entry.Address[:], value) // Set address
+
+			(*page).Entries = append(page.Entries, entry) // Append entry
+
+			return nil // No error occurred, return nil
+		}) // List accounts
+	}) // Read directory
+
+	if err != nil && err != errDirectoryPageFull { // Check for errors
+		return nil, err // Return found error
+	}
+
+	return page, nil // Return page
+}
+
+/* END EXPORTED METHODS */
+
+/* BEGIN INTERNAL METHODS */
+
+// indexDirectoryEntry lists a given account in the directory if it is discoverable, and removes it otherwise.
+func indexDirectoryEntry(tx storage.Tx, account *Account) error {
+	directory, err := tx.CreateBucketIfNotExists(directoryBucket) // Create directory bucket
+
+	if err != nil { // Check for errors
+		return err // Return found error
+	}
+
+	if !account.Discoverable() { // Check hidden
+		return directory.Delete([]byte(account.Name)) // Remove entry
+	}
+
+	return directory.Put([]byte(account.Name), account.Address.Bytes()) // Put entry
+}
+
+// removeDirectoryEntry removes a given account from the directory.
+func removeDirectoryEntry(tx storage.Tx, account *Account) error {
+	if directory := tx.Bucket(directoryBucket); directory != nil { // Check has directory
+		return directory.Delete([]byte(account.Name)) // Remove entry
+	}
+
+	return nil // Nothing to remove
+}
+
+// rebuildDirectoryIndex clears the directory, and lists every discoverable account in it.
+func rebuildDirectoryIndex(tx storage.Tx) error {
+	err := tx.DeleteBucket(directoryBucket) // Clear directory
+
+	if err != nil && err != storage.ErrBucketNotFound { // Check for errors
+		return err // Return found error
+	}
+
+	if _, err = tx.CreateBucketIfNotExists(directoryBucket); err != nil { // Recreate directory
+		return err // Return found error
+	}
+
+	accounts := tx.Bucket(accountsBucket) // Get accounts bucket
+
+	if accounts == nil { // Check no accounts yet
+		return nil // Nothing to index
+	}
+
+	return accounts.ForEach(func(_, accountBytes []byte) error {
+		var account struct {
+			Name                string                   `json:"name"`
+			Address             summercashCommon.Address `json:"address"`
+			HiddenFromDirectory bool                     `json:"hidden_from_directory"`
+			DeleteAfter         time.Time                `json:"delete_after"`
+		} // Only decode the indexed fields
+
+		if err := json.Unmarshal(accountBytes, &account); err != nil { // Deserialize account bytes
+			logger.Errorf("skipping undecodable account while rebuilding directory: %s", err.Error()) // Log error
+
+			return nil // Skip account
+		}
+
+		return indexDirectoryEntry(tx, &Account{
+			Name:                account.Name,                // Set name
+			Address:             account.Address,             // Set address
+			HiddenFromDirectory: account.HiddenFromDirectory, // Set hidden
+			DeleteAfter:         account.DeleteAfter,         // Set deletion time
+		}) // Index account
+	}) // Index all accounts
+}
+
+/* END INTERNAL METHODS */
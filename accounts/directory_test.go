@@ -0,0 +1,72 @@
+// Package accounts defines account-related helper methods and types.
+// The accounts database, for example, is defined in this package.
+package accounts
+
+import (
+	"testing"
+)
+
+/* BEGIN EXPORTED METHODS TESTS */
+
+// TestSearchDirectory tests that SearchDirectory() pages through discoverable accounts matching a prefix, and skips hidden and deleted accounts.
+func TestSearchDirectory(t *testing.T) {
+	purgeDeletedAccountsImmediately(t) // Purge deleted accounts right away
+
+	db := newTestDB(t) // Open test db
+
+	defer db.CloseDB() // Close db
+
+	for i, name := range []string{"bob", "alice", "alfred", "albert", "alma", "carol"} { // Iterate through usernames
+		if _, err := db.AddNewAccount(name, "test", testAddress(i).String()); err != nil { // Add account
+			t.Fatal(err) // Panic
+		}
+	}
+
+	if err := db.SetDiscoverable("alma", "wrong", false); err != ErrPasswordInvalid { // Hide with wrong password
+		t.Fatalf("expected ErrPasswordInvalid, got %v", err) // Panic
+	}
+
+	if err := db.SetDiscoverable("alma", "test", false); err != nil { // Hide account
+		t.Fatal(err) // Panic
+	}
+
+	if err := db.DeleteAccount("carol", "test", ""); err != nil { // Delete account
+		t.Fatal(err) // Panic
+	}
+
+	page, err := db.SearchDirectory("Al", "", 2) // Search first page
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	if len(page.Entries) != 2 || page.Entries[0].Username != "albert" || page.Entries[1].Username != "alfred" || page.NextCursor != "alfred" { // Check first page
+		t.Fatalf("unexpected first page %+v", *page) // Panic
+	}
+
+	if page.Entries[0].Address != testAddress(3) { // Check address
+		t.Fatalf("expected address %s, got %s", testAddress(3).String(), page.Entries[0].Address.String()) // Panic
+	}
+
+	if page, err = db.SearchDirectory("al", page.NextCursor, 2); err != nil { // Search next page
+		t.Fatal(err) // Panic
+	}
+
+	if len(page.Entries) != 1 || page.Entries[0].Username != "alice" || page.NextCursor != "" { // Check last page (alma is hidden)
+		t.Fatalf("unexpected last page %+v", *page) // Panic
+	}
+
+	if page, err = db.SearchDirectory("", "", 0); err != nil || len(page.Entries) != 4 { // List everyone (carol is deleted)
+		t.Fatalf("expected 4 discoverable accounts, got %d (%v)", len(page.Entries), err) // Panic
+	}
+
+	if err = db.SetDiscoverable("alma", "test", true); err != nil { // List account again
+		t.Fatal(err) // Panic
+	}
+
+	if page, err = db.SearchDirectory("alm", "", 0); err != nil || len(page.Entries) != 1 { // Search relisted account
+		t.Fatalf("expected alma to be listed again, got %d entries (%v)", len(page.Entries), err) // Panic
+	}
+}
+
+/* END EXPORTED METHODS TESTS */
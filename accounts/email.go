@@ -0,0 +1,185 @@
+// Package accounts defines account-related helper methods and types.
+// The accounts database, for example, is defined in this package.
+package accounts
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/SummerCash/summercash-wallet-server/crypto"
+	"github.com/SummerCash/summercash-wallet-server/mail"
+)
+
+var (
+	// ErrNoMailer is an error definition describing an attempt to set an email address on a server that can't send email.
+	ErrNoMailer = errors.New("email is not configured on this server")
+
+	// ErrEmailVerificationInvalid is an error definition describing a wrong or expired email verification token.
+	ErrEmailVerificationInvalid = errors.New("invalid or expired email verification token")
+)
+
+var (
+	// Mailer is the mailer verification and security emails are sent through (nil if the server doesn't send email).
+	Mailer mail.Mailer
+
+	// EmailVerificationLifetime is the duration for which an email verification token is valid.
+	EmailVerificationLifetime = 24 * time.Hour
+
+	// maxKnownDevices is the number of device keys remembered per account, beyond which the oldest is forgotten.
+	maxKnownDevices = 50
+)
+
+// EmailVerification represents an email address awaiting verification.
+type EmailVerification struct {
+	Email string `json:"email"` // Address being verified
+
+	TokenHash []byte `json:"token_hash"` // Hash of the verification token mailed to the address
+
+	ExpiresAt time.Time `json:"expires_at"` // Time after which the token is no longer valid
+}
+
+/* BEGIN EXPORTED METHODS */
+
+// SetEmail starts verifying a given email address for the account with a given username, given its password (or an access token carrying the admin scope).
+// A verification token is mailed to the address, which only becomes the account's email once the token is given to VerifyEmail.
+// The account's current email (if any) is kept until then, and is notified of the change first. An empty address removes the account's email.
+func (db *DB) SetEmail(name string, secret string, email string) (err error) {
+	defer func() { db.RecordAuditEvent(name, AuditActionSetEmail, email, err) }() // Record attempt
+
+	if err = db.AuthenticateScoped(name, secret, ScopeAdmin); err != nil { // Authenticate
+		return err // Return found error
+	}
+
+	if email == "" { // Check removing email
+		db.sendSecurityEmail(name, "Email address removed", "The email address of your account was just removed, so security notices will no longer be sent to it.") // Notify current email
+
+		_, err = db.updateAccount(name, func(account *Account) error {
+			(*account).Email = ""              // Clear email
+			(*account).EmailVerification = nil // Clear verification
+
+			return nil // No error occurred, return nil
+		}) // Update account
+
+		return err // Return error
+	}
+
+	if err = mail.ValidateAddress(email); err != nil { // Check invalid address
+		return err // Return found error
+	}
+
+	if Mailer == nil { // Check can't send verification
+		return ErrNoMailer // Return error
+	}
+
+	tokenBytes := make([]byte, 16) // Init token buffer
+
+	if _, err = rand.Read(tokenBytes); err != nil { // Read random
+		return err // Return found error
+	}
+
+	token := hex.EncodeToString(tokenBytes) // Encode token
+
+	verification := &EmailVerification{
+		Email:     email,                                     // Set email
+		TokenHash: crypto.Sha3([]byte(token)),                // Set token hash
+		ExpiresAt: time.Now().Add(EmailVerificationLifetime), // Set expiry
+	} // Init verification
+
+	db.sendSecurityEmail(name, "Email address change requested", fmt.Sprintf("A new email address (%s) was just added to your account. Once verified, it replaces this one, and security notices are sent to it instead.", email)) // Notify current email
+
+	if _, err = db.updateAccount(name, func(account *Account) error {
+		(*account).EmailVerification = verification // Set verification
+
+		return nil // No error occurred, return nil
+	}); err != nil { // Update account
+		return err // Return found error
+	}
+
+	return Mailer.Send(&mail.Message{
+		To:      email,                       // Set recipient
+		Subject: "Verify your email address", // Set subject
+		Body: fmt.Sprintf("Hi %s,\n\nYour verification token is:\n\n%s\n\nIt expires at %s. If you didn't add this address to your SummerCash wallet, you can ignore this email.\n",
+			name, token, verification.ExpiresAt.UTC().Format(time.RFC1123)), // Set body
+	}) // Send verification
+}
+
+// VerifyEmail sets the email address awaiting verification as the email of the account with a given username, given the token mailed to it by SetEmail.
+func (db *DB) VerifyEmail(name string, token string) (err error) {
+	defer func() { db.RecordAuditEvent(name, AuditActionVerifyEmail, "", err) }() // Record attempt
+
+	_, err = db.updateAccount(name, func(account *Account) error {
+		verification := account.EmailVerification // Get verification
+
+		if verification == nil || time.Now().After(verification.ExpiresAt) || subtle.ConstantTimeCompare(verification.TokenHash, crypto.Sha3([]byte(token))) != 1 { // Check invalid
+			return ErrEmailVerificationInvalid // Return error
+		}
+
+		(*account).Email = verification.Email // Set email
+		(*account).EmailVerification = nil    // Clear verification
+
+		return nil // No error occurred, return nil
+	}) // Update account
+
+	return err // Return error
+}
+
+/* END EXPORTED METHODS */
+
+/* BEGIN INTERNAL METHODS */
+
+// sendSecurityEmail mails a given security notice to the verified email of the account with a given username, if it has one.
+// Notices are best-effort: failing to send one is logged, rather than failing the operation it describes.
+func (db *DB) sendSecurityEmail(name string, subject string, notice string) {
+	if Mailer == nil { // Check can't send email
+		return // Nothing to do
+	}
+
+	account, err := db.QueryAccountByUsername(name) // Query account
+
+	if err != nil || account.Email == "" { // Check no verified email
+		return // Nothing to do
+	}
+
+	err = Mailer.Send(&mail.Message{
+		To:      account.Email, // Set recipient
+		Subject: subject,       // Set subject
+		Body: fmt.Sprintf("Hi %s,\n\n%s\n\nIf this wasn't you, change your password and revoke your sessions right away.\n",
+			name, notice), // Set body
+	}) // Send notice
+
+	if err != nil { // Check for errors
+		logger.Errorf("failed to send security email to %s: %s", name, err.Error()) // Log error
+	}
+}
+
+// deviceKey derives the key a login is told apart by device with from the source address and user agent of the working database handle (see FromClient).
+// The key is derived on the server, since a device label sent by the client could simply be left out, or copied from a known device.
+func (db *DB) deviceKey() string {
+	return hex.EncodeToString(crypto.Sha3([]byte(db.source + "\n" + db.userAgent))) // Return key
+}
+
+// rememberDevice adds a given device key to the account's known devices, and reports whether the account has logged in from other devices, but not this one.
+// The account itself is not persisted.
+func (account *Account) rememberDevice(key string) bool {
+	for _, knownKey := range account.KnownDeviceKeys { // Iterate through known devices
+		if knownKey == key { // Check known
+			return false // Not new
+		}
+	}
+
+	isNew := len(account.KnownDeviceKeys) > 0 // The first device seen isn't new
+
+	(*account).KnownDeviceKeys = append(account.KnownDeviceKeys, key) // Remember device
+
+	if len(account.KnownDeviceKeys) > maxKnownDevices { // Check too many devices
+		(*account).KnownDeviceKeys = account.KnownDeviceKeys[len(account.KnownDeviceKeys)-maxKnownDevices:] // Forget oldest devices
+	}
+
+	return isNew // Return is new
+}
+
+/* END INTERNAL METHODS */
@@ -0,0 +1,165 @@
+// Package accounts defines account-related helper methods and types.
+// The accounts database, for example, is defined in this package.
+package accounts
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/SummerCash/go-summercash/config"
+	"github.com/SummerCash/summercash-wallet-server/mail"
+)
+
+/* BEGIN EXPORTED METHODS TESTS */
+
+// TestVerifyEmail tests that an email set with SetEmail() only becomes the account's email once verified with the mailed token, and that the verified email is notified of changes.
+func TestVerifyEmail(t *testing.T) {
+	db := newTestDB(t) // Open test db
+
+	defer db.CloseDB() // Close db
+
+	if _, err := db.AddNewAccount("test", "test", testAddress(0).String()); err != nil { // Add account
+		t.Fatal(err) // Panic
+	}
+
+	if err := db.SetEmail("test", "test", "test@example.com"); err != ErrNoMailer { // Set email without mailer
+		t.Fatalf("expected ErrNoMailer, got %v", err) // Panic
+	}
+
+	mailer := useTestMailer(t) // Use in-memory mailer
+
+	if err := db.SetEmail("test", "test", "Test <test@example.com>"); err != mail.ErrAddressInvalid { // Set invalid email
+		t.Fatalf("expected mail.ErrAddressInvalid, got %v", err) // Panic
+	}
+
+	if err := db.SetEmail("test", "test", "test@example.com"); err != nil { // Set email
+		t.Fatal(err) // Panic
+	}
+
+	messages := mailer.Messages() // Get sent messages
+
+	if len(messages) != 1 || messages[0].To != "test@example.com" { // Check verification sent
+		t.Fatalf("expected a verification email to test@example.com, got %d messages", len(messages)) // Panic
+	}
+
+	if err := db.VerifyEmail("test", "wrong"); err != ErrEmailVerificationInvalid { // Verify with wrong token
+		t.Fatalf("expected ErrEmailVerificationInvalid, got %v", err) // Panic
+	}
+
+	if account, err := db.QueryAccountByUsername("test"); err != nil || account.Email != "" { // Check not yet verified
+		t.Fatalf("expected email to be unverified (%v)", err) // Panic
+	}
+
+	if err := db.VerifyEmail("test", mailedToken(messages[0])); err != nil { // Verify email
+		t.Fatal(err) // Panic
+	}
+
+	if account, err := db.QueryAccountByUsername("test"); err != nil || account.Email != "test@example.com" || account.EmailVerification != nil { // Check verified
+		t.Fatalf("expected email to be verified (%v)", err) // Panic
+	}
+
+	if err := db.VerifyEmail("test", mailedToken(messages[0])); err != ErrEmailVerificationInvalid { // Verify with used token
+		t.Fatalf("expected ErrEmailVerificationInvalid, got %v", err) // Panic
+	}
+
+	if err := db.SetEmail("test", "test", "new@example.com"); err != nil { // Change email
+		t.Fatal(err) // Panic
+	}
+
+	if err := db.SetEmail("test", "test", ""); err != nil { // Remove email
+		t.Fatal(err) // Panic
+	}
+
+	if account, err := db.QueryAccountByUsername("test"); err != nil || account.Email != "" { // Check removed
+		t.Fatalf("expected email to be removed (%v)", err) // Panic
+	}
+
+	messages = mailer.Messages() // Get sent messages
+
+	if len(messages) != 4 || messages[1].To != "test@example.com" || !strings.Contains(messages[1].Body, "new@example.com") || messages[2].To != "new@example.com" { // Check verified email notified before the change
+		t.Fatalf("expected a change notice to test@example.com before the verification email to new@example.com, got %d messages", len(messages)) // Panic
+	}
+
+	if messages[3].To != "test@example.com" || messages[3].Subject != "Email address removed" { // Check verified email notified of the removal
+		t.Fatalf("unexpected removal notice %+v", *messages[3]) // Panic
+	}
+}
+
+// TestSecurityEmails tests that logins from new devices and private key exports are mailed to the account's verified email.
+func TestSecurityEmails(t *testing.T) {
+	setTestDataDir(t) // Use temp data dir
+
+	if err := (&config.ChainConfig{}).WriteToMemory(); err != nil { // Write chain config, which account chains are initialized from
+		t.Fatal(err) // Panic
+	}
+
+	db := newTestDB(t) // Open test db
+
+	defer db.CloseDB() // Close db
+
+	if _, err := db.CreateNewAccount("test", "test"); err != nil { // Create account
+		t.Fatal(err) // Panic
+	}
+
+	mailer := useTestMailer(t) // Use in-memory mailer
+
+	if err := db.SetEmail("test", "test", "test@example.com"); err != nil { // Set email
+		t.Fatal(err) // Panic
+	}
+
+	if err := db.VerifyEmail("test", mailedToken(mailer.Messages()[0])); err != nil { // Verify email
+		t.Fatal(err) // Panic
+	}
+
+	for _, login := range []struct {
+		source, userAgent, device string
+	}{
+		{"192.0.2.1", "wallet/1.0", "phone"},  // First device
+		{"192.0.2.1", "wallet/1.0", "laptop"}, // Same device, another label
+		{"198.51.100.7", "curl/7.0", "phone"}, // New device, reusing a known label
+	} { // Iterate through logins
+		if _, err := db.FromClient(login.source, login.userAgent).IssueAccountToken("test", "test", login.device, nil); err != nil { // Log in
+			t.Fatal(err) // Panic
+		}
+	}
+
+	if _, err := db.ExportPrivateKey("test", "", "test", "", "passphrase"); err != nil { // Export private key
+		t.Fatal(err) // Panic
+	}
+
+	messages := mailer.Messages() // Get sent messages
+
+	if len(messages) != 3 { // Check only the new device and the export were notified (after the verification)
+		t.Fatalf("expected 3 messages, got %d", len(messages)) // Panic
+	}
+
+	if messages[1].Subject != "New login to your account" || !strings.Contains(messages[1].Body, "198.51.100.7") || !strings.Contains(messages[1].Body, "curl/7.0") { // Check login notice
+		t.Fatalf("unexpected login notice %+v", *messages[1]) // Panic
+	}
+
+	if messages[2].Subject != "Private key exported" || messages[2].To != "test@example.com" { // Check export notice
+		t.Fatalf("unexpected export notice %+v", *messages[2]) // Panic
+	}
+}
+
+/* END EXPORTED METHODS TESTS */
+
+/* BEGIN TEST HELPERS */
+
+// useTestMailer sends email through a new in-memory mailer for the duration of a test.
+func useTestMailer(t *testing.T) *mail.MemoryMailer {
+	mailer := &mail.MemoryMailer{} // Init mailer
+
+	Mailer = mailer // Set mailer
+
+	t.Cleanup(func() { Mailer = nil }) // Reset mailer
+
+	return mailer // Return mailer
+}
+
+// mailedToken extracts the verification token from a given verification email.
+func mailedToken(message *mail.Message) string {
+	return strings.Split(message.Body, "\n")[4] // Return token line
+}
+
+/* END TEST HELPERS */
@@ -0,0 +1,84 @@
+// Package accounts defines account-related helper methods and types.
+// The accounts database, for example, is defined in this package.
+package accounts
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrAccountFrozen is an error definition describing an attempt to send from an account frozen by an administrator.
+	ErrAccountFrozen = errors.New("account is frozen, and cannot send transactions until it is unfrozen")
+
+	// ErrFreezeReasonRequired is an error definition describing an attempt to freeze an account without giving a reason.
+	ErrFreezeReasonRequired = errors.New("a reason must be given to freeze an account")
+)
+
+/* BEGIN EXPORTED METHODS */
+
+// FreezeAccount freezes the account with a given username on behalf of a given actor, for a given reason, refusing sends from any of
+// its addresses (and faucet claims) until it is unfrozen. The account can still log in, view its balance and transactions, and receive transactions.
+func (db *DB) FreezeAccount(actor string, name string, reason string) (err error) {
+	reason = strings.TrimSpace(reason) // Trim reason
+
+	defer func() { db.RecordAuditEvent(name, AuditActionFreezeAccount, "by "+actor+": "+reason, err) }() // Record attempt
+
+	if reason == "" { // Check no reason
+		return ErrFreezeReasonRequired // Return error
+	}
+
+	_, err = db.updateAccount(name, func(account *Account) error {
+		(*account).Frozen = true               // Freeze
+		(*account).FreezeReason = reason       // Set reason
+		(*account).FrozenBy = actor            // Set actor
+		(*account).FrozenAt = time.Now().UTC() // Set freeze time
+
+		return nil // No error occurred, return nil
+	}) // Freeze account
+
+	return err // Return error
+}
+
+// UnfreezeAccount lifts the freeze of the account with a given username on behalf of a given actor.
+func (db *DB) UnfreezeAccount(actor string, name string) (err error) {
+	defer func() { db.RecordAuditEvent(name, AuditActionUnfreezeAccount, "by "+actor, err) }() // Record attempt
+
+	_, err = db.updateAccount(name, func(account *Account) error {
+		(*account).Frozen = false         // Unfreeze
+		(*account).FreezeReason = ""      // Clear reason
+		(*account).FrozenBy = ""          // Clear actor
+		(*account).FrozenAt = time.Time{} // Clear freeze time
+
+		return nil // No error occurred, return nil
+	}) // Unfreeze account
+
+	return err // Return error
+}
+
+// CheckAccountNotFrozen checks that the account with a given username has not been frozen, returning ErrAccountFrozen if it has.
+func (db *DB) CheckAccountNotFrozen(name string) error {
+	account, err := db.QueryAccountByUsername(name) // Query account
+
+	if err != nil { // Check for errors
+		return err // Return found error
+	}
+
+	return account.checkNotFrozen() // Check frozen
+}
+
+/* END EXPORTED METHODS */
+
+/* BEGIN INTERNAL METHODS */
+
+// checkNotFrozen checks that the account has not been frozen, returning ErrAccountFrozen if it has.
+func (account *Account) checkNotFrozen() error {
+	if account.Frozen { // Check frozen
+		return ErrAccountFrozen // Return error
+	}
+
+	return nil // Not frozen
+}
+
+/* END INTERNAL METHODS */
@@ -0,0 +1,70 @@
+// Package accounts defines account-related helper methods and types.
+// The accounts database, for example, is defined in this package.
+package accounts
+
+import (
+	"testing"
+
+	"github.com/SummerCash/go-summercash/config"
+)
+
+/* BEGIN EXPORTED METHODS TESTS */
+
+// TestFreezeAccount tests that FreezeAccount() refuses sends until UnfreezeAccount() is called.
+func TestFreezeAccount(t *testing.T) {
+	setTestDataDir(t) // Use temp data dir
+
+	if err := (&config.ChainConfig{}).WriteToMemory(); err != nil { // Write chain config, which account chains are initialized from
+		t.Fatal(err) // Panic
+	}
+
+	db := newTestDB(t) // Open test db
+
+	defer db.CloseDB() // Close db
+
+	if _, err := db.CreateNewAccount("test", "test"); err != nil { // Create account
+		t.Fatal(err) // Panic
+	}
+
+	if err := db.FreezeAccount("admin", "test", " "); err != ErrFreezeReasonRequired { // Freeze without reason
+		t.Fatalf("expected ErrFreezeReasonRequired, got %v", err) // Panic
+	}
+
+	if err := db.FreezeAccount("admin", "test", "compromised"); err != nil { // Freeze
+		t.Fatal(err) // Panic
+	}
+
+	if account, err := db.QueryAccountByUsername("test"); err != nil || account.FreezeReason != "compromised" || account.FrozenBy != "admin" || account.FrozenAt.IsZero() { // Check freeze persisted
+		t.Fatalf("expected freeze reason and actor to be persisted (%v)", err) // Panic
+	}
+
+	if err := db.CheckAccountNotFrozen("test"); err != ErrAccountFrozen { // Check frozen
+		t.Fatalf("expected ErrAccountFrozen, got %v", err) // Panic
+	}
+
+	if _, _, err := db.UnlockAddressPrivateKey("test", "", "test", OperationSend, ""); err != ErrAccountFrozen { // Unlock private key to send
+		t.Fatalf("expected ErrAccountFrozen, got %v", err) // Panic
+	}
+
+	if err := db.Authenticate("test", "test"); err != nil { // Log in while frozen
+		t.Fatal(err) // Panic
+	}
+
+	if matches, err := db.SearchAccounts(&AccountFilter{FrozenOnly: true}); err != nil || len(matches) != 1 { // Search frozen accounts
+		t.Fatalf("expected one frozen account, got %d (%v)", len(matches), err) // Panic
+	}
+
+	if err := db.UnfreezeAccount("admin", "test"); err != nil { // Unfreeze
+		t.Fatal(err) // Panic
+	}
+
+	if _, _, err := db.UnlockAddressPrivateKey("test", "", "test", OperationSend, ""); err != nil { // Unlock private key to send
+		t.Fatal(err) // Panic
+	}
+
+	if account, err := db.QueryAccountByUsername("test"); err != nil || account.Frozen || account.FreezeReason != "" { // Check freeze lifted
+		t.Fatalf("expected freeze to be lifted (%v)", err) // Panic
+	}
+}
+
+/* END EXPORTED METHODS TESTS */
@@ -0,0 +1,261 @@
+// Package accounts defines account-related helper methods and types.
+// The accounts database, for example, is defined in this package.
+package accounts
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+
+	summercashCommon "github.com/SummerCash/go-summercash/common"
+	"github.com/SummerCash/summercash-wallet-server/crypto"
+	"github.com/SummerCash/summercash-wallet-server/storage"
+)
+
+var (
+	// ErrKeystoreAddressMismatch is an error definition describing a keystore whose address does not match its private key.
+	ErrKeystoreAddressMismatch = errors.New("keystore address does not match its private key")
+
+	// ErrAddressAlreadyRegistered is an error definition describing an address that already belongs to an account.
+	ErrAddressAlreadyRegistered = errors.New("address already belongs to an account")
+
+	// ErrUnsupportedKeystore is an error definition describing a keystore written in a newer format, with an unknown cipher, or with excessive KDF parameters.
+	ErrUnsupportedKeystore = errors.New("unsupported keystore version, cipher or KDF parameters")
+
+	// ErrExportPassphraseTooShort is an error definition describing an export passphrase shorter than MinExportPassphraseLength.
+	ErrExportPassphraseTooShort = errors.New("export passphrase is too short")
+)
+
+const (
+	// KeystoreVersion is the version of the keystore format written by NewKeystore.
+	KeystoreVersion = 1
+
+	// KeystoreCipher is the AEAD cipher sealing keystore private keys.
+	KeystoreCipher = "aes-256-gcm"
+
+	// KeystoreKDF is the key derivation function deriving keystore keys from passphrases.
+	KeystoreKDF = "scrypt"
+)
+
+var (
+	// MinExportPassphraseLength is the minimum length, in characters, of a passphrase that an exported keystore is encrypted with.
+	MinExportPassphraseLength = 8
+
+	// maxKeystoreScryptMemory is the maximum memory, in bytes, that deriving the key of a keystore may take (128 * N * r for scrypt),
+	// so that decrypting an imported keystore is bounded to a fixed amount of the server's memory.
+	maxKeystoreScryptMemory = 256 << 20
+
+	// maxKeystoreScryptP is the maximum scrypt parallelism accepted from a keystore, bounding the time its key takes to derive along with the memory.
+	maxKeystoreScryptP = 16
+)
+
+// Keystore is the portable, passphrase-encrypted representation of a private key, written by ExportPrivateKey and accepted by ImportAccount,
+// so that keys can move between servers. The faucet keystore is written in the same format.
+// Keystores written before versioning have no version, cipher, or KDF name, and are read as version 1 keystores.
+type Keystore struct {
+	Version uint64 `json:"version,omitempty"` // Keystore format version
+
+	Address summercashCommon.Address `json:"address"` // Address of the private key
+
+	Cipher string `json:"cipher,omitempty"` // AEAD cipher sealing the private key
+
+	KDFName string `json:"kdf_name,omitempty"` // Key derivation function deriving the keystore key from the passphrase
+
+	KDF *crypto.ScryptParams `json:"kdf"` // Parameters used to derive the keystore key from the passphrase
+
+	Ciphertext []byte `json:"ciphertext"` // PEM-encoded private key, sealed under the keystore key
+}
+
+/* BEGIN EXPORTED METHODS */
+
+// NewKeystore encrypts a given private key under a key derived from a given passphrase.
+func NewKeystore(privateKey *ecdsa.PrivateKey, passphrase []byte) (*Keystore, error) {
+	address, err := summercashCommon.NewAddress(privateKey) // Get address
+
+	if err != nil { // Check for errors
+		return nil, err // Return found error
+	}
+
+	kdf, err := crypto.NewScryptParams() // Init KDF params
+
+	if err != nil { // Check for errors
+		return nil, err // Return found error
+	}
+
+	key, err := kdf.DeriveKey(passphrase) // Derive keystore key
+
+	if err != nil { // Check for errors
+		return nil, err // Return found error
+	}
+
+	pemEncoded, err := crypto.EncodePrivateKey(privateKey) // Encode private key
+
+	if err != nil { // Check for errors
+		return nil, err // Return found error
+	}
+
+	ciphertext, err := crypto.Seal(key, pemEncoded) // Encrypt private key
+
+	if err != nil { // Check for errors
+		return nil, err // Return found error
+	}
+
+	return &Keystore{
+		Version:    KeystoreVersion, // Set version
+		Address:    address,         // Set address
+		Cipher:     KeystoreCipher,  // Set cipher
+		KDFName:    KeystoreKDF,     // Set KDF name
+		KDF:        kdf,             // Set KDF params
+		Ciphertext: ciphertext,      // Set ciphertext
+	}, nil // Return keystore
+}
+
+// KeystoreFromBytes deserializes a keystore from its JSON encoding.
+func KeystoreFromBytes(b []byte) (*Keystore, error) {
+	keystore := &Keystore{} // Init keystore buffer
+
+	if err := json.Unmarshal(b, keystore); err != nil { // Unmarshal keystore
+		return nil, err // Return found error
+	}
+
+	if keystore.KDF == nil { // Check no KDF params
+		return nil, crypto.ErrInvalidPrivateKey // Return error
+	}
+
+	if !validKeystoreKDF(keystore.KDF) { // Check KDF params invalid, or too costly
+		return nil, ErrUnsupportedKeystore // Return error
+	}
+
+	if keystore.Version > KeystoreVersion || (keystore.Cipher != "" && keystore.Cipher != KeystoreCipher) || (keystore.KDFName != "" && keystore.KDFName != KeystoreKDF) { // Check unsupported
+		return nil, ErrUnsupportedKeystore // Return error
+	}
+
+	return keystore, nil // Return keystore
+}
+
+// Decrypt decrypts the private key in a keystore with a given passphrase, and checks that it matches the keystore's address.
+func (keystore *Keystore) Decrypt(passphrase []byte) (*ecdsa.PrivateKey, error) {
+	key, err := keystore.KDF.DeriveKey(passphrase) // Derive keystore key
+
+	if err != nil { // Check for errors
+		return nil, err // Return found error
+	}
+
+	pemEncoded, err := crypto.Open(key, keystore.Ciphertext) // Decrypt private key
+
+	if err != nil { // Check for errors
+		return nil, err // Return found error
+	}
+
+	privateKey, err := crypto.ParsePrivateKey(pemEncoded) // Parse private key
+
+	if err != nil { // Check for errors
+		return nil, err // Return found error
+	}
+
+	if address, err := summercashCommon.NewAddress(privateKey); err != nil || address != keystore.Address { // Check address mismatch
+		return nil, ErrKeystoreAddressMismatch // Return error
+	}
+
+	return privateKey, nil // Return private key
+}
+
+// Bytes serializes a given keystore to its JSON encoding.
+func (keystore *Keystore) Bytes() []byte {
+	marshaledVal, _ := json.MarshalIndent(*keystore, "", "  ") // Marshal
+
+	return marshaledVal // Return marshaled val
+}
+
+// ImportAccount creates a new account with a given name and password from an existing private key, which the server then holds
+// (unlike AddNewAccount, which creates a watch-only account). The private key is encrypted at rest under a key derived from the password.
+// The key may be given PEM-encoded, optionally hex-encoded (as exported by the accounts API), or as a keystore (see Keystore) along with its passphrase.
+// The key's address must not already belong to an account.
+func (db *DB) ImportAccount(name string, password string, key []byte, passphrase string) (*Account, error) {
+	if err := ValidateUsername(name); err != nil { // Validate username before decrypting the key
+		return &Account{}, err // Return found error
+	}
+
+	privateKey, err := parseImportKey(key, passphrase) // Parse key
+
+	if err != nil { // Check for errors
+		return &Account{}, err // Return found error
+	}
+
+	address, err := summercashCommon.NewAddress(privateKey) // Derive address
+
+	if err != nil { // Check for errors
+		return &Account{}, err // Return found error
+	}
+
+	accountInstance := &Account{
+		Name:         NormalizeUsername(name),       // Set name
+		PasswordHash: crypto.Salt([]byte(password)), // Set password hash
+		Address:      address,                       // Set address
+	}
+
+	err = db.CreateAccountsBucketIfNotExist() // Create accounts bucket
+
+	if err != nil { // Check for errors
+		return &Account{}, err // Return found error
+	}
+
+	err = db.store.Update(func(tx storage.Tx) error {
+		if err := checkUsernameAvailable(tx, accountInstance.Name, false); err != nil { // Check username available
+			return err // Return found error
+		}
+
+		if tx.Bucket(addressesBucket).Get(address.Bytes()) != nil { // Check address taken
+			return ErrAddressAlreadyRegistered // Return error
+		}
+
+		if err := writeChainIfNotExist(address); err != nil { // Write address chain
+			return err // Return found error
+		}
+
+		if err := accountInstance.encryptPrivateKey(privateKey, password); err != nil { // Encrypt private key
+			return err // Return found error
+		}
+
+		return putAccount(tx, accountInstance) // Put account
+	}) // Add new account to DB
+
+	if err != nil { // Check for errors
+		return &Account{}, err // Return found error
+	}
+
+	logger.Infof("imported private key of address %s as account %s", address.String(), accountInstance.Name) // Log import
+
+	return accountInstance, nil // Return account
+}
+
+/* END EXPORTED METHODS */
+
+/* BEGIN INTERNAL METHODS */
+
+// validKeystoreKDF checks that given scrypt params, read from a keystore, are valid and within the cost the server accepts.
+// N must be a power of two greater than one, r and p at least one, and the memory used (128 * N * r) at most maxKeystoreScryptMemory.
+func validKeystoreKDF(kdf *crypto.ScryptParams) bool {
+	if kdf.N <= 1 || kdf.N&(kdf.N-1) != 0 || kdf.R < 1 || kdf.P < 1 || kdf.P > maxKeystoreScryptP { // Check invalid params
+		return false // Invalid
+	}
+
+	return kdf.R <= maxKeystoreScryptMemory/128 && kdf.N <= maxKeystoreScryptMemory/(128*kdf.R) // Check memory within bound (without overflowing)
+}
+
+// parseImportKey parses a private key given to ImportAccount: a keystore if a passphrase is given, or a PEM-encoded (optionally hex-encoded) key otherwise.
+func parseImportKey(key []byte, passphrase string) (*ecdsa.PrivateKey, error) {
+	if passphrase == "" { // Check not a keystore
+		return crypto.ParsePrivateKey(key) // Parse private key
+	}
+
+	keystore, err := KeystoreFromBytes(key) // Decode keystore
+
+	if err != nil { // Check for errors
+		return nil, err // Return found error
+	}
+
+	return keystore.Decrypt([]byte(passphrase)) // Decrypt keystore
+}
+
+/* END INTERNAL METHODS */
@@ -0,0 +1,195 @@
+// Package accounts defines account-related helper methods and types.
+// The accounts database, for example, is defined in this package.
+package accounts
+
+import (
+	"encoding/hex"
+	"testing"
+
+	summercashAccounts "github.com/SummerCash/go-summercash/accounts"
+	"github.com/SummerCash/go-summercash/config"
+	"github.com/SummerCash/summercash-wallet-server/crypto"
+)
+
+/* BEGIN EXPORTED METHODS TESTS */
+
+// TestImportAccount tests the functionality of the ImportAccount() helper method.
+func TestImportAccount(t *testing.T) {
+	setTestDataDir(t) // Use temp data dir
+
+	if err := (&config.ChainConfig{}).WriteToMemory(); err != nil { // Write chain config, which account chains are initialized from
+		t.Fatal(err) // Panic
+	}
+
+	db := newTestDB(t) // Open test db
+
+	defer db.CloseDB() // Close db
+
+	keyPair, err := summercashAccounts.NewAccount() // Generate key pair
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	encoded, err := crypto.EncodePrivateKey(keyPair.PrivateKey) // Encode private key
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	if _, err = db.ImportAccount("test", "test", []byte("not a key"), ""); err != crypto.ErrInvalidPrivateKey { // Import garbage
+		t.Fatalf("expected ErrInvalidPrivateKey, got %v", err) // Panic
+	}
+
+	account, err := db.ImportAccount("test", "test", []byte(hex.EncodeToString(encoded)), "") // Import hex-encoded key
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	if account.Address != keyPair.Address || account.WatchOnly { // Check wrong address or watch-only
+		t.Fatalf("expected custodial account at %s, got %+v", keyPair.Address.String(), account) // Panic
+	}
+
+	if unlocked, err := db.UnlockPrivateKey("test", "test", OperationSend, ""); err != nil || unlocked.D.Cmp(keyPair.PrivateKey.D) != 0 { // Unlock imported key
+		t.Fatalf("should have unlocked imported private key: %v", err) // Panic
+	}
+
+	if _, err = db.ImportAccount("other", "test", encoded, ""); err != ErrAddressAlreadyRegistered { // Import key of existing account
+		t.Fatalf("expected ErrAddressAlreadyRegistered, got %v", err) // Panic
+	}
+
+	otherKeyPair, _ := summercashAccounts.NewAccount() // Generate other key pair
+
+	keystore, err := NewKeystore(otherKeyPair.PrivateKey, []byte("passphrase")) // Encrypt other key
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	if _, err = db.ImportAccount("other", "test", keystore.Bytes(), "wrong"); err != crypto.ErrDecryptionFailed { // Import keystore with wrong passphrase
+		t.Fatalf("expected ErrDecryptionFailed, got %v", err) // Panic
+	}
+
+	tampered := *keystore              // Copy keystore
+	tampered.Address = keyPair.Address // Claim another address
+
+	if _, err = db.ImportAccount("other", "test", tampered.Bytes(), "passphrase"); err != ErrKeystoreAddressMismatch { // Import tampered keystore
+		t.Fatalf("expected ErrKeystoreAddressMismatch, got %v", err) // Panic
+	}
+
+	if account, err = db.ImportAccount("other", "test", keystore.Bytes(), "passphrase"); err != nil || account.Address != otherKeyPair.Address { // Import keystore
+		t.Fatalf("should have imported keystore: %v", err) // Panic
+	}
+}
+
+// TestExportPrivateKey tests the functionality of the ExportPrivateKey() helper method, and that exported keystores can be imported.
+func TestExportPrivateKey(t *testing.T) {
+	setTestDataDir(t) // Use temp data dir
+
+	if err := (&config.ChainConfig{}).WriteToMemory(); err != nil { // Write chain config, which account chains are initialized from
+		t.Fatal(err) // Panic
+	}
+
+	db := newTestDB(t) // Open test db
+
+	defer db.CloseDB() // Close db
+
+	account, err := db.CreateNewAccount("test", "test") // Create account
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	if _, err = db.ExportPrivateKey("test", "", "test", "", "short"); err != ErrExportPassphraseTooShort { // Export with short passphrase
+		t.Fatalf("expected ErrExportPassphraseTooShort, got %v", err) // Panic
+	}
+
+	if _, err = db.ExportPrivateKey("test", "", "wrong", "", "passphrase"); err != ErrPasswordInvalid { // Export with wrong password
+		t.Fatalf("expected ErrPasswordInvalid, got %v", err) // Panic
+	}
+
+	keystore, err := db.ExportPrivateKey("test", "", "test", "", "passphrase") // Export private key
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	if keystore.Version != KeystoreVersion || keystore.Cipher != KeystoreCipher || keystore.KDFName != KeystoreKDF || keystore.Address != account.Address { // Check not self-describing
+		t.Fatalf("unexpected keystore %+v", keystore) // Panic
+	}
+
+	legacy := *keystore                                       // Copy keystore
+	legacy.Version, legacy.Cipher, legacy.KDFName = 0, "", "" // Strip format fields, as in keystores written before versioning
+
+	if _, err = KeystoreFromBytes(legacy.Bytes()); err != nil { // Decode unversioned keystore
+		t.Fatalf("should have decoded unversioned keystore: %v", err) // Panic
+	}
+
+	legacy.Version = KeystoreVersion + 1 // Set future version
+
+	if _, err = KeystoreFromBytes(legacy.Bytes()); err != ErrUnsupportedKeystore { // Decode future keystore
+		t.Fatalf("expected ErrUnsupportedKeystore, got %v", err) // Panic
+	}
+
+	costly := *keystore                                                       // Copy keystore
+	costly.KDF = &crypto.ScryptParams{N: 1 << 30, R: 8, P: 1, Salt: []byte{}} // Set excessive cost
+
+	if _, err = KeystoreFromBytes(costly.Bytes()); err != ErrUnsupportedKeystore { // Decode costly keystore
+		t.Fatalf("expected ErrUnsupportedKeystore, got %v", err) // Panic
+	}
+
+	purgeDeletedAccountsImmediately(t) // Free address on deletion
+
+	if err = db.DeleteAccount("test", "test", ""); err != nil { // Delete account
+		t.Fatal(err) // Panic
+	}
+
+	if imported, err := db.ImportAccount("moved", "new", keystore.Bytes(), "passphrase"); err != nil || imported.Address != account.Address { // Import exported keystore
+		t.Fatalf("should have imported exported keystore: %v", err) // Panic
+	}
+}
+
+// TestKeystoreFromBytes tests that KeystoreFromBytes() refuses keystores with invalid, or too costly, scrypt params.
+func TestKeystoreFromBytes(t *testing.T) {
+	setTestDataDir(t) // Use temp data dir
+
+	if err := (&config.ChainConfig{}).WriteToMemory(); err != nil { // Write chain config, which account chains are initialized from
+		t.Fatal(err) // Panic
+	}
+
+	keyPair, err := summercashAccounts.NewAccount() // Generate key pair
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	keystore, err := NewKeystore(keyPair.PrivateKey, []byte("passphrase")) // Init keystore
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	if _, err = KeystoreFromBytes(keystore.Bytes()); err != nil { // Decode keystore
+		t.Fatal(err) // Panic
+	}
+
+	for _, params := range [][3]int{
+		{0, 8, 1},             // No cost
+		{1, 8, 1},             // Cost of one
+		{1000, 8, 1},          // Cost not a power of two
+		{1 << 10, 0, 1},       // No block size
+		{1 << 10, 8, 0},       // No parallelism
+		{1 << 10, 8, 17},      // Too much parallelism
+		{1 << 20, 16, 1},      // Too much memory (2 GiB)
+		{1 << 10, 1 << 62, 1}, // Block size overflowing the memory bound
+	} { // Iterate through invalid params
+		(*keystore).KDF.N, (*keystore).KDF.R, (*keystore).KDF.P = params[0], params[1], params[2] // Set params
+
+		if _, err = KeystoreFromBytes(keystore.Bytes()); err != ErrUnsupportedKeystore { // Decode keystore
+			t.Fatalf("expected ErrUnsupportedKeystore for N=%d, r=%d, p=%d, got %v", params[0], params[1], params[2], err) // Panic
+		}
+	}
+}
+
+/* END EXPORTED METHODS TESTS */
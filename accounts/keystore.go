@@ -0,0 +1,384 @@
+// Package accounts defines account-related helper methods and types.
+// The accounts database, for example, is defined in this package.
+package accounts
+
+import (
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"unicode/utf8"
+
+	"github.com/SummerCash/summercash-wallet-server/storage"
+
+	summercashAccounts "github.com/SummerCash/go-summercash/accounts"
+	summercashCommon "github.com/SummerCash/go-summercash/common"
+	"github.com/SummerCash/summercash-wallet-server/common"
+	"github.com/SummerCash/summercash-wallet-server/crypto"
+)
+
+var (
+	// ErrNoPrivateKey is an error definition describing an account whose private key is not held by the server.
+	ErrNoPrivateKey = errors.New("no private key is stored for the given account")
+
+	// ErrPrivateKeyLocked is an error definition describing a token that cannot unlock an account's private key.
+	// The account password must be used to unlock the key instead.
+	ErrPrivateKeyLocked = errors.New("private key cannot be unlocked with the given token; authenticate with a password")
+)
+
+// MasterKey is the optional server master key. When set, the data key of each newly
+// encrypted private key is additionally wrapped under a key derived from the master key.
+var MasterKey []byte
+
+const (
+	// masterKeyInfo binds keys derived from the master key to the purpose of wrapping data keys.
+	masterKeyInfo = "summercash-wallet-server master key wrap"
+
+	// tokenKeyInfo binds keys derived from token secrets to the purpose of wrapping data keys.
+	tokenKeyInfo = "summercash-wallet-server token key wrap"
+)
+
+// KeyEncryption represents the metadata required to unlock an account's encrypted private key.
+// The private key is encrypted under a random data key, which is in turn wrapped by each key encryption key able to unlock it.
+type KeyEncryption struct {
+	PasswordKDF        *crypto.ScryptParams `json:"password_kdf"`         // Parameters used to derive the password key encryption key
+	PasswordWrappedKey []byte               `json:"password_wrapped_key"` // Data key wrapped under the password key encryption key
+
+	MasterWrappedKey []byte `json:"master_wrapped_key,omitempty"` // Data key wrapped under the master key encryption key
+}
+
+// encryptedKeystore is the on-disk representation of an encrypted private key.
+type encryptedKeystore struct {
+	Address summercashCommon.Address `json:"address"` // Account address
+
+	Ciphertext []byte `json:"ciphertext"` // PEM-encoded private key, sealed under the account data key
+}
+
+/* BEGIN EXPORTED METHODS */
+
+// KeystorePath gets the path of the encrypted keystore file of a given address.
+func KeystorePath(address summercashCommon.Address) string {
+	return filepath.FromSlash(fmt.Sprintf("%s/wallet_keystore/account_%s.json", common.DataDir, address.String())) // Return path
+}
+
+// UnlockPrivateKey decrypts the private key of a given account's primary address in memory, in order to perform a given operation.
+// The key may be unlocked with the account password, or with an access token carrying the scope the operation requires.
+// If the account has enabled two-factor authentication, and the operator requires a second factor for the operation, a valid second factor must also be given.
+// Every attempt is recorded in the audit log under the operation's name.
+func (db *DB) UnlockPrivateKey(username string, secret string, operation string, secondFactor string) (*ecdsa.PrivateKey, error) {
+	_, privateKey, err := db.UnlockAddressPrivateKey(username, "", secret, operation, secondFactor) // Unlock primary key
+
+	return privateKey, err // Return private key
+}
+
+// UnlockAddressPrivateKey decrypts the private key of one of a given account's addresses in memory, in order to perform a given operation.
+// The address may be given as the name or hex address of one of the account's sub-addresses, or left empty for the primary address.
+// Authorization works as it does for UnlockPrivateKey. Returns the resolved address along with its private key.
+func (db *DB) UnlockAddressPrivateKey(username string, from string, secret string, operation string, secondFactor string) (address summercashCommon.Address, privateKey *ecdsa.PrivateKey, err error) {
+	defer func() { db.RecordAuditEvent(username, operation, from, err) }() // Record attempt
+
+	account, err := db.QueryAccountByUsername(username) // Query account
+
+	if err != nil { // Check for errors
+		return summercashCommon.Address{}, nil, err // Return found error
+	}
+
+	if address, err = account.resolveOwnAddress(from); err != nil { // Resolve address
+		return summercashCommon.Address{}, nil, err // Return found error
+	}
+
+	dataKey, err := db.unlockDataKey(account, secret, operationScope(operation)) // Unlock data key
+
+	if err != nil { // Check for errors
+		return summercashCommon.Address{}, nil, err // Return found error
+	}
+
+	if operation == OperationSend { // Check sending
+		if err = account.checkNotFrozen(); err != nil { // Check frozen
+			return summercashCommon.Address{}, nil, err // Return found error
+		}
+	}
+
+	if err = db.verifySecondFactor(account, operation, secondFactor); err != nil { // Verify second factor
+		return summercashCommon.Address{}, nil, err // Return found error
+	}
+
+	privateKey, err = readEncryptedKeystore(address, dataKey) // Decrypt private key
+
+	if err != nil { // Check for errors
+		return summercashCommon.Address{}, nil, err // Return found error
+	}
+
+	return address, privateKey, nil // Return address and private key
+}
+
+// ExportPrivateKey decrypts the private key of one of a given account's addresses, and re-encrypts it into a keystore under a given export passphrase
+// (see Keystore). The address may be given as the name or hex address of one of the account's sub-addresses, or left empty for the primary address.
+// Authorization works as it does for UnlockPrivateKey, with the export key operation. A security email is sent once the key is exported.
+func (db *DB) ExportPrivateKey(username string, from string, secret string, secondFactor string, passphrase string) (*Keystore, error) {
+	if utf8.RuneCountInString(passphrase) < MinExportPassphraseLength { // Check passphrase too short
+		return nil, ErrExportPassphraseTooShort // Return error
+	}
+
+	address, privateKey, err := db.UnlockAddressPrivateKey(username, from, secret, OperationExportKey, secondFactor) // Decrypt private key in memory
+
+	if err != nil { // Check for errors
+		return nil, err // Return found error
+	}
+
+	keystore, err := NewKeystore(privateKey, []byte(passphrase)) // Encrypt private key
+
+	if err != nil { // Check for errors
+		return nil, err // Return found error
+	}
+
+	db.sendSecurityEmail(username, "Private key exported", fmt.Sprintf("The private key of your address %s was just exported.", address.String())) // Notify account
+
+	return keystore, nil // Return keystore
+}
+
+/* END EXPORTED METHODS */
+
+/* BEGIN INTERNAL METHODS */
+
+// unlockDataKey unwraps the data key of a given account with either the account password, or an access token carrying a given scope.
+// Watch-only accounts have no data key, and fail with ErrWatchOnlyAccount once authenticated.
+func (db *DB) unlockDataKey(account *Account, secret string, scope string) ([]byte, error) {
+	if db.ValidateAccountTokenScope(account, secret, scope) { // Check is token (checked first, so that tokens are never counted as failed passwords)
+		if account.WatchOnly { // Check watch-only
+			return nil, ErrWatchOnlyAccount // Return error
+		}
+
+		if account.KeyEncryption == nil { // Check no encrypted key
+			return nil, ErrNoPrivateKey // Return error
+		}
+
+		return account.unwrapTokenDataKey(account.findToken(secret, TokenTypeAccess), secret) // Unwrap data key
+	}
+
+	if err := db.verifyPassword(account, secret); err != nil { // Verify salt
+		return nil, err // Return found error
+	}
+
+	if account.WatchOnly { // Check watch-only
+		return nil, ErrWatchOnlyAccount // Return error
+	}
+
+	if account.KeyEncryption == nil { // Check no encrypted key (checked after verification, which may encrypt a legacy key)
+		return nil, ErrNoPrivateKey // Return error
+	}
+
+	return account.unwrapDataKey(secret) // Unwrap data key
+}
+
+// encryptPrivateKey generates a new data key for the account, writes the given private key to the account's
+// encrypted keystore, and wraps the data key under the given password (and master key, if configured).
+// Since the server then holds the account's private key, the account is no longer watch-only.
+// The account itself is not persisted.
+func (account *Account) encryptPrivateKey(privateKey *ecdsa.PrivateKey, password string) error {
+	dataKey, err := crypto.RandomBytes(crypto.KeySize) // Generate data key
+
+	if err != nil { // Check for errors
+		return err // Return found error
+	}
+
+	if err = writeEncryptedKeystore(account.Address, privateKey, dataKey); err != nil { // Write keystore
+		return err // Return found error
+	}
+
+	(*account).WatchOnly = false // Server holds private key
+
+	return account.wrapDataKey(dataKey, password) // Wrap data key
+}
+
+// wrapDataKey wraps a given data key under the given password (and master key, if configured),
+// replacing any existing key encryption metadata.
+func (account *Account) wrapDataKey(dataKey []byte, password string) error {
+	kdf, err := crypto.NewScryptParams() // Init KDF params
+
+	if err != nil { // Check for errors
+		return err // Return found error
+	}
+
+	passwordKey, err := kdf.DeriveKey([]byte(password)) // Derive password key encryption key
+
+	if err != nil { // Check for errors
+		return err // Return found error
+	}
+
+	passwordWrappedKey, err := crypto.Seal(passwordKey, dataKey) // Wrap data key
+
+	if err != nil { // Check for errors
+		return err // Return found error
+	}
+
+	keyEncryption := &KeyEncryption{
+		PasswordKDF:        kdf,                // Set KDF params
+		PasswordWrappedKey: passwordWrappedKey, // Set wrapped key
+	} // Init key encryption
+
+	if len(MasterKey) != 0 { // Check master key configured
+		masterKey, err := crypto.ExpandKey(MasterKey, account.Address.Bytes(), masterKeyInfo) // Derive master key encryption key
+
+		if err != nil { // Check for errors
+			return err // Return found error
+		}
+
+		if keyEncryption.MasterWrappedKey, err = crypto.Seal(masterKey, dataKey); err != nil { // Wrap data key
+			return err // Return found error
+		}
+	}
+
+	(*account).KeyEncryption = keyEncryption // Set key encryption
+
+	return nil // No error occurred, return nil
+}
+
+// unwrapDataKey unwraps the account's data key with a given password.
+func (account *Account) unwrapDataKey(password string) ([]byte, error) {
+	if account.KeyEncryption == nil { // Check no encrypted key
+		return nil, ErrNoPrivateKey // Return error
+	}
+
+	passwordKey, err := account.KeyEncryption.PasswordKDF.DeriveKey([]byte(password)) // Derive password key encryption key
+
+	if err != nil { // Check for errors
+		return nil, err // Return found error
+	}
+
+	return crypto.Open(passwordKey, account.KeyEncryption.PasswordWrappedKey) // Unwrap data key
+}
+
+// unwrapTokenDataKey unwraps the account's data key with a given token and its secret.
+// Tokens issued without a wrapped data key fall back to the master key, if one is configured.
+func (account *Account) unwrapTokenDataKey(token *Token, secret string) ([]byte, error) {
+	if token != nil && len(token.WrappedKey) != 0 { // Check token holds data key
+		tokenKey, err := crypto.ExpandKey([]byte(secret), nil, tokenKeyInfo) // Derive token key encryption key
+
+		if err != nil { // Check for errors
+			return nil, err // Return found error
+		}
+
+		return crypto.Open(tokenKey, token.WrappedKey) // Unwrap data key
+	}
+
+	if len(MasterKey) == 0 || account.KeyEncryption == nil || len(account.KeyEncryption.MasterWrappedKey) == 0 { // Check cannot fall back to master key
+		return nil, ErrPrivateKeyLocked // Return error
+	}
+
+	masterKey, err := crypto.ExpandKey(MasterKey, account.Address.Bytes(), masterKeyInfo) // Derive master key encryption key
+
+	if err != nil { // Check for errors
+		return nil, err // Return found error
+	}
+
+	return crypto.Open(masterKey, account.KeyEncryption.MasterWrappedKey) // Unwrap data key
+}
+
+// wrapTokenDataKey wraps a given data key under a key derived from a given token secret.
+func wrapTokenDataKey(dataKey []byte, secret string) ([]byte, error) {
+	tokenKey, err := crypto.ExpandKey([]byte(secret), nil, tokenKeyInfo) // Derive token key encryption key
+
+	if err != nil { // Check for errors
+		return nil, err // Return found error
+	}
+
+	return crypto.Seal(tokenKey, dataKey) // Wrap data key
+}
+
+// encryptLegacyPrivateKey moves the plaintext go-summercash keystore of an account into its encrypted keystore.
+// The plaintext keystore is only removed once the encrypted key has been persisted.
+func (db *DB) encryptLegacyPrivateKey(account *Account, password string) error {
+	legacyKeystorePath := filepath.FromSlash(fmt.Sprintf("%s/keystore/account_%s.json", summercashCommon.DataDir, account.Address.String())) // Get plaintext keystore path
+
+	if _, err := os.Stat(legacyKeystorePath); os.IsNotExist(err) { // Check no plaintext keystore (e.g. externally managed address)
+		return nil // Nothing to encrypt
+	}
+
+	legacyAccount, err := summercashAccounts.ReadAccountFromMemory(account.Address) // Read plaintext keystore
+
+	if err != nil { // Check for errors
+		return err // Return found error
+	}
+
+	if err = account.encryptPrivateKey(legacyAccount.PrivateKey, password); err != nil { // Encrypt private key
+		return err // Return found error
+	}
+
+	err = db.store.Update(func(tx storage.Tx) error {
+		return putAccount(tx, account) // Update account
+	}) // Persist key encryption
+
+	if err != nil { // Check for errors
+		(*account).KeyEncryption = nil // Reset key encryption
+
+		return err // Return found error
+	}
+
+	logger.Infof("encrypted legacy private key of account %s", account.Name) // Log migration
+
+	return os.Remove(legacyKeystorePath) // Remove plaintext keystore
+}
+
+// writeEncryptedKeystore seals a given private key under a given data key, and writes it to the keystore of a given address.
+func writeEncryptedKeystore(address summercashCommon.Address, privateKey *ecdsa.PrivateKey, dataKey []byte) error {
+	marshaledPrivateKey, err := x509.MarshalECPrivateKey(privateKey) // Marshal private key
+
+	if err != nil { // Check for errors
+		return err // Return found error
+	}
+
+	ciphertext, err := crypto.Seal(dataKey, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: marshaledPrivateKey})) // Encrypt private key
+
+	if err != nil { // Check for errors
+		return err // Return found error
+	}
+
+	keystoreBytes, err := json.MarshalIndent(&encryptedKeystore{Address: address, Ciphertext: ciphertext}, "", "  ") // Marshal keystore
+
+	if err != nil { // Check for errors
+		return err // Return found error
+	}
+
+	if err = common.CreateDirIfDoesNotExit(filepath.Dir(KeystorePath(address))); err != nil { // Create keystore dir
+		return err // Return found error
+	}
+
+	return ioutil.WriteFile(KeystorePath(address), keystoreBytes, 0600) // Write keystore
+}
+
+// readEncryptedKeystore reads the keystore of a given address, and decrypts its private key with a given data key.
+func readEncryptedKeystore(address summercashCommon.Address, dataKey []byte) (*ecdsa.PrivateKey, error) {
+	keystoreBytes, err := ioutil.ReadFile(KeystorePath(address)) // Read keystore
+
+	if err != nil { // Check for errors
+		return nil, err // Return found error
+	}
+
+	keystore := &encryptedKeystore{} // Init keystore buffer
+
+	if err = json.Unmarshal(keystoreBytes, keystore); err != nil { // Unmarshal keystore
+		return nil, err // Return found error
+	}
+
+	pemEncoded, err := crypto.Open(dataKey, keystore.Ciphertext) // Decrypt private key
+
+	if err != nil { // Check for errors
+		return nil, err // Return found error
+	}
+
+	block, _ := pem.Decode(pemEncoded) // Decode PEM
+
+	if block == nil { // Check invalid PEM
+		return nil, crypto.ErrDecryptionFailed // Return error
+	}
+
+	return x509.ParseECPrivateKey(block.Bytes) // Parse private key
+}
+
+/* END INTERNAL METHODS */
@@ -0,0 +1,147 @@
+// Package accounts defines account-related helper methods and types.
+// The accounts database, for example, is defined in this package.
+package accounts
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SummerCash/summercash-wallet-server/storage"
+
+	summercashAccounts "github.com/SummerCash/go-summercash/accounts"
+	summercashCommon "github.com/SummerCash/go-summercash/common"
+)
+
+/* BEGIN EXPORTED METHODS TESTS */
+
+// TestUnlockPrivateKey tests the functionality of the UnlockPrivateKey() helper method.
+func TestUnlockPrivateKey(t *testing.T) {
+	setTestDataDir(t) // Use temp data dir
+
+	db := newTestDB(t) // Open test db
+
+	defer db.CloseDB() // Close db
+
+	privateKey, err := ecdsa.GenerateKey(elliptic.P521(), rand.Reader) // Generate private key
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	account, err := db.AddNewAccount("test", "test", testAddress(0).String()) // Add account
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	if err = account.encryptPrivateKey(privateKey, "test"); err != nil { // Encrypt private key
+		t.Fatal(err) // Panic
+	}
+
+	if err = db.store.Update(func(tx storage.Tx) error { return putAccount(tx, account) }); err != nil { // Persist account
+		t.Fatal(err) // Panic
+	}
+
+	if unlocked, err := db.UnlockPrivateKey("test", "test", OperationSend, ""); err != nil || unlocked.D.Cmp(privateKey.D) != 0 { // Unlock with password
+		t.Fatalf("should have unlocked private key with password: %v", err) // Panic
+	}
+
+	if _, err = db.UnlockPrivateKey("test", "wrong", OperationSend, ""); err != ErrPasswordInvalid { // Unlock with wrong password
+		t.Fatal("should not have unlocked private key with wrong password") // Panic
+	}
+
+	issued, err := db.IssueAccountToken("test", "test", "phone", []string{ScopeSend}) // Issue token
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	if err = db.ResetAccountPassword("test", "test", "test2", false); err != nil { // Reset password
+		t.Fatal(err) // Panic
+	}
+
+	if _, err = db.UnlockPrivateKey("test", "test", OperationSend, ""); err != ErrPasswordInvalid { // Unlock with old password
+		t.Fatal("should not have unlocked private key with old password") // Panic
+	}
+
+	if unlocked, err := db.UnlockPrivateKey("test", "test2", OperationSend, ""); err != nil || unlocked.D.Cmp(privateKey.D) != 0 { // Unlock with new password
+		t.Fatalf("should have unlocked private key with new password: %v", err) // Panic
+	}
+
+	if unlocked, err := db.UnlockPrivateKey("test", issued.AccessToken, OperationSend, ""); err != nil || unlocked.D.Cmp(privateKey.D) != 0 { // Unlock with token
+		t.Fatalf("should have unlocked private key with token: %v", err) // Panic
+	}
+
+	if _, err = db.UnlockPrivateKey("test", issued.AccessToken, OperationExportKey, ""); err != ErrPasswordInvalid { // Unlock with token lacking scope
+		t.Fatal("should not have unlocked private key with token lacking scope") // Panic
+	}
+
+	refreshed, err := db.RefreshAccountToken("test", issued.RefreshToken) // Refresh token
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	if _, err = db.UnlockPrivateKey("test", refreshed.AccessToken, OperationSend, ""); err != nil { // Unlock with refreshed token
+		t.Fatalf("should have unlocked private key with refreshed token: %v", err) // Panic
+	}
+}
+
+/* END EXPORTED METHODS TESTS */
+
+/* BEGIN INTERNAL METHODS TESTS */
+
+// TestEncryptLegacyPrivateKey tests that plaintext keystores are encrypted on the next password login.
+func TestEncryptLegacyPrivateKey(t *testing.T) {
+	setTestDataDir(t) // Use temp data dir
+
+	db := newTestDB(t) // Open test db
+
+	defer db.CloseDB() // Close db
+
+	privateKey, err := ecdsa.GenerateKey(elliptic.P521(), rand.Reader) // Generate private key
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	legacyAccount, err := summercashAccounts.AccountFromKey(privateKey) // Init legacy account
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	if err = legacyAccount.WriteToMemory(); err != nil { // Write plaintext keystore
+		t.Fatal(err) // Panic
+	}
+
+	account, err := db.AddNewAccount("test", "test", legacyAccount.Address.String()) // Add account
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	(*account).WatchOnly = false // Simulate record written before watch-only accounts (which migrations leave unflagged while a plaintext keystore exists)
+
+	if err = db.store.Update(func(tx storage.Tx) error { return putAccount(tx, account) }); err != nil { // Persist account
+		t.Fatal(err) // Panic
+	}
+
+	if !db.Auth("test", "test") { // Log in
+		t.Fatal("should have authenticated") // Panic
+	}
+
+	if _, err = os.Stat(filepath.Join(summercashCommon.DataDir, "keystore", "account_"+legacyAccount.Address.String()+".json")); !os.IsNotExist(err) { // Check plaintext keystore removed
+		t.Fatal("plaintext keystore should have been removed") // Panic
+	}
+
+	if unlocked, err := db.UnlockPrivateKey("test", "test", OperationSend, ""); err != nil || unlocked.D.Cmp(privateKey.D) != 0 { // Unlock migrated key
+		t.Fatalf("should have unlocked migrated private key: %v", err) // Panic
+	}
+}
+
+/* END INTERNAL METHODS TESTS */
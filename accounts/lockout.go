@@ -0,0 +1,324 @@
+// Package accounts defines account-related helper methods and types.
+// The accounts database, for example, is defined in this package.
+package accounts
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/SummerCash/summercash-wallet-server/storage"
+)
+
+var (
+	// ErrAccountLockedOut is an error definition describing a login attempt for a username or from a source address that
+	// has been locked out after too many failed attempts.
+	ErrAccountLockedOut = errors.New("too many failed login attempts; try again later")
+)
+
+var (
+	// LockoutThreshold is the number of consecutive failed logins for a username after which the username is locked out.
+	LockoutThreshold uint = 5
+
+	// SourceLockoutThreshold is the number of consecutive failed logins from a source address after which the address is locked out.
+	// Source addresses may be shared by many users, so the threshold is higher than that of a username.
+	SourceLockoutThreshold uint = 20
+
+	// LockoutBaseDuration is the duration of the first lockout. Each further failed login doubles the duration.
+	LockoutBaseDuration = 1 * time.Minute
+
+	// LockoutMaxDuration is the maximum duration of a lockout. Failed logins are forgotten after this long without a failure.
+	LockoutMaxDuration = 24 * time.Hour
+)
+
+var (
+	// loginAttemptsBucket is the username / source address => failed login attempts bucket key definition.
+	loginAttemptsBucket = []byte("login_attempts")
+)
+
+// LoginAttempts represents the failed logins recorded for a username or source address.
+type LoginAttempts struct {
+	Failures uint `json:"failures"` // Number of consecutive failed logins
+
+	LastFailure time.Time `json:"last_failure"` // Time of the last failed login
+
+	LockedUntil time.Time `json:"locked_until"` // Time the current lockout ends (zero if never locked out)
+}
+
+/* BEGIN EXPORTED METHODS */
+
+// FromSource returns a handle to the working database that attributes failed logins to a given source address
+// (e.g. the IP address a request was received from), in addition to the username they were made for.
+func (db *DB) FromSource(source string) *DB {
+	return db.FromClient(source, db.userAgent) // Return handle
+}
+
+// FromClient returns a handle to the working database used on behalf of a client with a given source address and user agent.
+// Failed logins are attributed to the source address (see FromSource), and logins are told apart by device on both (see IssueAccountToken).
+func (db *DB) FromClient(source string, userAgent string) *DB {
+	return &DB{
+		store:     db.store,  // Set store
+		source:    source,    // Set source
+		userAgent: userAgent, // Set user agent
+	} // Return handle
+}
+
+// UnlockAccount clears the failed logins recorded for the account with a given username, lifting any lockout.
+func (db *DB) UnlockAccount(name string) (err error) {
+	defer func() { db.RecordAuditEvent(name, AuditActionUnlockAccount, "", err) }() // Record attempt
+
+	account, err := db.QueryAccountByUsername(name) // Query account
+
+	if err != nil { // Check for errors
+		return err // Return found error
+	}
+
+	logger.Infof("unlocking account %s", account.Name) // Log unlock
+
+	return db.clearLoginAttempts(usernameAttemptsKey(account.Name)) // Clear attempts
+}
+
+// UnlockSource clears the failed logins recorded for a given source address, lifting any lockout.
+func (db *DB) UnlockSource(source string) error {
+	logger.Infof("unlocking source %s", source) // Log unlock
+
+	return db.clearLoginAttempts(sourceAttemptsKey(source)) // Clear attempts
+}
+
+// PurgeStaleLoginAttempts removes the failed logins that have been forgotten (see LockoutMaxDuration) from the working database.
+// Returns the number of records removed.
+func (db *DB) PurgeStaleLoginAttempts() (int, error) {
+	err := db.CreateAccountsBucketIfNotExist() // Create login attempts bucket
+
+	if err != nil { // Check for errors
+		return 0, err // Return found error
+	}
+
+	purged := 0 // Init purged counter
+
+	err = db.store.Update(func(tx storage.Tx) error {
+		purged = 0 // Reset counter
+
+		bucket := tx.Bucket(loginAttemptsBucket) // Get bucket
+
+		var staleKeys [][]byte // Init stale keys buffer
+
+		err := bucket.ForEach(func(key []byte, value []byte) error {
+			attempts := &LoginAttempts{} // Init attempts buffer
+
+			if err := json.Unmarshal(value, attempts); err != nil || attempts.stale() { // Check undecodable or stale
+				staleKeys = append(staleKeys, append([]byte{}, key...)) // Mark stale
+			}
+
+			return nil // Continue
+		}) // Find stale records
+
+		if err != nil { // Check for errors
+			return err // Return found error
+		}
+
+		for _, key := range staleKeys { // Iterate through stale records
+			if err := bucket.Delete(key); err != nil { // Delete record
+				return err // Return found error
+			}
+
+			purged++ // Increment counter
+		}
+
+		return nil // No error occurred, return nil
+	}) // Purge stale records
+
+	return purged, err // Return purged count
+}
+
+/* END EXPORTED METHODS */
+
+/* BEGIN INTERNAL METHODS */
+
+// checkLockout checks that neither a given username, nor the source address of the working database handle, is locked out.
+func (db *DB) checkLockout(name string) error {
+	return db.store.View(func(tx storage.Tx) error {
+		bucket := tx.Bucket(loginAttemptsBucket) // Get bucket
+
+		if bucket == nil { // Check nothing recorded
+			return nil // Not locked out
+		}
+
+		for _, key := range db.attemptsKeys(name) { // Iterate through keys
+			attempts, err := loginAttemptsFromBytes(bucket.Get(key)) // Get attempts
+
+			if err != nil { // Check for errors
+				return err // Return found error
+			}
+
+			if time.Now().Before(attempts.LockedUntil) { // Check locked out
+				return ErrAccountLockedOut // Return error
+			}
+		}
+
+		return nil // Not locked out
+	}) // Check lockouts
+}
+
+// recordLoginFailure records a failed login for a given username (if any), and for the source address of the working database handle (if any),
+// locking either out once its threshold is reached.
+func (db *DB) recordLoginFailure(name string) {
+	err := db.CreateAccountsBucketIfNotExist() // Create login attempts bucket
+
+	if err == nil { // Check bucket exists
+		err = db.store.Update(func(tx storage.Tx) error {
+			if name != "" { // Check has username
+				if err := putLoginFailure(tx, usernameAttemptsKey(name), LockoutThreshold); err != nil { // Record username failure
+					return err // Return found error
+				}
+			}
+
+			if db.source != "" { // Check has source
+				return putLoginFailure(tx, sourceAttemptsKey(db.source), SourceLockoutThreshold) // Record source failure
+			}
+
+			return nil // No error occurred, return nil
+		}) // Record failure
+	}
+
+	if err != nil { // Check for errors
+		logger.Errorf("failed to record failed login for account %s: %s", name, err.Error()) // Log error
+	}
+}
+
+// putLoginFailure records a failed login in the record with a given key, locking the record out if a given threshold has been reached.
+func putLoginFailure(tx storage.Tx, key []byte, threshold uint) error {
+	bucket := tx.Bucket(loginAttemptsBucket) // Get bucket
+
+	attempts, err := loginAttemptsFromBytes(bucket.Get(key)) // Get attempts
+
+	if err != nil { // Check for errors
+		return err // Return found error
+	}
+
+	if attempts.fail(threshold) { // Record failure
+		logger.Infof("locking out %s until %s after %d failed logins", key, attempts.LockedUntil.Format(time.RFC3339), attempts.Failures) // Log lockout
+	}
+
+	return bucket.Put(key, attempts.bytes()) // Put attempts
+}
+
+// resetLoginFailures forgets the failed logins recorded for a given username after a successful login.
+// Failures recorded for the source address are kept, since they may have been made for other usernames.
+func (db *DB) resetLoginFailures(name string) {
+	if err := db.clearLoginAttempts(usernameAttemptsKey(name)); err != nil { // Clear attempts
+		logger.Errorf("failed to reset failed logins for account %s: %s", name, err.Error()) // Log error
+	}
+}
+
+// clearLoginAttempts deletes the failed login record with a given key, if one exists.
+func (db *DB) clearLoginAttempts(key []byte) error {
+	exists := false // Init exists buffer
+
+	err := db.store.View(func(tx storage.Tx) error {
+		bucket := tx.Bucket(loginAttemptsBucket) // Get bucket
+
+		exists = bucket != nil && bucket.Get(key) != nil // Check exists
+
+		return nil // No error occurred, return nil
+	}) // Check record exists, so that successful logins don't each cost a write
+
+	if err != nil || !exists { // Check for errors or nothing to clear
+		return err // Return error (if any)
+	}
+
+	return db.store.Update(func(tx storage.Tx) error {
+		return tx.Bucket(loginAttemptsBucket).Delete(key) // Delete record
+	}) // Delete record
+}
+
+// attemptsKeys gets the failed login record keys of a given username (if any), and of the source address of the working database handle (if any).
+func (db *DB) attemptsKeys(name string) [][]byte {
+	var keys [][]byte // Init keys buffer
+
+	if name != "" { // Check has username
+		keys = append(keys, usernameAttemptsKey(name)) // Append username key
+	}
+
+	if db.source != "" { // Check has source
+		keys = append(keys, sourceAttemptsKey(db.source)) // Append source key
+	}
+
+	return keys // Return keys
+}
+
+// usernameAttemptsKey gets the failed login record key of a given username.
+func usernameAttemptsKey(name string) []byte {
+	return []byte("user/" + NormalizeUsername(name)) // Return key
+}
+
+// sourceAttemptsKey gets the failed login record key of a given source address.
+func sourceAttemptsKey(source string) []byte {
+	return []byte("source/" + source) // Return key
+}
+
+// loginAttemptsFromBytes deserializes a failed login record. A nil record deserializes to an empty record.
+func loginAttemptsFromBytes(b []byte) (*LoginAttempts, error) {
+	attempts := &LoginAttempts{} // Init buffer
+
+	if b == nil { // Check nothing recorded
+		return attempts, nil // Return empty record
+	}
+
+	if err := json.Unmarshal(b, attempts); err != nil { // Decode record
+		return nil, err // Return found error
+	}
+
+	return attempts, nil // Return record
+}
+
+// fail records a failed login, locking the record out if a given threshold has been reached.
+// Returns whether or not the record was locked out.
+func (attempts *LoginAttempts) fail(threshold uint) bool {
+	if attempts.stale() { // Check previous failures forgotten
+		*attempts = LoginAttempts{} // Reset record
+	}
+
+	now := time.Now() // Get current time
+
+	(*attempts).Failures++        // Increment failures
+	(*attempts).LastFailure = now // Set last failure
+
+	if attempts.Failures < threshold { // Check threshold not reached
+		return false // Not locked out
+	}
+
+	(*attempts).LockedUntil = now.Add(lockoutDuration(attempts.Failures, threshold)) // Lock out
+
+	return true // Locked out
+}
+
+// stale checks whether or not a record's failed logins should be forgotten.
+func (attempts *LoginAttempts) stale() bool {
+	return time.Since(attempts.LastFailure) > LockoutMaxDuration && time.Now().After(attempts.LockedUntil) // Check no recent failure, and not locked out
+}
+
+// bytes serializes a failed login record.
+func (attempts *LoginAttempts) bytes() []byte {
+	marshaledVal, _ := json.Marshal(*attempts) // Marshal
+
+	return marshaledVal // Return marshaled val
+}
+
+// lockoutDuration gets the duration of the lockout that a given number of failed logins causes, given a threshold.
+// The duration doubles with every failure past the threshold, up to LockoutMaxDuration.
+func lockoutDuration(failures uint, threshold uint) time.Duration {
+	duration := LockoutBaseDuration // Init duration
+
+	for i := threshold; i < failures && duration < LockoutMaxDuration; i++ { // Double for each failure past the threshold
+		duration *= 2 // Double
+	}
+
+	if duration > LockoutMaxDuration { // Check exceeds max
+		return LockoutMaxDuration // Return max
+	}
+
+	return duration // Return duration
+}
+
+/* END INTERNAL METHODS */
@@ -0,0 +1,108 @@
+// Package accounts defines account-related helper methods and types.
+// The accounts database, for example, is defined in this package.
+package accounts
+
+import (
+	"testing"
+	"time"
+)
+
+/* BEGIN EXPORTED METHODS TESTS */
+
+// TestLockout tests that repeated failed logins lock out a username, and that UnlockAccount() lifts the lockout.
+func TestLockout(t *testing.T) {
+	db := newTestDB(t) // Open test db
+
+	defer db.CloseDB() // Close db
+
+	if _, err := db.AddNewAccount("test", "test", testAddress(0).String()); err != nil { // Add account
+		t.Fatal(err) // Panic
+	}
+
+	issued, err := db.IssueAccountToken("test", "test", "test", nil) // Issue token
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	for i := uint(0); i < LockoutThreshold; i++ { // Fail until locked out
+		if err = db.FromSource("127.0.0.1").Authenticate("test", "wrong"); err != ErrPasswordInvalid { // Authenticate with wrong password
+			t.Fatalf("expected ErrPasswordInvalid, got %v", err) // Panic
+		}
+
+		if err = db.AuthenticateScoped("test", issued.AccessToken, ScopeRead); err != nil { // Authenticate with token (should not count as a failure)
+			t.Fatal(err) // Panic
+		}
+	}
+
+	if err = db.FromSource("127.0.0.2").Authenticate("test", "test"); err != ErrAccountLockedOut { // Authenticate with correct password from other source
+		t.Fatalf("expected ErrAccountLockedOut, got %v", err) // Panic
+	}
+
+	if _, err = db.IssueAccountToken("test", "test", "test", nil); err != ErrAccountLockedOut { // Issue token while locked out
+		t.Fatalf("expected ErrAccountLockedOut, got %v", err) // Panic
+	}
+
+	if err = db.UnlockAccount("test"); err != nil { // Unlock
+		t.Fatal(err) // Panic
+	}
+
+	if err = db.Authenticate("test", "test"); err != nil { // Authenticate after unlock
+		t.Fatal(err) // Panic
+	}
+}
+
+// TestSourceLockout tests that repeated failed logins lock out a source address, and that UnlockSource() lifts the lockout.
+func TestSourceLockout(t *testing.T) {
+	db := newTestDB(t) // Open test db
+
+	defer db.CloseDB() // Close db
+
+	if _, err := db.AddNewAccount("test", "test", testAddress(0).String()); err != nil { // Add account
+		t.Fatal(err) // Panic
+	}
+
+	source := db.FromSource("127.0.0.1") // Get source handle
+
+	for i := uint(0); i < SourceLockoutThreshold; i++ { // Fail until locked out
+		if err := source.Authenticate("nobody", "wrong"); err != ErrAccountDoesNotExist { // Authenticate unknown user
+			t.Fatalf("expected ErrAccountDoesNotExist, got %v", err) // Panic
+		}
+	}
+
+	if err := source.Authenticate("test", "test"); err != ErrAccountLockedOut { // Authenticate from locked out source
+		t.Fatalf("expected ErrAccountLockedOut, got %v", err) // Panic
+	}
+
+	if err := db.FromSource("127.0.0.2").Authenticate("test", "test"); err != nil { // Authenticate from other source
+		t.Fatal(err) // Panic
+	}
+
+	if err := db.UnlockSource("127.0.0.1"); err != nil { // Unlock source
+		t.Fatal(err) // Panic
+	}
+
+	if err := source.Authenticate("test", "test"); err != nil { // Authenticate after unlock
+		t.Fatal(err) // Panic
+	}
+}
+
+/* END EXPORTED METHODS TESTS */
+
+/* BEGIN INTERNAL METHODS TESTS */
+
+// TestLockoutDuration tests the functionality of the lockoutDuration() helper method.
+func TestLockoutDuration(t *testing.T) {
+	for failures, expected := range map[uint]time.Duration{
+		5:   LockoutBaseDuration,     // First lockout
+		6:   2 * LockoutBaseDuration, // Doubled
+		8:   8 * LockoutBaseDuration, // Doubled thrice
+		500: LockoutMaxDuration,      // Capped
+	} {
+		if duration := lockoutDuration(failures, 5); duration != expected { // Check mismatch
+			t.Errorf("expected %s lockout after %d failures, got %s", expected, failures, duration) // Log error
+		}
+	}
+}
+
+/* END INTERNAL METHODS TESTS */
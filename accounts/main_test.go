@@ -0,0 +1,18 @@
+// Package accounts defines account-related helper methods and types.
+// The accounts database, for example, is defined in this package.
+package accounts
+
+import (
+	"os"
+	"testing"
+
+	"github.com/SummerCash/summercash-wallet-server/crypto"
+)
+
+// TestMain runs the accounts package tests with a cheap password hasher and KDF, so that tests creating many accounts stay fast.
+func TestMain(m *testing.M) {
+	crypto.DefaultPasswordHasher = crypto.NewBcryptHasher(4) // Use minimum bcrypt cost
+	crypto.DefaultScryptN = 1 << 10                          // Use cheap scrypt cost
+
+	os.Exit(m.Run()) // Run tests
+}
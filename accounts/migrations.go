@@ -0,0 +1,318 @@
+// Package accounts defines account-related helper methods and types.
+// The accounts database, for example, is defined in this package.
+package accounts
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/SummerCash/summercash-wallet-server/storage"
+
+	"github.com/SummerCash/summercash-wallet-server/crypto"
+)
+
+// Migration represents a single, ordered change to the layout of the accounts database.
+type Migration struct {
+	Version uint64 `json:"version"` // Schema version the database is at once the migration has been applied
+
+	Description string `json:"description"` // Human-readable description of the migration
+
+	Migrate func(tx storage.Tx) error `json:"-"` // Migration body
+}
+
+var (
+	// ErrSchemaTooNew is an error definition describing a database written by a newer version of the server.
+	ErrSchemaTooNew = errors.New("database schema is newer than this server supports")
+
+	// errDryRun is an error used to roll back a dry-run migration transaction.
+	errDryRun = errors.New("dry run")
+)
+
+var (
+	// metadataBucket is the metadata bucket key definition.
+	metadataBucket = []byte("metadata")
+
+	// schemaVersionKey is the key of the schema version in the metadata bucket.
+	schemaVersionKey = []byte("schema_version")
+)
+
+// Migrations is the ordered list of migrations that bring a database up to the current schema version.
+// New migrations must be appended with a version one greater than the last.
+var Migrations = []Migration{
+	{
+		Version:     1,
+		Description: "index accounts by address",
+		Migrate:     rebuildAddressIndex,
+	},
+	{
+		Version:     2,
+		Description: "stamp account records with their schema version",
+		Migrate:     migrateStampSchemaVersion,
+	},
+	{
+		Version:     3,
+		Description: "replace plaintext account tokens with hashed, expiring tokens",
+		Migrate:     migrateHashLegacyTokens,
+	},
+	{
+		Version:     4,
+		Description: "rekey accounts under their normalized usernames, and index username skeletons",
+		Migrate:     migrateNormalizeUsernames,
+	},
+	{
+		Version:     5,
+		Description: "flag accounts whose private key is not held by the server as watch-only",
+		Migrate:     migrateFlagWatchOnlyAccounts,
+	},
+	{
+		Version:     6,
+		Description: "list discoverable accounts in a sorted username directory",
+		Migrate:     rebuildDirectoryIndex,
+	},
+}
+
+/* BEGIN EXPORTED METHODS */
+
+// CurrentSchemaVersion gets the schema version that the latest migration brings a database to.
+func CurrentSchemaVersion() uint64 {
+	return Migrations[len(Migrations)-1].Version // Return last version
+}
+
+// SchemaVersion gets the schema version of the working database.
+func (db *DB) SchemaVersion() (uint64, error) {
+	version := uint64(0) // Init version buffer
+
+	err := db.store.View(func(tx storage.Tx) error {
+		version = readSchemaVersion(tx) // Read version
+
+		return nil // No error occurred, return nil
+	}) // Read version
+
+	return version, err // Return version
+}
+
+// Migrate applies all pending migrations to the working database, in order, in a single transaction.
+// If dryRun is true, the migrations are run, but rolled back rather than committed.
+// Returns the list of migrations that were (or would have been) applied.
+func (db *DB) Migrate(dryRun bool) ([]Migration, error) {
+	var applied []Migration // Init applied migrations buffer
+
+	err := db.store.Update(func(tx storage.Tx) error {
+		applied = []Migration{} // Reset buffer
+
+		version := readSchemaVersion(tx) // Get current version
+
+		if version > CurrentSchemaVersion() { // Check database from the future
+			return ErrSchemaTooNew // Return error
+		}
+
+		for _, migration := range Migrations { // Iterate through migrations
+			if migration.Version <= version { // Check already applied
+				continue // Skip
+			}
+
+			logger.Infof("applying migration %d (%s) (dry run: %t)", migration.Version, migration.Description, dryRun) // Log migration
+
+			err := migration.Migrate(tx) // Migrate
+
+			if err != nil { // Check for errors
+				logger.Errorf("migration %d failed: %s", migration.Version, err.Error()) // Log error
+
+				return err // Return found error
+			}
+
+			err = writeSchemaVersion(tx, migration.Version) // Bump version
+
+			if err != nil { // Check for errors
+				return err // Return found error
+			}
+
+			applied = append(applied, migration) // Append applied migration
+		}
+
+		if len(applied) > 0 { // Check migrated
+			if err := stampSchemaVersion(tx, CurrentSchemaVersion()); err != nil { // Stamp accounts
+				return err // Return found error
+			}
+		}
+
+		if dryRun { // Check is dry run
+			return errDryRun // Roll back
+		}
+
+		return nil // No error occurred, return nil
+	}) // Run migrations
+
+	if err != nil && err != errDryRun { // Check for errors
+		return nil, err // Return found error
+	}
+
+	return applied, nil // Return applied migrations
+}
+
+// DryRunMigrations opens the local DB, and reports the migrations that OpenDB would apply without committing them.
+func DryRunMigrations() ([]Migration, error) {
+	db, err := openStore(Backend) // Open DB
+
+	if err != nil { // Check for errors
+		return nil, err // Return found error
+	}
+
+	defer db.CloseDB() // Close DB
+
+	return db.Migrate(true) // Dry run
+}
+
+/* END EXPORTED METHODS */
+
+/* BEGIN INTERNAL METHODS */
+
+// readSchemaVersion reads the schema version from the metadata bucket in a given transaction.
+// Databases without a metadata bucket predate versioning, and are at version zero.
+func readSchemaVersion(tx storage.Tx) uint64 {
+	bucket := tx.Bucket(metadataBucket) // Get metadata bucket
+
+	if bucket == nil { // Check unversioned
+		return 0 // Unversioned
+	}
+
+	if versionBytes := bucket.Get(schemaVersionKey); len(versionBytes) == 8 { // Check has version
+		return binary.BigEndian.Uint64(versionBytes) // Return version
+	}
+
+	return 0 // Unversioned
+}
+
+// writeSchemaVersion writes a given schema version to the metadata bucket in a given transaction.
+func writeSchemaVersion(tx storage.Tx, version uint64) error {
+	bucket, err := tx.CreateBucketIfNotExists(metadataBucket) // Get metadata bucket
+
+	if err != nil { // Check for errors
+		return err // Return found error
+	}
+
+	versionBytes := make([]byte, 8) // Init version buffer
+
+	binary.BigEndian.PutUint64(versionBytes, version) // Encode version
+
+	return bucket.Put(schemaVersionKey, versionBytes) // Put version
+}
+
+// migrateStampSchemaVersion introduced the per-record schema version.
+// The records themselves are stamped by Migrate once every pending migration has been applied, so that they carry the
+// version the database ends up at, rather than that of the migration that first stamped them.
+func migrateStampSchemaVersion(tx storage.Tx) error {
+	return nil // Nothing to do
+}
+
+// stampSchemaVersion rewrites each account record so that it carries a given schema version.
+func stampSchemaVersion(tx storage.Tx, version uint64) error {
+	encodedVersion, err := json.Marshal(version) // Encode version
+
+	if err != nil { // Check for errors
+		return err // Return found error
+	}
+
+	return forEachAccountRecord(tx, func(record map[string]json.RawMessage) (bool, error) {
+		record["schema_version"] = encodedVersion // Set version
+
+		return true, nil // Record changed
+	}) // Stamp accounts
+}
+
+// migrateHashLegacyTokens replaces the plaintext token strings stored in legacy account records with hashed tokens.
+// Legacy tokens keep working until they expire, but lose the ability to authorize destructive operations.
+func migrateHashLegacyTokens(tx storage.Tx) error {
+	return forEachAccountRecord(tx, func(record map[string]json.RawMessage) (bool, error) {
+		var legacyTokens []string // Init legacy tokens buffer
+
+		if err := json.Unmarshal(record["tokens"], &legacyTokens); err != nil { // Check tokens not legacy
+			return false, nil // Skip account
+		}
+
+		tokens := []*Token{} // Init tokens buffer
+
+		for _, legacyToken := range legacyTokens { // Iterate through legacy tokens
+			tokens = append(tokens, &Token{
+				ID:        hex.EncodeToString(crypto.Sha3([]byte(legacyToken))[:8]), // Set ID
+				SessionID: hex.EncodeToString(crypto.Sha3([]byte(legacyToken))[:8]), // Give each legacy token its own session
+				Type:      TokenTypeAccess,                                          // Set type
+				Hash:      crypto.Sha3([]byte(legacyToken)),                         // Set hash
+				Device:    "legacy",                                                 // Set device
+				Scopes:    DefaultTokenScopes,                                       // Set scopes
+				IssuedAt:  time.Now(),                                               // Set issue time
+				ExpiresAt: time.Now().Add(RefreshTokenLifetime),                     // Set expiry
+			}) // Hash legacy token
+		}
+
+		encodedTokens, err := json.Marshal(tokens) // Encode tokens
+
+		if err != nil { // Check for errors
+			return false, err // Return found error
+		}
+
+		record["tokens"] = encodedTokens // Set tokens
+
+		return true, nil // Record changed
+	}) // Hash tokens
+}
+
+// forEachAccountRecord calls a given function with the raw, undecoded fields of each record in the accounts bucket.
+// Migrations operate on raw records, since legacy records may not decode into the current Account type.
+// If the function reports that it changed the record, the record is written back.
+func forEachAccountRecord(tx storage.Tx, fn func(record map[string]json.RawMessage) (bool, error)) error {
+	bucket := tx.Bucket(accountsBucket) // Get accounts bucket
+
+	if bucket == nil { // Check no accounts yet
+		return nil // Nothing to migrate
+	}
+
+	var keys [][]byte // Init keys buffer
+
+	err := bucket.ForEach(func(key, _ []byte) error {
+		keys = append(keys, append([]byte{}, key...)) // Copy key
+
+		return nil // No error occurred, return nil
+	}) // Collect keys
+
+	if err != nil { // Check for errors
+		return err // Return found error
+	}
+
+	for _, key := range keys { // Iterate through keys
+		record := make(map[string]json.RawMessage) // Init raw record buffer
+
+		if err = json.Unmarshal(bucket.Get(key), &record); err != nil { // Decode raw record
+			logger.Errorf("skipping undecodable account during migration: %s", err.Error()) // Log error
+
+			continue // Skip account
+		}
+
+		changed, err := fn(record) // Apply
+
+		if err != nil { // Check for errors
+			return err // Return found error
+		}
+
+		if !changed { // Check nothing to write
+			continue // Skip account
+		}
+
+		encoded, err := json.MarshalIndent(record, "", "  ") // Encode record
+
+		if err != nil { // Check for errors
+			return err // Return found error
+		}
+
+		if err = bucket.Put(key, encoded); err != nil { // Put account
+			return err // Return found error
+		}
+	}
+
+	return nil // No error occurred, return nil
+}
+
+/* END INTERNAL METHODS */
@@ -0,0 +1,122 @@
+// Package accounts defines account-related helper methods and types.
+// The accounts database, for example, is defined in this package.
+package accounts
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/SummerCash/summercash-wallet-server/storage"
+
+	summercashCommon "github.com/SummerCash/go-summercash/common"
+	"github.com/SummerCash/summercash-wallet-server/crypto"
+)
+
+/* BEGIN EXPORTED METHODS TESTS */
+
+// TestMigrate tests the functionality of the Migrate() helper method.
+func TestMigrate(t *testing.T) {
+	db := newTestDB(t) // Open test db
+
+	defer db.CloseDB() // Close db
+
+	legacyAccount, _ := json.Marshal(struct {
+		Name    string                   `json:"name"`
+		Address summercashCommon.Address `json:"address"`
+		Tokens  []string                 `json:"tokens"`
+	}{"test", testAddress(0), []string{"legacy_token"}}) // Marshal account as written before versioning
+
+	err := db.store.Update(func(tx storage.Tx) error {
+		return tx.Bucket(accountsBucket).Put(crypto.Sha3([]byte("test")), legacyAccount) // Put unversioned account
+	}) // Simulate legacy record
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	pending, err := db.Migrate(true) // Dry run
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	if len(pending) != len(Migrations) { // Check not all pending
+		t.Fatalf("expected %d pending migrations, got %d", len(Migrations), len(pending)) // Panic
+	}
+
+	if version, _ := db.SchemaVersion(); version != 0 { // Check dry run committed
+		t.Fatalf("dry run should not change schema version, got %d", version) // Panic
+	}
+
+	if _, err = db.Migrate(false); err != nil { // Migrate
+		t.Fatal(err) // Panic
+	}
+
+	if version, _ := db.SchemaVersion(); version != CurrentSchemaVersion() { // Check not migrated
+		t.Fatalf("expected schema version %d, got %d", CurrentSchemaVersion(), version) // Panic
+	}
+
+	account, err := db.QueryAccountByAddress(testAddress(0)) // Query migrated account
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	if account.SchemaVersion != CurrentSchemaVersion() { // Check not stamped
+		t.Fatalf("expected account schema version %d, got %d", CurrentSchemaVersion(), account.SchemaVersion) // Panic
+	}
+
+	if !account.WatchOnly { // Check keyless account not flagged
+		t.Fatal("account without a server-held private key should be flagged as watch-only") // Panic
+	}
+
+	if !db.AuthScoped("test", "legacy_token", ScopeSend) || db.AuthScoped("test", "legacy_token", ScopeAdmin) { // Check legacy token not migrated
+		t.Fatal("legacy token should be usable to send, but not to administer the account") // Panic
+	}
+
+	if applied, _ := db.Migrate(false); len(applied) != 0 { // Check reapplied
+		t.Fatal("migrations should not be reapplied") // Panic
+	}
+}
+
+// TestMigrateRestampsSchemaVersion tests that migrating a versioned database restamps its account records.
+func TestMigrateRestampsSchemaVersion(t *testing.T) {
+	db := newTestDB(t) // Open test db
+
+	defer db.CloseDB() // Close db
+
+	stampedAccount, _ := json.Marshal(struct {
+		Name          string                   `json:"name"`
+		Address       summercashCommon.Address `json:"address"`
+		Tokens        []string                 `json:"tokens"`
+		SchemaVersion uint64                   `json:"schema_version"`
+	}{"test", testAddress(0), []string{}, 2}) // Marshal account as written at schema version 2
+
+	err := db.store.Update(func(tx storage.Tx) error {
+		if err := tx.Bucket(accountsBucket).Put(crypto.Sha3([]byte("test")), stampedAccount); err != nil { // Put stamped account
+			return err // Return found error
+		}
+
+		return writeSchemaVersion(tx, 2) // Set version
+	}) // Simulate version 2 database
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	if _, err = db.Migrate(false); err != nil { // Migrate
+		t.Fatal(err) // Panic
+	}
+
+	account, err := db.QueryAccountByUsername("test") // Query migrated account
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	if account.SchemaVersion != CurrentSchemaVersion() { // Check not restamped
+		t.Fatalf("expected account schema version %d, got %d", CurrentSchemaVersion(), account.SchemaVersion) // Panic
+	}
+}
+
+/* END EXPORTED METHODS TESTS */
@@ -0,0 +1,191 @@
+// Package accounts defines account-related helper methods and types.
+// The accounts database, for example, is defined in this package.
+package accounts
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/SummerCash/summercash-wallet-server/storage"
+
+	summercashAccounts "github.com/SummerCash/go-summercash/accounts"
+	summercashCommon "github.com/SummerCash/go-summercash/common"
+	"github.com/SummerCash/go-summercash/types"
+	"github.com/SummerCash/summercash-wallet-server/crypto"
+)
+
+var (
+	// ErrRecoveryPhraseMismatch is an error definition describing a valid recovery phrase that does not derive an account's address.
+	ErrRecoveryPhraseMismatch = errors.New("recovery phrase does not match the given account")
+)
+
+// maxRecoveryKeyIndex is the number of key indices tried when deriving an account key from a recovery phrase.
+// go-summercash rejects addresses containing a carriage return, so derivation skips to the next index until a usable address is found.
+const maxRecoveryKeyIndex = 256
+
+/* BEGIN EXPORTED METHODS */
+
+// CreateNewRecoverableAccount creates a new account with a given name and password, whose private key is derived from a new recovery phrase.
+// The phrase is not stored, and must be shown to the user exactly once; it is the only way to regain access to the account without its password.
+// Returns the new account and its recovery phrase.
+func (db *DB) CreateNewRecoverableAccount(name string, password string) (*Account, string, error) {
+	if err := ValidateUsername(name); err != nil { // Validate username before generating a key pair
+		return &Account{}, "", err // Return found error
+	}
+
+	mnemonic, err := crypto.NewMnemonic() // Generate recovery phrase
+
+	if err != nil { // Check for errors
+		return &Account{}, "", err // Return found error
+	}
+
+	account, err := deriveRecoveryAccount(mnemonic) // Derive account
+
+	if err != nil { // Check for errors
+		return &Account{}, "", err // Return found error
+	}
+
+	if err = writeChainIfNotExist(account.Address); err != nil { // Write account chain
+		return &Account{}, "", err // Return found error
+	}
+
+	accountInstance := &Account{
+		Name:         NormalizeUsername(name),       // Set name
+		PasswordHash: crypto.Salt([]byte(password)), // Set password hash
+		Address:      account.Address,               // Set address
+		Recoverable:  true,                          // Set recoverable
+	}
+
+	err = db.CreateAccountsBucketIfNotExist() // Create accounts bucket
+
+	if err != nil { // Check for errors
+		return &Account{}, "", err // Return found error
+	}
+
+	err = db.store.Update(func(tx storage.Tx) error {
+		if err := checkUsernameAvailable(tx, accountInstance.Name, false); err != nil { // Check username available
+			return err // Return found error
+		}
+
+		if err := accountInstance.encryptPrivateKey(account.PrivateKey, password); err != nil { // Encrypt private key
+			return err // Return found error
+		}
+
+		return putAccount(tx, accountInstance) // Put account
+	}) // Add new account to DB
+
+	if err != nil { // Check for errors
+		return &Account{}, "", err // Return found error
+	}
+
+	return accountInstance, mnemonic, nil // Return account and recovery phrase
+}
+
+// RecoverAccount restores access to the account with a given username, given the recovery phrase it was created with.
+// The account's private key is re-derived from the phrase and encrypted under a given new password, and every token issued to the account is revoked.
+func (db *DB) RecoverAccount(name string, mnemonic string, newPassword string) (recoveredAccount *Account, err error) {
+	defer func() { db.RecordAuditEvent(name, AuditActionRecoverAccount, "", err) }() // Record attempt
+
+	recovered, err := deriveRecoveryAccount(mnemonic) // Derive account
+
+	if err != nil { // Check for errors
+		return &Account{}, err // Return found error
+	}
+
+	err = db.CreateAccountsBucketIfNotExist() // Create accounts bucket
+
+	if err != nil { // Check for errors
+		return &Account{}, err // Return found error
+	}
+
+	var account *Account // Init account buffer
+
+	err = db.store.Update(func(tx storage.Tx) error {
+		key := accountKey(tx, name) // Resolve account key
+
+		if key == nil { // Check no account with username
+			return ErrAccountDoesNotExist // Return error
+		}
+
+		account, err = AccountFromBytes(tx.Bucket(accountsBucket).Get(key)) // Deserialize account bytes
+
+		if err != nil { // Check for errors
+			return err // Return found error
+		}
+
+		if account.Address != recovered.Address { // Check phrase derives another address
+			return ErrRecoveryPhraseMismatch // Return error
+		}
+
+		if account.PendingDeletion() { // Check pending deletion
+			return ErrAccountPendingDeletion // Return error
+		}
+
+		if account.PasswordResetRequired && crypto.VerifySalted(account.PasswordHash, newPassword) { // Check forced reset wouldn't rotate password
+			return ErrPasswordUnchanged // Return error
+		}
+
+		if err = account.encryptPrivateKey(recovered.PrivateKey, newPassword); err != nil { // Re-encrypt private key under new password
+			return err // Return found error
+		}
+
+		(*account).PasswordHash = crypto.Salt([]byte(newPassword)) // Set salt
+		(*account).Recoverable = true                              // Set recoverable
+		(*account).PasswordResetRequired = false                   // Reset complete
+		(*account).Tokens = []*Token{}                             // Revoke all tokens, since they wrap the replaced data key
+
+		return putAccount(tx, account) // Put account
+	}) // Recover account
+
+	if err != nil { // Check for errors
+		return &Account{}, err // Return found error
+	}
+
+	logger.Infof("recovered account %s with its recovery phrase", account.Name) // Log recovery
+
+	return account, nil // Return recovered account
+}
+
+/* END EXPORTED METHODS */
+
+/* BEGIN INTERNAL METHODS */
+
+// deriveRecoveryAccount derives the go-summercash account whose private key is held by a given recovery phrase.
+func deriveRecoveryAccount(mnemonic string) (*summercashAccounts.Account, error) {
+	for index := uint32(0); index < maxRecoveryKeyIndex; index++ { // Iterate through key indices
+		privateKey, err := crypto.DeriveMnemonicKey(mnemonic, index) // Derive key
+
+		if err != nil { // Check for errors
+			return nil, err // Return found error
+		}
+
+		account, err := summercashAccounts.AccountFromKey(privateKey) // Derive account
+
+		if err != nil { // Check for errors
+			return nil, err // Return found error
+		}
+
+		if !bytes.Contains(account.Address.Bytes(), []byte{'\r'}) { // Check usable address
+			return account, nil // Return account
+		}
+	}
+
+	return nil, crypto.ErrInvalidMnemonic // No usable key could be derived
+}
+
+// writeChainIfNotExist writes a new, empty chain for a given address if one does not already exist.
+func writeChainIfNotExist(address summercashCommon.Address) error {
+	if _, err := types.ReadChainFromMemory(address); err == nil { // Check chain exists
+		return nil // Nothing to write
+	}
+
+	chain, err := types.NewChain(address) // Init chain
+
+	if err != nil { // Check for errors
+		return err // Return found error
+	}
+
+	return chain.WriteToMemory() // Write chain to memory
+}
+
+/* END INTERNAL METHODS */
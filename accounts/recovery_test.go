@@ -0,0 +1,61 @@
+// Package accounts defines account-related helper methods and types.
+// The accounts database, for example, is defined in this package.
+package accounts
+
+import (
+	"testing"
+
+	"github.com/SummerCash/go-summercash/config"
+	"github.com/SummerCash/summercash-wallet-server/crypto"
+)
+
+/* BEGIN EXPORTED METHODS TESTS */
+
+// TestRecoverAccount tests the functionality of the CreateNewRecoverableAccount() and RecoverAccount() helper methods.
+func TestRecoverAccount(t *testing.T) {
+	setTestDataDir(t) // Use temp data dir
+
+	if err := (&config.ChainConfig{}).WriteToMemory(); err != nil { // Write chain config, which account chains are initialized from
+		t.Fatal(err) // Panic
+	}
+
+	db := newTestDB(t) // Open test db
+
+	defer db.CloseDB() // Close db
+
+	account, mnemonic, err := db.CreateNewRecoverableAccount("test", "test") // Create account
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	if _, err = db.IssueAccountToken("test", "test", "test", nil); err != nil { // Issue token
+		t.Fatal(err) // Panic
+	}
+
+	otherMnemonic, _ := crypto.NewMnemonic() // Generate other mnemonic
+
+	if _, err = db.RecoverAccount("test", otherMnemonic, "new"); err != ErrRecoveryPhraseMismatch { // Recover with other mnemonic
+		t.Fatalf("expected ErrRecoveryPhraseMismatch, got %v", err) // Panic
+	}
+
+	recovered, err := db.RecoverAccount("test", mnemonic, "new") // Recover account
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	if recovered.Address != account.Address || len(recovered.Tokens) != 0 { // Check address changed or tokens kept
+		t.Fatal("recovered account should keep its address, and have its tokens revoked") // Panic
+	}
+
+	if db.Auth("test", "test") || !db.Auth("test", "new") { // Check password not reset
+		t.Fatal("recovered account should only authenticate with its new password") // Panic
+	}
+
+	if _, err = db.UnlockPrivateKey("test", "new", OperationSend, ""); err != nil { // Unlock private key with new password
+		t.Fatal(err) // Panic
+	}
+}
+
+/* END EXPORTED METHODS TESTS */
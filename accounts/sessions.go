@@ -0,0 +1,141 @@
+// Package accounts defines account-related helper methods and types.
+// The accounts database, for example, is defined in this package.
+package accounts
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+var (
+	// ErrSessionDoesNotExist is an error definition describing an unknown session ID.
+	ErrSessionDoesNotExist = errors.New("no session exists with the given ID")
+)
+
+// Session represents a single login of an account on a device, along with the tokens issued to it.
+type Session struct {
+	ID string `json:"id"` // Session ID
+
+	Device string `json:"device"` // Device label
+
+	Scopes []string `json:"scopes"` // Granted scopes
+
+	IssuedAt  time.Time `json:"issued_at"`  // Time at which the session was started
+	LastUsed  time.Time `json:"last_used"`  // Last time any of the session's tokens were used
+	ExpiresAt time.Time `json:"expires_at"` // Time at which the session's last token expires
+
+	Current bool `json:"current"` // Whether or not the session is the one making the request
+}
+
+/* BEGIN EXPORTED METHODS */
+
+// ListSessions lists the active sessions of an account, most recently used first.
+// If a current token is given, its session is marked as current.
+func (db *DB) ListSessions(username string, currentToken string) ([]*Session, error) {
+	account, err := db.QueryAccountByUsername(username) // Query account
+
+	if err != nil { // Check for errors
+		return []*Session{}, err // Return found error
+	}
+
+	currentSessionID := account.sessionIDOf(currentToken) // Get current session ID
+
+	sessions := make(map[string]*Session) // Init sessions buffer
+
+	for _, token := range account.Tokens { // Iterate through tokens
+		if token.Expired() { // Check expired
+			continue // Skip
+		}
+
+		session, ok := sessions[token.SessionID] // Get session
+
+		if !ok { // Check first token in session
+			session = &Session{
+				ID:       token.SessionID,                                              // Set ID
+				Device:   token.Device,                                                 // Set device
+				Scopes:   token.Scopes,                                                 // Set scopes
+				IssuedAt: token.sessionStarted(),                                       // Set issue time
+				Current:  token.SessionID != "" && token.SessionID == currentSessionID, // Set is current
+			} // Init session
+
+			sessions[token.SessionID] = session // Set session
+		}
+
+		if token.LastUsed.After(session.LastUsed) { // Check more recently used
+			(*session).LastUsed = token.LastUsed // Set last use
+		}
+
+		if token.ExpiresAt.After(session.ExpiresAt) { // Check expires later
+			(*session).ExpiresAt = token.ExpiresAt // Set expiry
+		}
+	}
+
+	sessionList := []*Session{} // Init session list
+
+	for _, session := range sessions { // Iterate through sessions
+		sessionList = append(sessionList, session) // Append session
+	}
+
+	sort.Slice(sessionList, func(i, j int) bool {
+		return sessionList[i].LastUsed.After(sessionList[j].LastUsed) // Sort by last use
+	}) // Sort sessions
+
+	return sessionList, nil // Return sessions
+}
+
+// RevokeSession revokes every token belonging to a given session of an account.
+func (db *DB) RevokeSession(username string, sessionID string) error {
+	_, err := db.updateAccount(username, func(account *Account) error {
+		if removed := account.removeTokens(func(token *Token) bool { return token.SessionID == sessionID }); removed == 0 { // Revoke session tokens
+			return ErrSessionDoesNotExist // Return error
+		}
+
+		return nil // No error occurred, return nil
+	}) // Revoke on the current account, so that concurrent changes aren't overwritten
+
+	return err // Return error
+}
+
+// RevokeOtherSessions revokes every session of an account other than the one a given token belongs to.
+// If the given token does not belong to a session (e.g. it is the account password), every session is revoked.
+// Returns the number of tokens revoked.
+func (db *DB) RevokeOtherSessions(username string, currentToken string) (int, error) {
+	removed := 0 // Init removed counter
+
+	_, err := db.updateAccount(username, func(account *Account) error {
+		currentSessionID := account.sessionIDOf(currentToken) // Get current session ID
+
+		removed = account.removeTokens(func(token *Token) bool {
+			return currentSessionID == "" || token.SessionID != currentSessionID // Revoke other sessions
+		}) // Revoke tokens
+
+		return nil // No error occurred, return nil
+	}) // Revoke on the current account, so that concurrent changes aren't overwritten
+
+	if err != nil { // Check for errors
+		return 0, err // Return found error
+	}
+
+	return removed, nil // Return removed count
+}
+
+/* END EXPORTED METHODS */
+
+/* BEGIN INTERNAL METHODS */
+
+// sessionIDOf gets the ID of the session that a given access or refresh token belongs to.
+// Returns an empty string if the token is not valid for the account.
+func (account *Account) sessionIDOf(secret string) string {
+	if token := account.findToken(secret, TokenTypeAccess); token != nil { // Check is access token
+		return token.SessionID // Return session ID
+	}
+
+	if token := account.findToken(secret, TokenTypeRefresh); token != nil { // Check is refresh token
+		return token.SessionID // Return session ID
+	}
+
+	return "" // Not a token
+}
+
+/* END INTERNAL METHODS */
@@ -0,0 +1,58 @@
+// Package accounts defines account-related helper methods and types.
+// The accounts database, for example, is defined in this package.
+package accounts
+
+import "testing"
+
+/* BEGIN EXPORTED METHODS TESTS */
+
+// TestRevokeOtherSessions tests the functionality of the ListSessions() and RevokeOtherSessions() helper methods.
+func TestRevokeOtherSessions(t *testing.T) {
+	db := newTestDB(t) // Open test db
+
+	defer db.CloseDB() // Close db
+
+	if _, err := db.AddNewAccount("test", "test", testAddress(0).String()); err != nil { // Add account
+		t.Fatal(err) // Panic
+	}
+
+	phone, err := db.IssueAccountToken("test", "test", "phone", nil) // Issue phone token
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	if _, err = db.IssueAccountToken("test", "test", "browser", nil); err != nil { // Issue browser token
+		t.Fatal(err) // Panic
+	}
+
+	sessions, err := db.ListSessions("test", phone.AccessToken) // List sessions
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	if len(sessions) != 2 { // Check wrong number of sessions
+		t.Fatalf("expected 2 sessions, got %d", len(sessions)) // Panic
+	}
+
+	if _, err = db.RevokeOtherSessions("test", phone.AccessToken); err != nil { // Revoke browser session
+		t.Fatal(err) // Panic
+	}
+
+	sessions, _ = db.ListSessions("test", phone.AccessToken) // List sessions
+
+	if len(sessions) != 1 || !sessions[0].Current || sessions[0].Device != "phone" { // Check revoked wrong session
+		t.Fatal("only the current session should remain") // Panic
+	}
+
+	if err = db.ResetAccountPassword("test", "test", "test2", true); err != nil { // Reset password, revoking tokens
+		t.Fatal(err) // Panic
+	}
+
+	if db.AuthScoped("test", phone.AccessToken, ScopeRead) { // Check token still valid
+		t.Fatal("password reset should revoke all tokens") // Panic
+	}
+}
+
+/* END EXPORTED METHODS TESTS */
@@ -0,0 +1,99 @@
+// Package accounts defines account-related helper methods and types.
+// The accounts database, for example, is defined in this package.
+package accounts
+
+import (
+	"errors"
+	"os"
+
+	"github.com/SummerCash/summercash-wallet-server/storage"
+)
+
+var (
+	// ErrNotAccountsDB is an error definition describing a database without an accounts bucket.
+	ErrNotAccountsDB = errors.New("database does not contain an accounts bucket")
+)
+
+// Snapshot represents a consistent, read-only view of the accounts database.
+type Snapshot struct {
+	Tx storage.Tx // Read transaction backing the view
+
+	SchemaVersion uint64 // Schema version of the view
+
+	Accounts []*Account // Every decodable account in the view
+}
+
+/* BEGIN EXPORTED METHODS */
+
+// Snapshot runs a given function within a consistent, read-only view of the working database.
+// The view is only valid for the duration of the function.
+func (db *DB) Snapshot(fn func(snapshot *Snapshot) error) error {
+	err := db.CreateAccountsBucketIfNotExist() // Create accounts bucket
+
+	if err != nil { // Check for errors
+		return err // Return found error
+	}
+
+	return db.store.View(func(tx storage.Tx) error {
+		snapshot := &Snapshot{
+			Tx:            tx,                    // Set tx
+			SchemaVersion: readSchemaVersion(tx), // Set schema version
+		} // Init snapshot
+
+		err := forEachAccount(tx.Bucket(accountsBucket), func(_ []byte, account *Account) error {
+			snapshot.Accounts = append(snapshot.Accounts, account) // Append account
+
+			return nil // No error occurred, return nil
+		}) // Collect accounts
+
+		if err != nil { // Check for errors
+			return err // Return found error
+		}
+
+		return fn(snapshot) // Run function
+	}) // Open view
+}
+
+// CheckDBFile checks the integrity of the accounts database file of a given storage backend at a given path, without migrating it.
+// Returns the schema version of the database.
+func CheckDBFile(backend string, path string) (uint64, error) {
+	if _, err := os.Stat(path); err != nil { // Check exists (opening a store creates it)
+		return 0, err // Return found error
+	}
+
+	store, err := storage.Open(backend, path) // Open db
+
+	if err != nil { // Check for errors
+		return 0, err // Return found error
+	}
+
+	defer store.Close() // Close db
+
+	if err = store.Check(); err != nil { // Check integrity
+		return 0, err // Return found error
+	}
+
+	version := uint64(0) // Init version buffer
+
+	err = store.View(func(tx storage.Tx) error {
+		if tx.Bucket(accountsBucket) == nil { // Check not an accounts db
+			return ErrNotAccountsDB // Return error
+		}
+
+		version = readSchemaVersion(tx) // Read version
+
+		return nil // No error occurred, return nil
+	}) // Check db
+
+	if err != nil { // Check for errors
+		return 0, err // Return found error
+	}
+
+	if version > CurrentSchemaVersion() { // Check too new
+		return version, ErrSchemaTooNew // Return error
+	}
+
+	return version, nil // Return version
+}
+
+/* END EXPORTED METHODS */
@@ -0,0 +1,225 @@
+// Package accounts defines account-related helper methods and types.
+// The accounts database, for example, is defined in this package.
+package accounts
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	summercashCommon "github.com/SummerCash/go-summercash/common"
+	"github.com/SummerCash/go-summercash/types"
+)
+
+const (
+	// SpendingLimitPerTransaction is the name of the limit on the amount of a single outgoing transaction.
+	SpendingLimitPerTransaction = "per_transaction"
+
+	// SpendingLimitDaily is the name of the limit on the total amount of outgoing transactions in any rolling 24 hours.
+	SpendingLimitDaily = "daily"
+)
+
+var (
+	// ErrSpendingLimitInvalid is an error definition describing an unknown spending limit, or a negative limit amount.
+	ErrSpendingLimitInvalid = errors.New("invalid spending limit")
+
+	// ErrTransactionLimitExceeded is an error definition describing a transaction larger than the account's per-transaction spending limit.
+	ErrTransactionLimitExceeded = errors.New("transaction exceeds the account's per-transaction spending limit")
+
+	// ErrDailyLimitExceeded is an error definition describing a transaction that would exceed the account's daily spending limit.
+	ErrDailyLimitExceeded = errors.New("transaction would exceed the account's daily spending limit")
+)
+
+var (
+	// SpendingLimitCooldown is the duration after which a raised (or removed) spending limit takes effect. Lowered limits take effect immediately.
+	SpendingLimitCooldown = 48 * time.Hour
+
+	// spendingLimitWindow is the rolling window the daily spending limit applies to.
+	spendingLimitWindow = 24 * time.Hour
+
+	// spendingLocks are the locks serializing the outgoing transactions of each account (see LockSpending), by normalized username.
+	spendingLocks = make(map[string]*sync.Mutex)
+
+	// spendingLocksMutex guards spendingLocks.
+	spendingLocksMutex sync.Mutex
+)
+
+// SpendingLimit represents a single self-imposed limit on an account's outgoing transactions.
+type SpendingLimit struct {
+	Amount float64 `json:"amount"` // Limit in effect (zero if unlimited)
+
+	PendingAmount float64 `json:"pending_amount"` // Raised limit awaiting the cooldown (zero if unlimited)
+
+	PendingFrom time.Time `json:"pending_from"` // Time the raised limit takes effect (zero if no limit is pending)
+}
+
+// SpendingLimits represents the self-imposed limits on an account's outgoing transactions.
+type SpendingLimits struct {
+	PerTransaction SpendingLimit `json:"per_transaction"` // Limit on the amount of a single transaction
+
+	Daily SpendingLimit `json:"daily"` // Limit on the total amount sent in any rolling 24 hours
+}
+
+/* BEGIN EXPORTED METHODS */
+
+// SetSpendingLimit sets one of the spending limits (SpendingLimitPerTransaction or SpendingLimitDaily) of the account with a given username
+// to a given amount (zero meaning unlimited), given its password (or an access token carrying the admin scope).
+// Lowering a limit takes effect immediately, while raising or removing one only takes effect after SpendingLimitCooldown,
+// so that a compromised account can't lift its own limits right away.
+func (db *DB) SetSpendingLimit(name string, secret string, limit string, amount float64) (limits *SpendingLimits, err error) {
+	defer func() { db.RecordAuditEvent(name, AuditActionSetSpendingLimit, limit, err) }() // Record attempt
+
+	if amount < 0 || (limit != SpendingLimitPerTransaction && limit != SpendingLimitDaily) { // Check invalid limit
+		return nil, ErrSpendingLimitInvalid // Return error
+	}
+
+	if err = db.AuthenticateScoped(name, secret, ScopeAdmin); err != nil { // Authenticate
+		return nil, err // Return found error
+	}
+
+	account, err := db.updateAccount(name, func(account *Account) error {
+		if account.SpendingLimits == nil { // Check no limits yet
+			(*account).SpendingLimits = &SpendingLimits{} // Init limits
+		}
+
+		target := &account.SpendingLimits.PerTransaction // Get per-transaction limit
+
+		if limit == SpendingLimitDaily { // Check daily limit
+			target = &account.SpendingLimits.Daily // Get daily limit
+		}
+
+		target.set(amount, time.Now()) // Set limit
+
+		return nil // No error occurred, return nil
+	}) // Update account
+
+	if err != nil { // Check for errors
+		return nil, err // Return found error
+	}
+
+	return account.SpendingLimits, nil // Return limits
+}
+
+// CheckSpendingLimits checks that sending a given amount from the account with a given username would respect its spending limits.
+// The amounts of the account's outgoing transactions (from any of its addresses, to addresses other than its own) in the last 24 hours are summed from its address chains.
+// The check should be made under LockSpending, and the checked transaction published before unlocking.
+func (db *DB) CheckSpendingLimits(name string, amount float64) error {
+	account, err := db.QueryAccountByUsername(name) // Query account
+
+	if err != nil { // Check for errors
+		return err // Return found error
+	}
+
+	if account.SpendingLimits == nil { // Check no limits
+		return nil // Nothing to check
+	}
+
+	now := time.Now() // Get current time
+
+	if limit := account.SpendingLimits.PerTransaction.Effective(now); limit != 0 && amount > limit { // Check exceeds per-transaction limit
+		return ErrTransactionLimitExceeded // Return error
+	}
+
+	limit := account.SpendingLimits.Daily.Effective(now) // Get daily limit
+
+	if limit == 0 { // Check unlimited
+		return nil // Nothing to check
+	}
+
+	transactions, err := db.GetUserCombinedTransactions(name) // Get transactions
+
+	if err != nil { // Check for errors
+		return err // Return found error
+	}
+
+	if sent := sumOutgoingTransactions(transactions, account.Addresses(), now.Add(-spendingLimitWindow)); sent+amount > limit { // Check exceeds daily limit
+		return ErrDailyLimitExceeded // Return error
+	}
+
+	return nil // Within limits
+}
+
+// LockSpending locks the outgoing transactions of the account with a given username, and returns the function unlocking them.
+// Holding the lock from CheckSpendingLimits until the checked transaction has been added to the account's chain keeps concurrent
+// sends from each passing the daily limit check on their own, and exceeding the limit together.
+func LockSpending(name string) func() {
+	name = NormalizeUsername(name) // Normalize username
+
+	spendingLocksMutex.Lock() // Lock locks
+
+	lock, ok := spendingLocks[name] // Get account lock
+
+	if !ok { // Check no lock yet
+		lock = &sync.Mutex{} // Init lock
+
+		spendingLocks[name] = lock // Set lock
+	}
+
+	spendingLocksMutex.Unlock() // Unlock locks
+
+	lock.Lock() // Lock account
+
+	return lock.Unlock // Return unlock
+}
+
+// Effective gets the limit in effect at a given time, taking a pending raise into account once its cooldown has passed.
+func (limit *SpendingLimit) Effective(now time.Time) float64 {
+	if !limit.PendingFrom.IsZero() && !now.Before(limit.PendingFrom) { // Check pending limit in effect
+		return limit.PendingAmount // Return pending limit
+	}
+
+	return limit.Amount // Return limit
+}
+
+/* END EXPORTED METHODS */
+
+/* BEGIN INTERNAL METHODS */
+
+// set sets the limit to a given amount at a given time: immediately if the amount lowers the limit in effect, or after SpendingLimitCooldown otherwise.
+// Setting a limit replaces any pending raise.
+func (limit *SpendingLimit) set(amount float64, now time.Time) {
+	current := limit.Effective(now) // Get limit in effect
+
+	(*limit).Amount = current          // Settle limit in effect
+	(*limit).PendingAmount = 0         // Clear pending limit
+	(*limit).PendingFrom = time.Time{} // Clear pending time
+
+	if current == amount { // Check unchanged
+		return // Nothing to do
+	}
+
+	if amount != 0 && (current == 0 || amount < current) { // Check lowers limit (zero being unlimited)
+		(*limit).Amount = amount // Set limit
+
+		return // Done
+	}
+
+	(*limit).PendingAmount = amount                       // Set pending limit
+	(*limit).PendingFrom = now.Add(SpendingLimitCooldown) // Set pending time
+}
+
+// sumOutgoingTransactions sums the amounts of the given transactions sent from any of a given set of addresses at or after a given time.
+// Transfers between the given addresses (e.g. from the primary address to a sub-address) aren't counted.
+func sumOutgoingTransactions(transactions []*types.Transaction, addresses []summercashCommon.Address, since time.Time) float64 {
+	own := make(map[summercashCommon.Address]bool) // Init own addresses set
+
+	for _, address := range addresses { // Iterate through addresses
+		own[address] = true // Add address
+	}
+
+	sent := 0.0 // Init sent buffer
+
+	for _, transaction := range transactions { // Iterate through transactions
+		if transaction.Sender == nil || transaction.Amount == nil || !own[*transaction.Sender] || transaction.Recipient != nil && own[*transaction.Recipient] || transaction.Timestamp.Before(since) { // Check not recent and outgoing
+			continue // Skip
+		}
+
+		amount, _ := transaction.Amount.Float64() // Get amount
+
+		sent += amount // Add amount
+	}
+
+	return sent // Return sent amount
+}
+
+/* END INTERNAL METHODS */
@@ -0,0 +1,235 @@
+// Package accounts defines account-related helper methods and types.
+// The accounts database, for example, is defined in this package.
+package accounts
+
+import (
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	summercashCommon "github.com/SummerCash/go-summercash/common"
+	"github.com/SummerCash/go-summercash/config"
+	"github.com/SummerCash/go-summercash/types"
+)
+
+/* BEGIN EXPORTED METHODS TESTS */
+
+// TestSetSpendingLimit tests that SetSpendingLimit() lowers limits immediately, and only raises them after the cooldown.
+func TestSetSpendingLimit(t *testing.T) {
+	db := newTestDB(t) // Open test db
+
+	defer db.CloseDB() // Close db
+
+	if _, err := db.AddNewAccount("test", "test", testAddress(0).String()); err != nil { // Add account
+		t.Fatal(err) // Panic
+	}
+
+	if _, err := db.SetSpendingLimit("test", "test", "weekly", 10); err != ErrSpendingLimitInvalid { // Set unknown limit
+		t.Fatalf("expected ErrSpendingLimitInvalid, got %v", err) // Panic
+	}
+
+	if _, err := db.SetSpendingLimit("test", "wrong", SpendingLimitPerTransaction, 10); err != ErrPasswordInvalid { // Set limit with wrong password
+		t.Fatalf("expected ErrPasswordInvalid, got %v", err) // Panic
+	}
+
+	limits, err := db.SetSpendingLimit("test", "test", SpendingLimitPerTransaction, 10) // Set first limit
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	if limits.PerTransaction.Amount != 10 || !limits.PerTransaction.PendingFrom.IsZero() { // Check limit applied immediately
+		t.Fatalf("expected first limit to apply immediately, got %+v", limits.PerTransaction) // Panic
+	}
+
+	if limits, err = db.SetSpendingLimit("test", "test", SpendingLimitPerTransaction, 20); err != nil { // Raise limit
+		t.Fatal(err) // Panic
+	}
+
+	now := time.Now() // Get current time
+
+	if limits.PerTransaction.Effective(now) != 10 || limits.PerTransaction.Effective(now.Add(SpendingLimitCooldown)) != 20 { // Check raise pending
+		t.Fatalf("expected raised limit to apply after the cooldown, got %+v", limits.PerTransaction) // Panic
+	}
+
+	if err = db.CheckSpendingLimits("test", 15); err != ErrTransactionLimitExceeded { // Send above limit in effect
+		t.Fatalf("expected ErrTransactionLimitExceeded, got %v", err) // Panic
+	}
+
+	if limits, err = db.SetSpendingLimit("test", "test", SpendingLimitPerTransaction, 5); err != nil { // Lower limit
+		t.Fatal(err) // Panic
+	}
+
+	if limits.PerTransaction.Amount != 5 || !limits.PerTransaction.PendingFrom.IsZero() { // Check lowered immediately, cancelling raise
+		t.Fatalf("expected lowered limit to apply immediately, got %+v", limits.PerTransaction) // Panic
+	}
+
+	if limits, err = db.SetSpendingLimit("test", "test", SpendingLimitPerTransaction, 0); err != nil { // Remove limit
+		t.Fatal(err) // Panic
+	}
+
+	if limits.PerTransaction.Effective(now) != 5 || limits.PerTransaction.Effective(now.Add(2*SpendingLimitCooldown)) != 0 { // Check removal pending
+		t.Fatalf("expected removed limit to apply after the cooldown, got %+v", limits.PerTransaction) // Panic
+	}
+}
+
+// TestCheckSpendingLimits tests that CheckSpendingLimits() sums the outgoing transactions of the last 24 hours (other than transfers between
+// the account's own addresses) against the daily limit.
+func TestCheckSpendingLimits(t *testing.T) {
+	setTestDataDir(t) // Use temp data dir
+
+	if err := (&config.ChainConfig{}).WriteToMemory(); err != nil { // Write chain config, which account chains are initialized from
+		t.Fatal(err) // Panic
+	}
+
+	db := newTestDB(t) // Open test db
+
+	defer db.CloseDB() // Close db
+
+	account, err := db.CreateNewAccount("test", "test") // Create account
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	chain, err := types.ReadChainFromMemory(account.Address) // Read account chain
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	other := testAddress(1) // Get other address
+
+	savings, err := db.CreateSubAddress("test", "test", "savings") // Create sub-address
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	for i, sent := range []struct {
+		transaction *types.Transaction
+		age         time.Duration
+	}{
+		{newTestTransaction(t, &account.Address, &other, 3), time.Hour},           // Recent send
+		{newTestTransaction(t, &account.Address, &other, 4), 2 * 24 * time.Hour},  // Old send
+		{newTestTransaction(t, &other, &account.Address, 5), time.Hour},           // Recent receive
+		{newTestTransaction(t, &account.Address, &savings.Address, 6), time.Hour}, // Recent transfer to own sub-address
+	} { // Iterate through transactions
+		sent.transaction.Timestamp = time.Now().Add(-sent.age) // Set timestamp
+
+		sent.transaction.AccountNonce = uint64(i) // Set nonce
+
+		chain.Transactions = append(chain.Transactions, sent.transaction) // Append transaction
+	}
+
+	if err = chain.WriteToMemory(); err != nil { // Write account chain
+		t.Fatal(err) // Panic
+	}
+
+	if _, err = db.SetSpendingLimit("test", "test", SpendingLimitDaily, 10); err != nil { // Set daily limit
+		t.Fatal(err) // Panic
+	}
+
+	if err = db.CheckSpendingLimits("test", 7); err != nil { // Send up to limit
+		t.Fatal(err) // Panic
+	}
+
+	if err = db.CheckSpendingLimits("test", 8); err != ErrDailyLimitExceeded { // Send past limit
+		t.Fatalf("expected ErrDailyLimitExceeded, got %v", err) // Panic
+	}
+}
+
+// TestLockSpending tests that concurrent sends checked and recorded under LockSpending() can't exceed the daily limit together.
+func TestLockSpending(t *testing.T) {
+	setTestDataDir(t) // Use temp data dir
+
+	if err := (&config.ChainConfig{}).WriteToMemory(); err != nil { // Write chain config, which account chains are initialized from
+		t.Fatal(err) // Panic
+	}
+
+	db := newTestDB(t) // Open test db
+
+	defer db.CloseDB() // Close db
+
+	account, err := db.CreateNewAccount("test", "test") // Create account
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	if _, err = db.SetSpendingLimit("test", "test", SpendingLimitDaily, 10); err != nil { // Set daily limit
+		t.Fatal(err) // Panic
+	}
+
+	other := testAddress(1) // Get other address
+
+	var wg sync.WaitGroup // Init wait group
+
+	results := make(chan error, 8) // Init results channel
+
+	for i := 0; i < 8; i++ { // Send concurrently
+		wg.Add(1) // Add send
+
+		go func(name string) {
+			defer wg.Done() // Done
+
+			defer LockSpending(name)() // Hold spending limits until recorded
+
+			if err := db.CheckSpendingLimits(name, 3); err != nil { // Check spending limits
+				results <- err // Send error
+
+				return // Stop
+			}
+
+			time.Sleep(10 * time.Millisecond) // Take a while to publish, as sends racing past the check would
+
+			chain, err := types.ReadChainFromMemory(account.Address) // Read account chain
+
+			if err == nil { // Check no errors
+				transaction, _ := types.NewTransaction(uint64(len(chain.Transactions)), nil, &account.Address, &other, big.NewFloat(3), nil) // Initialize transaction
+
+				chain.Transactions = append(chain.Transactions, transaction) // Record send, as publishing does
+
+				err = chain.WriteToMemory() // Write account chain
+			}
+
+			results <- err // Send result
+		}([]string{"test", "Test"}[i%2]) // Lock by either casing of the username
+	}
+
+	wg.Wait() // Wait for sends
+
+	close(results) // Close results
+
+	sent := 0 // Init sent counter
+
+	for err := range results { // Iterate through results
+		if err == nil { // Check sent
+			sent++ // Increment sent
+		} else if err != ErrDailyLimitExceeded { // Check unexpected error
+			t.Fatal(err) // Panic
+		}
+	}
+
+	if sent != 3 { // Check more sends than the limit allows
+		t.Fatalf("expected 3 sends within the daily limit, got %d", sent) // Panic
+	}
+}
+
+/* END EXPORTED METHODS TESTS */
+
+/* BEGIN TEST HELPERS */
+
+// newTestTransaction initializes a new, unsigned transaction of a given amount between two given addresses.
+func newTestTransaction(t *testing.T, sender *summercashCommon.Address, recipient *summercashCommon.Address, amount float64) *types.Transaction {
+	transaction, err := types.NewTransaction(0, nil, sender, recipient, big.NewFloat(amount), nil) // Initialize transaction
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	return transaction // Return transaction
+}
+
+/* END TEST HELPERS */
@@ -0,0 +1,244 @@
+// Package accounts defines account-related helper methods and types.
+// The accounts database, for example, is defined in this package.
+package accounts
+
+import (
+	"errors"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	summercashAccounts "github.com/SummerCash/go-summercash/accounts"
+	summercashCommon "github.com/SummerCash/go-summercash/common"
+	"github.com/SummerCash/go-summercash/types"
+)
+
+var (
+	// ErrSubAddressNameInvalid is an error definition describing a sub-address name that is empty, too long, or that could be mistaken for an address.
+	ErrSubAddressNameInvalid = errors.New("sub-address name must be between 1 and 64 characters long, and must not contain 0x")
+
+	// ErrSubAddressAlreadyExists is an error definition describing a sub-address name that is already taken.
+	ErrSubAddressAlreadyExists = errors.New("sub-address with name already exists")
+
+	// ErrSubAddressDoesNotExist is an error definition describing a sub-address that could not be found.
+	ErrSubAddressDoesNotExist = errors.New("account has no such address")
+
+	// ErrTooManySubAddresses is an error definition describing an account that has reached MaxSubAddresses.
+	ErrTooManySubAddresses = errors.New("too many sub-addresses")
+)
+
+var (
+	// MaxSubAddresses is the maximum number of sub-addresses an account may own.
+	MaxSubAddresses = 20
+
+	// maxSubAddressNameLength is the maximum length of a sub-address name, in characters.
+	maxSubAddressNameLength = 64
+)
+
+// SubAddress represents an additional, named receiving address owned by an account.
+// Its private key is kept in its own keystore, encrypted under the account's data key.
+type SubAddress struct {
+	Name string `json:"name"` // Name (unique per account, case-insensitively)
+
+	Address summercashCommon.Address `json:"address"` // Address
+
+	CreatedAt time.Time `json:"created_at"` // Creation time
+}
+
+/* BEGIN EXPORTED METHODS */
+
+// CreateSubAddress generates a new key pair for the account with a given username, and adds its address to the account under a given name.
+// The account's data key must be unlocked with the account password, or with an access token carrying the send scope.
+func (db *DB) CreateSubAddress(username string, secret string, name string) (*SubAddress, error) {
+	name = strings.TrimSpace(name) // Trim name
+
+	if name == "" || utf8.RuneCountInString(name) > maxSubAddressNameLength || strings.Contains(name, "0x") { // Check invalid name
+		return nil, ErrSubAddressNameInvalid // Return error
+	}
+
+	account, err := db.QueryAccountByUsername(username) // Query account
+
+	if err != nil { // Check for errors
+		return nil, err // Return found error
+	}
+
+	dataKey, err := db.unlockDataKey(account, secret, ScopeSend) // Unlock data key
+
+	if err != nil { // Check for errors
+		return nil, err // Return found error
+	}
+
+	if account.findSubAddress(name) != -1 { // Check name taken
+		return nil, ErrSubAddressAlreadyExists // Return error
+	}
+
+	keyPair, err := summercashAccounts.NewAccount() // Generate key pair
+
+	if err != nil { // Check for errors
+		return nil, err // Return found error
+	}
+
+	if err = writeChainIfNotExist(keyPair.Address); err != nil { // Write address chain
+		return nil, err // Return found error
+	}
+
+	if err = writeEncryptedKeystore(keyPair.Address, keyPair.PrivateKey, dataKey); err != nil { // Write keystore
+		return nil, err // Return found error
+	}
+
+	subAddress := &SubAddress{
+		Name:      name,            // Set name
+		Address:   keyPair.Address, // Set address
+		CreatedAt: time.Now(),      // Set creation time
+	} // Init sub-address
+
+	_, err = db.updateAccount(username, func(account *Account) error {
+		if account.findSubAddress(name) != -1 { // Check name taken concurrently
+			return ErrSubAddressAlreadyExists // Return error
+		}
+
+		if len(account.SubAddresses) >= MaxSubAddresses { // Check too many sub-addresses
+			return ErrTooManySubAddresses // Return error
+		}
+
+		(*account).SubAddresses = append(account.SubAddresses, subAddress) // Add sub-address
+
+		return nil // No error occurred, return nil
+	}) // Add sub-address
+
+	if err != nil { // Check for errors
+		return nil, err // Return found error
+	}
+
+	logger.Infof("created sub-address %s for account %s", subAddress.Address.String(), account.Name) // Log creation
+
+	return subAddress, nil // Return sub-address
+}
+
+// Addresses gets every address owned by an account, starting with its primary address.
+func (account *Account) Addresses() []summercashCommon.Address {
+	addresses := []summercashCommon.Address{account.Address} // Init addresses buffer
+
+	for _, subAddress := range account.SubAddresses { // Iterate through sub-addresses
+		addresses = append(addresses, subAddress.Address) // Append address
+	}
+
+	return addresses // Return addresses
+}
+
+// GetAddressBalance calculates the balance of a single address. Addresses without a chain have a zero balance.
+func (db *DB) GetAddressBalance(address summercashCommon.Address) *big.Float {
+	chain, err := types.ReadChainFromMemory(address) // Read chain
+
+	if err != nil { // Check for errors
+		return big.NewFloat(0) // No chain, no balance
+	}
+
+	return chain.CalculateBalance() // Return calculated balance
+}
+
+// GetUserCombinedBalance calculates the combined balance of every address owned by a particular account.
+func (db *DB) GetUserCombinedBalance(username string) (*big.Float, error) {
+	account, err := db.QueryAccountByUsername(username) // Query account
+
+	if err != nil { // Check for errors
+		return big.NewFloat(0), err // Return found error
+	}
+
+	balance := big.NewFloat(0) // Init balance
+
+	for _, address := range account.Addresses() { // Iterate through addresses
+		balance.Add(balance, db.GetAddressBalance(address)) // Add address balance
+	}
+
+	return balance, nil // Return combined balance
+}
+
+// GetUserCombinedTransactions fetches the transactions of every address owned by a particular account, oldest first.
+// Transfers between the account's own addresses are only listed once.
+func (db *DB) GetUserCombinedTransactions(username string) ([]*types.Transaction, error) {
+	account, err := db.QueryAccountByUsername(username) // Query account
+
+	if err != nil { // Check for errors
+		return []*types.Transaction{}, err // Return found error
+	}
+
+	seen := make(map[summercashCommon.Hash]bool) // Init seen transactions buffer
+
+	transactions := []*types.Transaction{} // Init transactions buffer
+
+	for _, address := range account.Addresses() { // Iterate through addresses
+		chain, err := types.ReadChainFromMemory(address) // Read chain
+
+		if err != nil { // Check for errors
+			continue // No chain, no transactions
+		}
+
+		for _, transaction := range chain.Transactions { // Iterate through transactions
+			if transaction.Hash != nil && seen[*transaction.Hash] { // Check already listed
+				continue // Skip
+			}
+
+			if transaction.Hash != nil { // Check has hash
+				seen[*transaction.Hash] = true // Mark listed
+			}
+
+			transactions = append(transactions, transaction) // Append transaction
+		}
+	}
+
+	sort.SliceStable(transactions, func(i, j int) bool { return transactions[i].Timestamp.Before(transactions[j].Timestamp) }) // Sort by time
+
+	return transactions, nil // Return transactions
+}
+
+/* END EXPORTED METHODS */
+
+/* BEGIN INTERNAL METHODS */
+
+// resolveOwnAddress resolves the name or hex address of one of an account's sub-addresses to its address.
+// An empty string resolves to the account's primary address.
+func (account *Account) resolveOwnAddress(from string) (summercashCommon.Address, error) {
+	if from == "" { // Check primary address
+		return account.Address, nil // Return primary address
+	}
+
+	if strings.Contains(from, "0x") { // Check is address
+		address, err := summercashCommon.StringToAddress(from) // Parse address
+
+		if err != nil { // Check for errors
+			return summercashCommon.Address{}, err // Return found error
+		}
+
+		for _, ownAddress := range account.Addresses() { // Iterate through addresses
+			if ownAddress == address { // Check owned
+				return address, nil // Return address
+			}
+		}
+
+		return summercashCommon.Address{}, ErrSubAddressDoesNotExist // Not owned
+	}
+
+	if i := account.findSubAddress(from); i != -1 { // Check is sub-address name
+		return account.SubAddresses[i].Address, nil // Return address
+	}
+
+	return summercashCommon.Address{}, ErrSubAddressDoesNotExist // No such sub-address
+}
+
+// findSubAddress gets the index of the sub-address with a given name (compared case-insensitively) in an account's sub-addresses, or -1 if there is none.
+func (account *Account) findSubAddress(name string) int {
+	name = strings.TrimSpace(name) // Trim name
+
+	for i, subAddress := range account.SubAddresses { // Iterate through sub-addresses
+		if strings.EqualFold(subAddress.Name, name) { // Check matches
+			return i // Return index
+		}
+	}
+
+	return -1 // No sub-address with name
+}
+
+/* END INTERNAL METHODS */
@@ -0,0 +1,81 @@
+// Package accounts defines account-related helper methods and types.
+// The accounts database, for example, is defined in this package.
+package accounts
+
+import (
+	"testing"
+
+	summercashCommon "github.com/SummerCash/go-summercash/common"
+	"github.com/SummerCash/go-summercash/config"
+)
+
+/* BEGIN EXPORTED METHODS TESTS */
+
+// TestCreateSubAddress tests the functionality of the CreateSubAddress() and UnlockAddressPrivateKey() helper methods.
+func TestCreateSubAddress(t *testing.T) {
+	setTestDataDir(t) // Use temp data dir
+
+	if err := (&config.ChainConfig{}).WriteToMemory(); err != nil { // Write chain config, which account chains are initialized from
+		t.Fatal(err) // Panic
+	}
+
+	db := newTestDB(t) // Open test db
+
+	defer db.CloseDB() // Close db
+
+	account, err := db.CreateNewAccount("test", "test") // Create account
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	for _, name := range []string{"", "0xsavings"} { // Iterate through invalid names
+		if _, err = db.CreateSubAddress("test", "test", name); err != ErrSubAddressNameInvalid { // Create sub-address with invalid name
+			t.Errorf("expected ErrSubAddressNameInvalid for name %q, got %v", name, err) // Log error
+		}
+	}
+
+	if _, err = db.CreateSubAddress("test", "wrong", "savings"); err == nil { // Create sub-address with wrong password
+		t.Fatal("expected sub-address creation with wrong password to fail") // Panic
+	}
+
+	subAddress, err := db.CreateSubAddress("test", "test", "Savings") // Create sub-address
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	if _, err = db.CreateSubAddress("test", "test", "savings"); err != ErrSubAddressAlreadyExists { // Create duplicate sub-address
+		t.Fatalf("expected ErrSubAddressAlreadyExists, got %v", err) // Panic
+	}
+
+	if owner, err := db.QueryAccountByAddress(subAddress.Address); err != nil || owner.Name != account.Name { // Check indexed
+		t.Fatalf("expected sub-address to be indexed to its account, got %v", err) // Panic
+	}
+
+	for _, from := range []string{"savings", subAddress.Address.String()} { // Iterate through sending address references
+		address, privateKey, err := db.UnlockAddressPrivateKey("test", from, "test", OperationSend, "") // Unlock sub-address private key
+
+		if err != nil { // Check for errors
+			t.Fatal(err) // Panic
+		}
+
+		if address != subAddress.Address || summercashCommon.PublicKeyToAddress(&privateKey.PublicKey) != subAddress.Address { // Check wrong key
+			t.Fatalf("expected key for %s, got key for %s", subAddress.Address.String(), address.String()) // Panic
+		}
+	}
+
+	if _, _, err = db.UnlockAddressPrivateKey("test", testAddress(0).String(), "test", OperationSend, ""); err != ErrSubAddressDoesNotExist { // Unlock key of foreign address
+		t.Fatalf("expected ErrSubAddressDoesNotExist, got %v", err) // Panic
+	}
+
+	if address, _, err := db.UnlockAddressPrivateKey("test", "", "test", OperationSend, ""); err != nil || address != account.Address { // Unlock primary private key
+		t.Fatalf("expected empty sending address to resolve to primary address, got %s (%v)", address.String(), err) // Panic
+	}
+
+	if balance, err := db.GetUserCombinedBalance("test"); err != nil || balance.Sign() != 0 { // Get combined balance
+		t.Fatalf("expected zero combined balance, got %v (%v)", balance, err) // Panic
+	}
+}
+
+/* END EXPORTED METHODS TESTS */
@@ -0,0 +1,455 @@
+// Package accounts defines account-related helper methods and types.
+// The accounts database, for example, is defined in this package.
+package accounts
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/SummerCash/summercash-wallet-server/storage"
+
+	"github.com/SummerCash/summercash-wallet-server/crypto"
+)
+
+const (
+	// ScopeRead is the token scope permitting read-only account access.
+	ScopeRead = "read"
+
+	// ScopeSend is the token scope permitting the creation of transactions.
+	ScopeSend = "send"
+
+	// ScopeAdmin is the token scope permitting destructive account operations (e.g. deletion, key export).
+	ScopeAdmin = "admin"
+
+	// TokenTypeAccess is the type of a short-lived access token.
+	TokenTypeAccess = "access"
+
+	// TokenTypeRefresh is the type of a long-lived token used solely to obtain new access tokens.
+	TokenTypeRefresh = "refresh"
+)
+
+var (
+	// AccessTokenLifetime is the duration for which a newly issued access token is valid.
+	AccessTokenLifetime = 1 * time.Hour
+
+	// RefreshTokenLifetime is the duration for which a newly issued refresh token is valid.
+	RefreshTokenLifetime = 30 * 24 * time.Hour
+
+	// DefaultTokenScopes are the scopes granted to a token when none are requested.
+	DefaultTokenScopes = []string{ScopeRead, ScopeSend}
+
+	// tokenLastUsedResolution is the minimum interval between persisted last-used time updates.
+	tokenLastUsedResolution = time.Minute
+)
+
+var (
+	// ErrTokenInvalid is an error definition describing an unknown or expired token.
+	ErrTokenInvalid = errors.New("invalid token")
+
+	// ErrScopeInvalid is an error definition describing a request for an unknown token scope.
+	ErrScopeInvalid = errors.New("invalid token scope")
+)
+
+// Token represents a hashed, expiring, scoped account token. The token secret itself is never stored.
+type Token struct {
+	ID string `json:"id"` // Public token identifier
+
+	SessionID      string    `json:"session_id"`      // ID of the session (login) the token belongs to
+	SessionStarted time.Time `json:"session_started"` // Time at which the session was started
+
+	Type string `json:"type"` // Token type (access or refresh)
+
+	Hash []byte `json:"hash"` // Hash of the token secret
+
+	Device string `json:"device"` // Device label
+
+	Scopes []string `json:"scopes"` // Granted scopes
+
+	IssuedAt  time.Time `json:"issued_at"`  // Issue time
+	ExpiresAt time.Time `json:"expires_at"` // Expiry time
+	LastUsed  time.Time `json:"last_used"`  // Last time the token was successfully validated
+
+	WrappedKey []byte `json:"wrapped_key,omitempty"` // Account data key, wrapped under a key derived from the token secret
+}
+
+// IssuedToken represents a newly issued access and refresh token pair.
+// The secrets contained in an IssuedToken are returned to the client once, and never persisted.
+type IssuedToken struct {
+	SessionID string `json:"session_id"` // Session ID
+
+	AccessToken      string    `json:"token"`              // Access token secret
+	AccessExpiresAt  time.Time `json:"expires_at"`         // Access token expiry
+	RefreshToken     string    `json:"refresh_token"`      // Refresh token secret
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"` // Refresh token expiry
+
+	Scopes []string `json:"scopes"` // Granted scopes
+}
+
+/* BEGIN EXPORTED METHODS */
+
+// IssueAccountToken authenticates a user by password, and issues a new access and refresh token pair for a given device.
+// If no scopes are requested, DefaultTokenScopes are granted. A security email is sent if the account has logged in from other devices, but not this one.
+// Devices are told apart by the source address and user agent of the working database handle (see FromClient); the given device label is only displayed.
+func (db *DB) IssueAccountToken(username, password, device string, scopes []string) (issued *IssuedToken, err error) {
+	defer func() { db.RecordAuditEvent(username, AuditActionIssueToken, device, err) }() // Record attempt
+
+	account, err := db.QueryAccountByUsername(username) // Query account
+
+	if err != nil { // Check for errors
+		return &IssuedToken{}, err // Return found error
+	}
+
+	if err = db.verifyPassword(account, password); err != nil { // Check should not issue token
+		return &IssuedToken{}, err // Invalid
+	}
+
+	if len(scopes) == 0 { // Check no scopes requested
+		scopes = DefaultTokenScopes // Set default scopes
+	}
+
+	for _, scope := range scopes { // Iterate through requested scopes
+		if scope != ScopeRead && scope != ScopeSend && scope != ScopeAdmin { // Check unknown scope
+			return &IssuedToken{}, ErrScopeInvalid // Return error
+		}
+	}
+
+	sessionID, err := newTokenID() // Generate session ID
+
+	if err != nil { // Check for errors
+		return &IssuedToken{}, err // Return found error
+	}
+
+	var dataKey []byte // Init data key buffer
+
+	if account.KeyEncryption != nil { // Check has encrypted private key
+		if dataKey, err = account.unwrapDataKey(password); err != nil { // Unwrap data key
+			return &IssuedToken{}, err // Return found error
+		}
+	}
+
+	newDevice := false // Init new device buffer
+
+	_, err = db.updateAccount(username, func(current *Account) error {
+		if !bytes.Equal(current.PasswordHash, account.PasswordHash) { // Check password changed since it was verified
+			return ErrPasswordInvalid // Return error
+		}
+
+		if err := current.checkTokensIssuable(); err != nil { // Check can't issue tokens (e.g. reset required since verification)
+			return err // Return found error
+		}
+
+		if issued, err = current.issueTokenPair(sessionID, time.Now(), device, scopes, dataKey); err != nil { // Issue tokens
+			return err // Return found error
+		}
+
+		newDevice = current.rememberDevice(db.deviceKey()) // Remember device (by source address and user agent, rather than by the given label)
+
+		return nil // No error occurred, return nil
+	}) // Persist tokens on the current account, so that concurrent changes aren't overwritten
+
+	if err != nil { // Check for errors
+		return &IssuedToken{}, err // Return found error
+	}
+
+	if newDevice { // Check logged in from a new device
+		db.sendSecurityEmail(username, "New login to your account", fmt.Sprintf("Your account was just logged in to from a new device (%q, from %s, using %q).", device, db.source, db.userAgent)) // Notify account
+	}
+
+	return issued, nil // Return tokens
+}
+
+// RefreshAccountToken exchanges a valid refresh token for a new access and refresh token pair in the same session.
+// The presented refresh token is revoked. Tokens are refused under the same conditions as password logins
+// (e.g. while the account is locked out, pending deletion, or requires a password reset).
+func (db *DB) RefreshAccountToken(username, refreshToken string) (*IssuedToken, error) {
+	if err := db.checkLockout(username); err != nil { // Check locked out
+		return &IssuedToken{}, err // Return found error
+	}
+
+	var issued *IssuedToken // Init issued buffer
+
+	_, err := db.updateAccount(username, func(account *Account) error {
+		token := account.findToken(refreshToken, TokenTypeRefresh) // Find refresh token
+
+		if token == nil { // Check invalid
+			return ErrTokenInvalid // Return error
+		}
+
+		if err := account.checkTokensIssuable(); err != nil { // Check can't issue tokens
+			return err // Return found error
+		}
+
+		var dataKey []byte // Init data key buffer
+
+		if account.KeyEncryption != nil { // Check has encrypted private key
+			var err error // Init error buffer
+
+			if dataKey, err = account.unwrapTokenDataKey(token, refreshToken); err != nil && err != ErrPrivateKeyLocked { // Unwrap data key
+				return err // Return found error
+			}
+		}
+
+		account.removeTokens(func(current *Token) bool { return current.ID == token.ID }) // Revoke used refresh token
+
+		var err error // Init error buffer
+
+		issued, err = account.issueTokenPair(token.SessionID, token.sessionStarted(), token.Device, token.Scopes, dataKey) // Issue tokens
+
+		return err // Return error
+	}) // Exchange tokens on the current account, so that concurrent changes aren't overwritten
+
+	if err != nil { // Check for errors
+		return &IssuedToken{}, err // Return found error
+	}
+
+	return issued, nil // Return tokens
+}
+
+// ValidateAccountToken checks whether or not a given access token is valid for an account.
+func (db *DB) ValidateAccountToken(account *Account, token string) bool {
+	return db.ValidateAccountTokenScope(account, token, "") // Validate without scope
+}
+
+// ValidateAccountTokenScope checks whether or not a given access token is valid for an account, and carries a given scope.
+// An empty scope matches any valid access token.
+func (db *DB) ValidateAccountTokenScope(account *Account, token string, scope string) bool {
+	matchingToken := account.findToken(token, TokenTypeAccess) // Find token
+
+	if matchingToken == nil || (scope != "" && !matchingToken.HasScope(scope)) { // Check invalid
+		return false // Invalid token
+	}
+
+	if time.Since(matchingToken.LastUsed) > tokenLastUsedResolution { // Check should persist last use
+		(*matchingToken).LastUsed = time.Now() // Set last use
+
+		_, err := db.updateAccount(account.Name, func(current *Account) error {
+			for _, token := range current.Tokens { // Iterate through current tokens
+				if token.ID == matchingToken.ID { // Check is matching token (unless revoked since)
+					(*token).LastUsed = matchingToken.LastUsed // Set last use
+				}
+			}
+
+			return nil // No error occurred, return nil
+		}) // Persist last use on the current account, so that concurrent changes aren't overwritten
+
+		if err != nil { // Check for errors
+			logger.Errorf("failed to persist token last use for account %s: %s", account.Name, err.Error()) // Log error
+		}
+	}
+
+	return true // Valid token
+}
+
+// PurgeExpiredTokens removes all expired tokens from every account in the working database.
+// Returns the number of tokens removed.
+func (db *DB) PurgeExpiredTokens() (int, error) {
+	err := db.CreateAccountsBucketIfNotExist() // Create accounts bucket
+
+	if err != nil { // Check for errors
+		return 0, err // Return found error
+	}
+
+	purged := 0 // Init purged counter
+
+	err = db.store.Update(func(tx storage.Tx) error {
+		purged = 0 // Reset counter
+
+		return forEachAccount(tx.Bucket(accountsBucket), func(_ []byte, account *Account) error {
+			removed := account.removeTokens(func(token *Token) bool { return token.Expired() }) // Remove expired tokens
+
+			if removed == 0 { // Check nothing to do
+				return nil // Skip
+			}
+
+			purged += removed // Increment counter
+
+			return putAccount(tx, account) // Update account
+		}) // Purge all accounts
+	}) // Purge tokens
+
+	return purged, err // Return purged count
+}
+
+// StartIntermittentTokenCleanup purges expired tokens, and stale failed login records, from the working database at a given interval.
+// This method blocks, and should be started in its own goroutine.
+func (db *DB) StartIntermittentTokenCleanup(interval time.Duration) {
+	ticker := time.NewTicker(interval) // Init ticker
+
+	defer ticker.Stop() // Stop ticker
+
+	for range ticker.C { // Wait for tick
+		if purged, err := db.PurgeStaleLoginAttempts(); err != nil { // Purge failed login records
+			logger.Errorf("errored while purging stale failed login records: %s", err.Error()) // Log error
+		} else if purged > 0 { // Check purged any
+			logger.Infof("purged %d stale failed login records", purged) // Log purge
+		}
+
+		purged, err := db.PurgeExpiredTokens() // Purge tokens
+
+		if err != nil { // Check for errors
+			logger.Errorf("errored while purging expired tokens: %s", err.Error()) // Log error
+
+			continue // Try again next tick
+		}
+
+		if purged > 0 { // Check purged any
+			logger.Infof("purged %d expired tokens", purged) // Log purge
+		}
+	}
+}
+
+// HasScope checks whether or not a token has been granted a given scope.
+func (token *Token) HasScope(scope string) bool {
+	for _, grantedScope := range token.Scopes { // Iterate through scopes
+		if grantedScope == scope { // Check matches
+			return true // Has scope
+		}
+	}
+
+	return false // Does not have scope
+}
+
+// Expired checks whether or not a token has expired.
+func (token *Token) Expired() bool {
+	return time.Now().After(token.ExpiresAt) // Check expired
+}
+
+/* END EXPORTED METHODS */
+
+/* BEGIN INTERNAL METHODS */
+
+// issueTokenPair generates a new access and refresh token pair in a given session, and adds their hashes to the account.
+// If a data key is given, it is wrapped under each token, so that the tokens are able to unlock the account's private key.
+func (account *Account) issueTokenPair(sessionID string, sessionStarted time.Time, device string, scopes []string, dataKey []byte) (*IssuedToken, error) {
+	account.removeTokens(func(token *Token) bool { return token.Expired() }) // Clean up expired tokens
+
+	accessSecret, accessToken, err := newToken(sessionID, TokenTypeAccess, device, scopes, AccessTokenLifetime) // Generate access token
+
+	if err != nil { // Check for errors
+		return &IssuedToken{}, err // Return found error
+	}
+
+	refreshSecret, refreshToken, err := newToken(sessionID, TokenTypeRefresh, device, scopes, RefreshTokenLifetime) // Generate refresh token
+
+	if err != nil { // Check for errors
+		return &IssuedToken{}, err // Return found error
+	}
+
+	(*accessToken).SessionStarted = sessionStarted  // Set access token session start
+	(*refreshToken).SessionStarted = sessionStarted // Set refresh token session start
+
+	if dataKey != nil { // Check should wrap data key
+		if (*accessToken).WrappedKey, err = wrapTokenDataKey(dataKey, accessSecret); err != nil { // Wrap data key under access token
+			return &IssuedToken{}, err // Return found error
+		}
+
+		if (*refreshToken).WrappedKey, err = wrapTokenDataKey(dataKey, refreshSecret); err != nil { // Wrap data key under refresh token
+			return &IssuedToken{}, err // Return found error
+		}
+	}
+
+	(*account).Tokens = append(account.Tokens, accessToken, refreshToken) // Add tokens to account
+
+	return &IssuedToken{
+		SessionID:        sessionID,              // Set session ID
+		AccessToken:      accessSecret,           // Set access token
+		AccessExpiresAt:  accessToken.ExpiresAt,  // Set access expiry
+		RefreshToken:     refreshSecret,          // Set refresh token
+		RefreshExpiresAt: refreshToken.ExpiresAt, // Set refresh expiry
+		Scopes:           scopes,                 // Set scopes
+	}, nil // Return issued tokens
+}
+
+// checkTokensIssuable checks that new tokens may be issued to the account, as password logins check once the password is verified.
+func (account *Account) checkTokensIssuable() error {
+	if account.PendingDeletion() { // Check pending deletion
+		return ErrAccountPendingDeletion // Return error
+	}
+
+	if account.PasswordResetRequired { // Check reset required
+		return ErrPasswordResetRequired // Return error
+	}
+
+	return nil // Tokens may be issued
+}
+
+// sessionStarted gets the time at which the token's session was started.
+// Tokens migrated from legacy plaintext tokens carry no session start, so their issue time is used instead.
+func (token *Token) sessionStarted() time.Time {
+	if token.SessionStarted.IsZero() { // Check no session start
+		return token.IssuedAt // Return issue time
+	}
+
+	return token.SessionStarted // Return session start
+}
+
+// findToken finds the unexpired token of a given type matching a given secret.
+func (account *Account) findToken(secret string, tokenType string) *Token {
+	hash := crypto.Sha3([]byte(secret)) // Hash secret
+
+	for _, token := range account.Tokens { // Iterate through account tokens
+		if token.Type == tokenType && subtle.ConstantTimeCompare(token.Hash, hash) == 1 && !token.Expired() { // Check token matches
+			return token // Return token
+		}
+	}
+
+	return nil // No matching token
+}
+
+// removeTokens removes all tokens matching a given predicate from the account.
+// Returns the number of tokens removed.
+func (account *Account) removeTokens(shouldRemove func(token *Token) bool) int {
+	remaining := []*Token{} // Init remaining tokens buffer
+
+	for _, token := range account.Tokens { // Iterate through account tokens
+		if !shouldRemove(token) { // Check should keep
+			remaining = append(remaining, token) // Keep token
+		}
+	}
+
+	removed := len(account.Tokens) - len(remaining) // Count removed
+
+	(*account).Tokens = remaining // Set remaining
+
+	return removed // Return removed count
+}
+
+// newToken generates a new token secret, along with its hashed, persistable counterpart.
+func newToken(sessionID, tokenType, device string, scopes []string, lifetime time.Duration) (string, *Token, error) {
+	secretBytes := make([]byte, 32) // Init secret buffer
+
+	if _, err := rand.Read(secretBytes); err != nil { // Read random
+		return "", &Token{}, err // Return found error
+	}
+
+	secret := hex.EncodeToString(secretBytes) // Encode secret
+
+	return secret, &Token{
+		ID:        hex.EncodeToString(crypto.Sha3([]byte(secret))[:8]), // Set ID
+		SessionID: sessionID,                                           // Set session ID
+		Type:      tokenType,                                           // Set type
+		Hash:      crypto.Sha3([]byte(secret)),                         // Set hash
+		Device:    device,                                              // Set device
+		Scopes:    scopes,                                              // Set scopes
+		IssuedAt:  time.Now(),                                          // Set issue time
+		ExpiresAt: time.Now().Add(lifetime),                            // Set expiry
+	}, nil // Return token
+}
+
+// newTokenID generates a new random token or session identifier.
+func newTokenID() (string, error) {
+	idBytes := make([]byte, 8) // Init ID buffer
+
+	if _, err := rand.Read(idBytes); err != nil { // Read random
+		return "", err // Return found error
+	}
+
+	return hex.EncodeToString(idBytes), nil // Return ID
+}
+
+/* END INTERNAL METHODS */
@@ -0,0 +1,179 @@
+// Package accounts defines account-related helper methods and types.
+// The accounts database, for example, is defined in this package.
+package accounts
+
+import (
+	"testing"
+	"time"
+
+	"github.com/SummerCash/summercash-wallet-server/storage"
+
+	"github.com/SummerCash/summercash-wallet-server/crypto"
+)
+
+/* BEGIN EXPORTED METHODS TESTS */
+
+// TestIssueAccountToken tests the functionality of the IssueAccountToken() helper method.
+func TestIssueAccountToken(t *testing.T) {
+	db := newTestDB(t) // Open test db
+
+	defer db.CloseDB() // Close db
+
+	if _, err := db.AddNewAccount("test", "test", testAddress(0).String()); err != nil { // Add account
+		t.Fatal(err) // Panic
+	}
+
+	if _, err := db.IssueAccountToken("test", "wrong", "phone", nil); err != ErrPasswordInvalid { // Check issued with wrong password
+		t.Fatal("should not issue token with invalid password") // Panic
+	}
+
+	issued, err := db.IssueAccountToken("test", "test", "phone", nil) // Issue token
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	if !db.AuthScoped("test", issued.AccessToken, ScopeSend) { // Check can't send
+		t.Fatal("default token should be able to send") // Panic
+	}
+
+	if db.AuthScoped("test", issued.AccessToken, ScopeAdmin) { // Check can administer
+		t.Fatal("default token should not carry the admin scope") // Panic
+	}
+
+	if db.Auth("test", issued.AccessToken) { // Check token accepted as password
+		t.Fatal("token should not be accepted as a password") // Panic
+	}
+
+	if db.AuthScoped("test", issued.RefreshToken, ScopeRead) { // Check refresh token accepted as access token
+		t.Fatal("refresh token should not be accepted as an access token") // Panic
+	}
+
+	account, err := db.QueryAccountByUsername("test") // Query account
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	for _, token := range account.Tokens { // Iterate through tokens
+		if string(token.Hash) == issued.AccessToken || string(token.Hash) == issued.RefreshToken { // Check stored in plaintext
+			t.Fatal("token secrets should not be persisted") // Panic
+		}
+	}
+}
+
+// TestRefreshAccountToken tests the functionality of the RefreshAccountToken() helper method.
+func TestRefreshAccountToken(t *testing.T) {
+	db := newTestDB(t) // Open test db
+
+	defer db.CloseDB() // Close db
+
+	if _, err := db.AddNewAccount("test", "test", testAddress(0).String()); err != nil { // Add account
+		t.Fatal(err) // Panic
+	}
+
+	issued, err := db.IssueAccountToken("test", "test", "phone", []string{ScopeRead}) // Issue token
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	refreshed, err := db.RefreshAccountToken("test", issued.RefreshToken) // Refresh token
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	if refreshed.SessionID != issued.SessionID || len(refreshed.Scopes) != 1 { // Check session not preserved
+		t.Fatal("refreshed token should keep its session and scopes") // Panic
+	}
+
+	if _, err = db.RefreshAccountToken("test", issued.RefreshToken); err != ErrTokenInvalid { // Check refresh token reusable
+		t.Fatal("refresh token should be revoked once used") // Panic
+	}
+
+	if _, err = db.updateAccount("test", func(account *Account) error {
+		(*account).PasswordResetRequired = true // Require reset, without revoking tokens
+
+		return nil // No error occurred, return nil
+	}); err != nil { // Update account
+		t.Fatal(err) // Panic
+	}
+
+	if _, err = db.RefreshAccountToken("test", refreshed.RefreshToken); err != ErrPasswordResetRequired { // Refresh while reset required
+		t.Fatalf("expected ErrPasswordResetRequired, got %v", err) // Panic
+	}
+}
+
+// TestValidateAccountTokenScopeStale tests that persisting a token's last use doesn't write back a stale copy of the account.
+func TestValidateAccountTokenScopeStale(t *testing.T) {
+	db := newTestDB(t) // Open test db
+
+	defer db.CloseDB() // Close db
+
+	if _, err := db.AddNewAccount("test", "test", testAddress(0).String()); err != nil { // Add account
+		t.Fatal(err) // Panic
+	}
+
+	issued, err := db.IssueAccountToken("test", "test", "phone", nil) // Issue token
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	stale, err := db.QueryAccountByUsername("test") // Query account before revocation
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	(*stale.findToken(issued.AccessToken, TokenTypeAccess)).LastUsed = time.Time{} // Force last use to be persisted
+
+	if _, err = db.RevokeAccountTokens("admin", "test"); err != nil { // Revoke tokens
+		t.Fatal(err) // Panic
+	}
+
+	if !db.ValidateAccountTokenScope(stale, issued.AccessToken, ScopeRead) { // Validate against stale copy
+		t.Fatal("expected token to be valid against the stale copy") // Panic
+	}
+
+	if db.AuthScoped("test", issued.AccessToken, ScopeRead) { // Check revoked token resurrected
+		t.Fatal("revoked token should stay revoked") // Panic
+	}
+}
+
+// TestPurgeExpiredTokens tests the functionality of the PurgeExpiredTokens() helper method.
+func TestPurgeExpiredTokens(t *testing.T) {
+	db := newTestDB(t) // Open test db
+
+	defer db.CloseDB() // Close db
+
+	account, err := db.AddNewAccount("test", "test", testAddress(0).String()) // Add account
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	_, expired, _ := newToken("session", TokenTypeAccess, "phone", DefaultTokenScopes, -time.Minute) // Generate expired token
+	secret, live, _ := newToken("session", TokenTypeAccess, "phone", DefaultTokenScopes, time.Hour)  // Generate live token
+
+	(*account).Tokens = []*Token{expired, live} // Set tokens
+
+	err = db.store.Update(func(tx storage.Tx) error {
+		return tx.Bucket(accountsBucket).Put(crypto.Sha3([]byte(account.Name)), account.Bytes()) // Put account without pruning
+	}) // Write tokens
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	if purged, err := db.PurgeExpiredTokens(); err != nil || purged != 1 { // Purge
+		t.Fatalf("expected 1 token to be purged, got %d (%v)", purged, err) // Panic
+	}
+
+	if !db.AuthScoped("test", secret, ScopeRead) { // Check live token purged
+		t.Fatal("unexpired token should not be purged") // Panic
+	}
+}
+
+/* END EXPORTED METHODS TESTS */
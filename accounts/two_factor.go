@@ -0,0 +1,376 @@
+// Package accounts defines account-related helper methods and types.
+// The accounts database, for example, is defined in this package.
+package accounts
+
+import (
+	"crypto/subtle"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/SummerCash/summercash-wallet-server/crypto"
+)
+
+var (
+	// ErrSecondFactorRequired is an error definition describing a sensitive operation attempted without a second factor.
+	ErrSecondFactorRequired = errors.New("a second factor is required for this operation")
+
+	// ErrSecondFactorInvalid is an error definition describing an invalid, expired, or already used second factor.
+	ErrSecondFactorInvalid = errors.New("invalid second factor")
+
+	// ErrTwoFactorAlreadyEnabled is an error definition describing an enrollment attempt on an account that has already enabled two-factor authentication.
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication is already enabled for the given account")
+
+	// ErrTwoFactorNotEnabled is an error definition describing an account that has not enabled two-factor authentication.
+	ErrTwoFactorNotEnabled = errors.New("two-factor authentication is not enabled for the given account")
+
+	// ErrNoPendingTwoFactorEnrollment is an error definition describing a confirmation attempt without a pending enrollment.
+	ErrNoPendingTwoFactorEnrollment = errors.New("no two-factor enrollment is pending for the given account")
+
+	// ErrUnknownOperation is an error definition describing an operation name that does not name a sensitive operation.
+	ErrUnknownOperation = errors.New("unknown operation")
+)
+
+const (
+	// OperationSend is the operation of signing a transaction with an account's private key.
+	OperationSend = "send"
+
+	// OperationExportKey is the operation of exporting an account's private key.
+	OperationExportKey = "export_key"
+
+	// OperationDeleteAccount is the operation of deleting an account.
+	OperationDeleteAccount = "delete_account"
+)
+
+// SecondFactorOperations is the list of operations that accounts with two-factor authentication enabled must present a second factor for.
+var SecondFactorOperations = []string{OperationSend, OperationExportKey, OperationDeleteAccount}
+
+// TwoFactorIssuer is the issuer name shown next to enrolled accounts in authenticator apps.
+var TwoFactorIssuer = "SummerCash"
+
+const (
+	// backupCodeCount is the number of one-time backup codes issued on enrollment.
+	backupCodeCount = 10
+
+	// backupCodeSize is the size, in bytes, of the randomness in a backup code (8 base32 characters).
+	backupCodeSize = 5
+
+	// totpSkew is the number of time steps of clock skew tolerated in either direction when validating a TOTP code.
+	totpSkew = 1
+)
+
+// operationScopes maps each sensitive operation to the scope an access token must carry to authorize it.
+// Operations not listed require the admin scope.
+var operationScopes = map[string]string{
+	OperationSend:          ScopeSend,  // Sending requires the send scope
+	OperationExportKey:     ScopeAdmin, // Exporting a key requires the admin scope
+	OperationDeleteAccount: ScopeAdmin, // Deleting an account requires the admin scope
+}
+
+// TwoFactor represents the TOTP two-factor authentication state of an account.
+type TwoFactor struct {
+	Secret []byte `json:"secret"` // TOTP secret
+
+	Enabled bool `json:"enabled"` // Whether the enrollment has been confirmed with a valid code
+
+	LastUsedStep uint64 `json:"last_used_step"` // Time step of the last accepted TOTP code; codes from this step or earlier are rejected as replays
+
+	BackupCodeHashes [][]byte `json:"backup_code_hashes,omitempty"` // Hashes of the unused one-time backup codes
+}
+
+// TwoFactorEnrollment represents a pending two-factor enrollment, as shown to the enrolling user.
+type TwoFactorEnrollment struct {
+	Secret string `json:"secret"` // Base32-encoded TOTP secret, for manual entry
+
+	ProvisioningURI string `json:"provisioning_uri"` // otpauth:// URI, to be shown as a QR code
+}
+
+/* BEGIN EXPORTED METHODS */
+
+// ParseOperations parses a given comma-separated list of operation names (e.g. "send,export_key").
+func ParseOperations(list string) ([]string, error) {
+	operations := []string{} // Init operations buffer
+
+	for _, operation := range strings.Split(list, ",") { // Iterate through operation names
+		operation = strings.TrimSpace(operation) // Trim operation name
+
+		if operation == "" { // Check empty
+			continue // Skip
+		}
+
+		if _, ok := operationScopes[operation]; !ok { // Check unknown operation
+			return nil, fmt.Errorf("%s: %s", ErrUnknownOperation.Error(), operation) // Return error
+		}
+
+		operations = append(operations, operation) // Append operation
+	}
+
+	return operations, nil // Return operations
+}
+
+// RequiresSecondFactor checks whether or not the operator requires a second factor for a given operation.
+func RequiresSecondFactor(operation string) bool {
+	for _, requiredOperation := range SecondFactorOperations { // Iterate through operations
+		if requiredOperation == operation { // Check matches
+			return true // Requires second factor
+		}
+	}
+
+	return false // Does not require second factor
+}
+
+// TwoFactorEnabled checks whether or not an account has confirmed a two-factor enrollment.
+func (account *Account) TwoFactorEnabled() bool {
+	return account.TwoFactor != nil && account.TwoFactor.Enabled // Check enabled
+}
+
+// BeginTwoFactorEnrollment generates a new TOTP secret for the account with a given username, replacing any pending enrollment.
+// Two-factor authentication is not enabled until the enrollment is confirmed with a code generated from the secret.
+// The enrollment must be authorized by the account's password, or by a token carrying the admin scope.
+func (db *DB) BeginTwoFactorEnrollment(name string, password string) (*TwoFactorEnrollment, error) {
+	if err := db.AuthenticateScoped(name, password, ScopeAdmin); err != nil { // Auth
+		return nil, err // Return found error
+	}
+
+	secret, err := crypto.NewTOTPSecret() // Generate secret
+
+	if err != nil { // Check for errors
+		return nil, err // Return found error
+	}
+
+	account, err := db.updateAccount(name, func(account *Account) error {
+		if account.TwoFactorEnabled() { // Check already enabled
+			return ErrTwoFactorAlreadyEnabled // Return error
+		}
+
+		(*account).TwoFactor = &TwoFactor{Secret: secret} // Set pending enrollment
+
+		return nil // No error occurred, return nil
+	}) // Store pending enrollment
+
+	if err != nil { // Check for errors
+		return nil, err // Return found error
+	}
+
+	return &TwoFactorEnrollment{
+		Secret:          crypto.EncodeTOTPSecret(secret),                                   // Set secret
+		ProvisioningURI: crypto.TOTPProvisioningURI(secret, TwoFactorIssuer, account.Name), // Set URI
+	}, nil // Return enrollment
+}
+
+// ConfirmTwoFactorEnrollment enables two-factor authentication for the account with a given username, given a TOTP code generated
+// from its pending enrollment's secret. Returns the account's one-time backup codes, which are only stored hashed, and must be shown to the user exactly once.
+func (db *DB) ConfirmTwoFactorEnrollment(name string, password string, code string) (backupCodes []string, err error) {
+	defer func() { db.RecordAuditEvent(name, AuditActionEnableTwoFactor, "", err) }() // Record attempt
+
+	if err = db.AuthenticateScoped(name, password, ScopeAdmin); err != nil { // Auth
+		return nil, err // Return found error
+	}
+
+	backupCodes, backupCodeHashes, err := newBackupCodes() // Generate backup codes
+
+	if err != nil { // Check for errors
+		return nil, err // Return found error
+	}
+
+	_, err = db.updateTwoFactor(name, func(account *Account) error {
+		if account.TwoFactor == nil || account.TwoFactor.Enabled { // Check no pending enrollment
+			return ErrNoPendingTwoFactorEnrollment // Return error
+		}
+
+		if !account.TwoFactor.verifyTOTP(code) { // Check invalid code
+			return ErrSecondFactorInvalid // Return error
+		}
+
+		(*account.TwoFactor).Enabled = true                      // Enable
+		(*account.TwoFactor).BackupCodeHashes = backupCodeHashes // Set backup codes
+
+		return nil // No error occurred, return nil
+	}) // Confirm enrollment
+
+	if err != nil { // Check for errors
+		return nil, err // Return found error
+	}
+
+	logger.Infof("enabled two-factor authentication for account %s", NormalizeUsername(name)) // Log enrollment
+
+	return backupCodes, nil // Return backup codes
+}
+
+// RegenerateBackupCodes replaces the backup codes of the account with a given username, given a valid second factor.
+// Returns the new backup codes.
+func (db *DB) RegenerateBackupCodes(name string, password string, code string) (backupCodes []string, err error) {
+	defer func() { db.RecordAuditEvent(name, AuditActionRegenerateBackupCodes, "", err) }() // Record attempt
+
+	if err = db.AuthenticateScoped(name, password, ScopeAdmin); err != nil { // Auth
+		return nil, err // Return found error
+	}
+
+	backupCodes, backupCodeHashes, err := newBackupCodes() // Generate backup codes
+
+	if err != nil { // Check for errors
+		return nil, err // Return found error
+	}
+
+	_, err = db.updateTwoFactor(name, func(account *Account) error {
+		if !account.TwoFactorEnabled() { // Check not enabled
+			return ErrTwoFactorNotEnabled // Return error
+		}
+
+		if !account.TwoFactor.verify(code) { // Check invalid second factor
+			return ErrSecondFactorInvalid // Return error
+		}
+
+		(*account.TwoFactor).BackupCodeHashes = backupCodeHashes // Replace backup codes
+
+		return nil // No error occurred, return nil
+	}) // Replace backup codes
+
+	if err != nil { // Check for errors
+		return nil, err // Return found error
+	}
+
+	return backupCodes, nil // Return backup codes
+}
+
+// DisableTwoFactor disables two-factor authentication for the account with a given username, given a valid second factor.
+func (db *DB) DisableTwoFactor(name string, password string, code string) (err error) {
+	defer func() { db.RecordAuditEvent(name, AuditActionDisableTwoFactor, "", err) }() // Record attempt
+
+	if err = db.AuthenticateScoped(name, password, ScopeAdmin); err != nil { // Auth
+		return err // Return found error
+	}
+
+	_, err = db.updateTwoFactor(name, func(account *Account) error {
+		if !account.TwoFactorEnabled() { // Check not enabled
+			return ErrTwoFactorNotEnabled // Return error
+		}
+
+		if !account.TwoFactor.verify(code) { // Check invalid second factor
+			return ErrSecondFactorInvalid // Return error
+		}
+
+		(*account).TwoFactor = nil // Disable
+
+		return nil // No error occurred, return nil
+	}) // Disable two-factor authentication
+
+	if err != nil { // Check for errors
+		return err // Return found error
+	}
+
+	logger.Infof("disabled two-factor authentication for account %s", NormalizeUsername(name)) // Log disable
+
+	return nil // No error occurred, return nil
+}
+
+/* END EXPORTED METHODS */
+
+/* BEGIN INTERNAL METHODS */
+
+// verifySecondFactor checks that a given second factor (a TOTP code or a backup code) authorizes a given operation on an account.
+// Accounts without two-factor authentication, and operations the operator does not require a second factor for, always pass.
+// Accepted TOTP codes and backup codes are persisted as used, so that neither can be replayed.
+func (db *DB) verifySecondFactor(account *Account, operation string, code string) error {
+	if !RequiresSecondFactor(operation) || !account.TwoFactorEnabled() { // Check no second factor required
+		return nil // Nothing to verify
+	}
+
+	if code == "" { // Check no second factor given
+		return ErrSecondFactorRequired // Return error
+	}
+
+	_, err := db.updateTwoFactor(account.Name, func(account *Account) error {
+		if account.TwoFactorEnabled() && !account.TwoFactor.verify(code) { // Check invalid second factor
+			return ErrSecondFactorInvalid // Return error
+		}
+
+		return nil // No error occurred, return nil
+	}) // Consume second factor
+
+	return err // Return error (if any)
+}
+
+// updateTwoFactor applies a given update to the account with a given username, like updateAccount.
+// If the update rejects a second factor, the rejection is recorded as a failed login.
+func (db *DB) updateTwoFactor(name string, update func(account *Account) error) (*Account, error) {
+	account, err := db.updateAccount(name, update) // Update account
+
+	if err == ErrSecondFactorInvalid { // Check second factor rejected
+		db.recordLoginFailure(name) // Record failure
+	}
+
+	return account, err // Return updated account
+}
+
+// operationScope gets the scope an access token must carry to authorize a given operation.
+func operationScope(operation string) string {
+	if scope, ok := operationScopes[operation]; ok { // Check has scope
+		return scope // Return scope
+	}
+
+	return ScopeAdmin // Default to the most privileged scope
+}
+
+// verify checks a given TOTP code or backup code. A matching backup code is removed, and a matching TOTP code's time step is recorded.
+func (twoFactor *TwoFactor) verify(code string) bool {
+	if twoFactor.verifyTOTP(code) { // Check valid TOTP code
+		return true // Valid
+	}
+
+	codeHash := crypto.Sha3([]byte(normalizeBackupCode(code))) // Hash backup code
+
+	for i, backupCodeHash := range twoFactor.BackupCodeHashes { // Iterate through backup codes
+		if subtle.ConstantTimeCompare(backupCodeHash, codeHash) == 1 { // Check matches
+			(*twoFactor).BackupCodeHashes = append(twoFactor.BackupCodeHashes[:i], twoFactor.BackupCodeHashes[i+1:]...) // Consume backup code
+
+			return true // Valid
+		}
+	}
+
+	return false // Invalid
+}
+
+// verifyTOTP checks a given TOTP code, rejecting codes from time steps at or before the last accepted code.
+func (twoFactor *TwoFactor) verifyTOTP(code string) bool {
+	step, valid := crypto.ValidateTOTP(twoFactor.Secret, strings.TrimSpace(code), time.Now(), totpSkew) // Validate code
+
+	if !valid || step <= twoFactor.LastUsedStep { // Check invalid or replayed
+		return false // Invalid
+	}
+
+	(*twoFactor).LastUsedStep = step // Record step
+
+	return true // Valid
+}
+
+// newBackupCodes generates a new set of one-time backup codes, formatted as xxxx-xxxx.
+// Returns the codes and their hashes.
+func newBackupCodes() ([]string, [][]byte, error) {
+	codes := make([]string, backupCodeCount)  // Init codes buffer
+	hashes := make([][]byte, backupCodeCount) // Init hashes buffer
+
+	for i := range codes { // Generate codes
+		randomness, err := crypto.RandomBytes(backupCodeSize) // Generate randomness
+
+		if err != nil { // Check for errors
+			return nil, nil, err // Return found error
+		}
+
+		code := strings.ToLower(base32.StdEncoding.EncodeToString(randomness)) // Encode code
+
+		codes[i] = code[:4] + "-" + code[4:]  // Format code
+		hashes[i] = crypto.Sha3([]byte(code)) // Hash code
+	}
+
+	return codes, hashes, nil // Return codes
+}
+
+// normalizeBackupCode lowercases a given backup code, and strips its separators.
+func normalizeBackupCode(code string) string {
+	return strings.NewReplacer("-", "", " ", "").Replace(strings.ToLower(code)) // Normalize
+}
+
+/* END INTERNAL METHODS */
@@ -0,0 +1,120 @@
+// Package accounts defines account-related helper methods and types.
+// The accounts database, for example, is defined in this package.
+package accounts
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SummerCash/summercash-wallet-server/storage"
+
+	"github.com/SummerCash/summercash-wallet-server/crypto"
+)
+
+/* BEGIN EXPORTED METHODS TESTS */
+
+// TestTwoFactor tests the functionality of the two-factor enrollment helper methods, and the second factor checks they enable.
+func TestTwoFactor(t *testing.T) {
+	setTestDataDir(t) // Use temp data dir
+
+	db := newTestDB(t) // Open test db
+
+	defer db.CloseDB() // Close db
+
+	privateKey, _ := ecdsa.GenerateKey(elliptic.P521(), rand.Reader) // Generate private key
+
+	account, err := db.AddNewAccount("test", "test", testAddress(0).String()) // Add account
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	if err = account.encryptPrivateKey(privateKey, "test"); err != nil { // Encrypt private key
+		t.Fatal(err) // Panic
+	}
+
+	if err = db.store.Update(func(tx storage.Tx) error { return putAccount(tx, account) }); err != nil { // Persist account
+		t.Fatal(err) // Panic
+	}
+
+	enrollment, err := db.BeginTwoFactorEnrollment("test", "test") // Begin enrollment
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	if !strings.HasPrefix(enrollment.ProvisioningURI, "otpauth://totp/SummerCash:test?") { // Check invalid URI
+		t.Fatalf("unexpected provisioning URI %s", enrollment.ProvisioningURI) // Panic
+	}
+
+	if _, err = db.UnlockPrivateKey("test", "test", OperationSend, ""); err != nil { // Unlock before enrollment is confirmed
+		t.Fatal(err) // Panic
+	}
+
+	account, _ = db.QueryAccountByUsername("test") // Query pending enrollment
+
+	step := crypto.TOTPStep(time.Now()) // Get current step
+
+	backupCodes, err := db.ConfirmTwoFactorEnrollment("test", "test", crypto.TOTPCode(account.TwoFactor.Secret, step)) // Confirm enrollment
+
+	if err != nil || len(backupCodes) != backupCodeCount { // Check for errors
+		t.Fatalf("should have confirmed enrollment: %v", err) // Panic
+	}
+
+	if _, err = db.UnlockPrivateKey("test", "test", OperationSend, ""); err != ErrSecondFactorRequired { // Unlock without second factor
+		t.Fatalf("expected ErrSecondFactorRequired, got %v", err) // Panic
+	}
+
+	if _, err = db.UnlockPrivateKey("test", "test", OperationSend, crypto.TOTPCode(account.TwoFactor.Secret, step)); err != ErrSecondFactorInvalid { // Unlock with replayed code
+		t.Fatalf("expected ErrSecondFactorInvalid for replayed code, got %v", err) // Panic
+	}
+
+	if _, err = db.UnlockPrivateKey("test", "test", OperationSend, crypto.TOTPCode(account.TwoFactor.Secret, step+1)); err != nil { // Unlock with next code
+		t.Fatal(err) // Panic
+	}
+
+	if _, err = db.UnlockPrivateKey("test", "test", OperationExportKey, strings.ToUpper(backupCodes[0])); err != nil { // Unlock with backup code
+		t.Fatal(err) // Panic
+	}
+
+	if err = db.DeleteAccount("test", "test", backupCodes[0]); err != ErrSecondFactorInvalid { // Delete with used backup code
+		t.Fatalf("expected ErrSecondFactorInvalid for used backup code, got %v", err) // Panic
+	}
+
+	SecondFactorOperations = []string{OperationSend} // Only require a second factor for sending
+
+	defer func() { SecondFactorOperations = []string{OperationSend, OperationExportKey, OperationDeleteAccount} }() // Reset operations
+
+	if _, err = db.UnlockPrivateKey("test", "test", OperationExportKey, ""); err != nil { // Unlock for operation not requiring a second factor
+		t.Fatal(err) // Panic
+	}
+
+	if err = db.DisableTwoFactor("test", "test", backupCodes[1]); err != nil { // Disable
+		t.Fatal(err) // Panic
+	}
+
+	if _, err = db.UnlockPrivateKey("test", "test", OperationSend, ""); err != nil { // Unlock after disabling
+		t.Fatal(err) // Panic
+	}
+}
+
+// TestParseOperations tests the functionality of the ParseOperations() helper method.
+func TestParseOperations(t *testing.T) {
+	if operations, err := ParseOperations("send, export_key"); err != nil || len(operations) != 2 { // Parse operations
+		t.Fatalf("should have parsed operations: %v", err) // Panic
+	}
+
+	if operations, err := ParseOperations(""); err != nil || len(operations) != 0 { // Parse empty list
+		t.Fatalf("should have parsed empty list: %v", err) // Panic
+	}
+
+	if _, err := ParseOperations("send,sned"); err == nil { // Parse unknown operation
+		t.Fatal("should not have parsed unknown operation") // Panic
+	}
+}
+
+/* END EXPORTED METHODS TESTS */
@@ -0,0 +1,253 @@
+// Package accounts defines account-related helper methods and types.
+// The accounts database, for example, is defined in this package.
+package accounts
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/SummerCash/summercash-wallet-server/storage"
+
+	summercashCommon "github.com/SummerCash/go-summercash/common"
+	"github.com/SummerCash/summercash-wallet-server/crypto"
+)
+
+var (
+	// ErrInvalidUsername is an error definition describing a username containing disallowed characters.
+	ErrInvalidUsername = errors.New("usernames may only contain lowercase letters, digits, '_', '-' and '.', and must start and end with a letter or digit")
+
+	// ErrUsernameLength is an error definition describing a username that is too short or too long.
+	ErrUsernameLength = errors.New("username is too short or too long")
+
+	// ErrUsernameReserved is an error definition describing a username that is, or is confusable with, a reserved username.
+	ErrUsernameReserved = errors.New("username is reserved")
+
+	// ErrUsernameConfusable is an error definition describing a username that is confusable with that of an existing account.
+	ErrUsernameConfusable = errors.New("username is too similar to that of an existing account")
+)
+
+var (
+	// MinUsernameLength is the minimum length of a username.
+	MinUsernameLength = 3
+
+	// MaxUsernameLength is the maximum length of a username.
+	MaxUsernameLength = 32
+
+	// ReservedUsernames is the list of usernames (and look-alikes thereof) that may not be registered by users.
+	ReservedUsernames = []string{"faucet", "admin", "administrator", "root", "system", "support", "everyone", "api", "null", "undefined"}
+
+	// usernameSkeletonsBucket is the username skeleton => username index bucket key definition.
+	usernameSkeletonsBucket = []byte("username_skeletons")
+
+	// confusableSequences maps character sequences to the sequences they are visually confusable with.
+	// Sequences are substituted in order, after separators have been removed.
+	confusableSequences = strings.NewReplacer("rn", "m", "vv", "w", "0", "o", "1", "l", "i", "l", "5", "s")
+)
+
+/* BEGIN EXPORTED METHODS */
+
+// NormalizeUsername gets the canonical form of a given username, which accounts are stored under.
+// Usernames are case-insensitive, and surrounding whitespace is ignored.
+func NormalizeUsername(name string) string {
+	return strings.ToLower(strings.TrimSpace(name)) // Case-fold
+}
+
+// ValidateUsername checks that a given username, once normalized, satisfies the length and character set policies.
+func ValidateUsername(name string) error {
+	name = NormalizeUsername(name) // Normalize
+
+	if len(name) < MinUsernameLength || len(name) > MaxUsernameLength { // Check length
+		return ErrUsernameLength // Return error
+	}
+
+	for i, char := range name { // Iterate through characters
+		switch {
+		case char >= 'a' && char <= 'z', char >= '0' && char <= '9': // Letter or digit
+		case (char == '_' || char == '-' || char == '.') && i != 0 && i != len(name)-1: // Inner separator
+		default:
+			return ErrInvalidUsername // Return error
+		}
+	}
+
+	return nil // No error occurred, return nil
+}
+
+// IsReservedUsername checks whether a given username is, or is confusable with, a reserved username.
+func IsReservedUsername(name string) bool {
+	skeleton := usernameSkeleton(NormalizeUsername(name)) // Get skeleton
+
+	for _, reserved := range ReservedUsernames { // Iterate through reserved usernames
+		if skeleton == usernameSkeleton(NormalizeUsername(reserved)) { // Check confusable
+			return true // Reserved
+		}
+	}
+
+	return false // Not reserved
+}
+
+/* END EXPORTED METHODS */
+
+/* BEGIN INTERNAL METHODS */
+
+// usernameSkeleton reduces a given normalized username to a skeleton shared by every username it is visually confusable with.
+func usernameSkeleton(name string) string {
+	name = strings.NewReplacer("_", "", "-", "", ".", "").Replace(name) // Remove separators
+
+	return confusableSequences.Replace(name) // Substitute confusable sequences
+}
+
+// checkUsernameAvailable checks that a given normalized username may be registered in a given transaction.
+// Reserved usernames (and configured admin usernames) are only available if allowReserved is set.
+func checkUsernameAvailable(tx storage.Tx, name string, allowReserved bool) error {
+	if err := ValidateUsername(name); err != nil { // Validate username
+		return err // Return found error
+	}
+
+	if !allowReserved && (IsReservedUsername(name) || isAdminUsername(name)) { // Check reserved (configured admin usernames are reserved too)
+		return ErrUsernameReserved // Return error
+	}
+
+	if accountKey(tx, name) != nil { // Check already exists
+		return ErrAccountAlreadyExists // Return error
+	}
+
+	if owner := tx.Bucket(usernameSkeletonsBucket).Get([]byte(usernameSkeleton(name))); owner != nil { // Check confusable with existing account
+		return ErrUsernameConfusable // Return error
+	}
+
+	return nil // No error occurred, return nil
+}
+
+// accountKey resolves the key of the account with a given username in a given transaction, or nil if no such account exists.
+// Exact matches take precedence, so that legacy accounts which could not be rekeyed under their normalized username remain reachable.
+func accountKey(tx storage.Tx, name string) []byte {
+	bucket := tx.Bucket(accountsBucket) // Get accounts bucket
+
+	for _, candidate := range []string{name, NormalizeUsername(name)} { // Iterate through candidate usernames
+		if key := crypto.Sha3([]byte(candidate)); bucket.Get(key) != nil { // Check exists
+			return key // Return key
+		}
+	}
+
+	return nil // Account does not exist
+}
+
+// claimUsernameSkeleton claims the skeleton of a given username, unless it is already claimed by another username.
+func claimUsernameSkeleton(tx storage.Tx, name string) error {
+	index := tx.Bucket(usernameSkeletonsBucket) // Get index bucket
+
+	skeleton := []byte(usernameSkeleton(NormalizeUsername(name))) // Get skeleton
+
+	if index.Get(skeleton) != nil { // Check already claimed
+		return nil // Nothing to index
+	}
+
+	return index.Put(skeleton, []byte(name)) // Index skeleton
+}
+
+// migrateNormalizeUsernames rekeys each account under its normalized username, and builds the username skeleton index.
+// Accounts whose normalized username is already taken are left under their legacy username.
+func migrateNormalizeUsernames(tx storage.Tx) error {
+	if _, err := tx.CreateBucketIfNotExists(usernameSkeletonsBucket); err != nil { // Create index bucket
+		return err // Return found error
+	}
+
+	bucket := tx.Bucket(accountsBucket) // Get accounts bucket
+
+	if bucket == nil { // Check no accounts yet
+		return nil // Nothing to migrate
+	}
+
+	var legacyNames []string // Init legacy usernames buffer
+
+	err := bucket.ForEach(func(_, accountBytes []byte) error {
+		var account struct {
+			Name string `json:"name"`
+		} // Only decode the username, so that records are migrated untouched
+
+		if err := json.Unmarshal(accountBytes, &account); err != nil { // Deserialize account bytes
+			logger.Errorf("skipping undecodable account during migration: %s", err.Error()) // Log error
+
+			return nil // Skip account
+		}
+
+		if account.Name != NormalizeUsername(account.Name) { // Check not normalized
+			legacyNames = append(legacyNames, account.Name) // Rekey after normalized accounts have claimed their names
+
+			return nil // Next account
+		}
+
+		return claimUsernameSkeleton(tx, account.Name) // Index account
+	}) // Index normalized accounts
+
+	if err != nil { // Check for errors
+		return err // Return found error
+	}
+
+	for _, name := range legacyNames { // Iterate through legacy usernames
+		if err = rekeyAccountRecord(tx, name, NormalizeUsername(name)); err != nil { // Rekey account
+			return err // Return found error
+		}
+	}
+
+	return nil // No error occurred, return nil
+}
+
+// rekeyAccountRecord moves the raw account record with a given legacy username under a given normalized username,
+// unless the normalized username is already taken.
+func rekeyAccountRecord(tx storage.Tx, name string, normalized string) error {
+	bucket := tx.Bucket(accountsBucket) // Get accounts bucket
+
+	if bucket.Get(crypto.Sha3([]byte(normalized))) != nil { // Check normalized username taken
+		logger.Warningf("account %s collides with an existing account once normalized; leaving it under its legacy username", name) // Log collision
+
+		return claimUsernameSkeleton(tx, name) // Index account under legacy username
+	}
+
+	record := make(map[string]json.RawMessage) // Init raw record buffer
+
+	if err := json.Unmarshal(bucket.Get(crypto.Sha3([]byte(name))), &record); err != nil { // Decode raw record
+		return err // Return found error
+	}
+
+	encodedName, err := json.Marshal(normalized) // Encode normalized username
+
+	if err != nil { // Check for errors
+		return err // Return found error
+	}
+
+	record["name"] = encodedName // Set normalized username
+
+	encoded, err := json.MarshalIndent(record, "", "  ") // Encode record
+
+	if err != nil { // Check for errors
+		return err // Return found error
+	}
+
+	if err = bucket.Delete(crypto.Sha3([]byte(name))); err != nil { // Remove legacy key
+		return err // Return found error
+	}
+
+	if err = bucket.Put(crypto.Sha3([]byte(normalized)), encoded); err != nil { // Put record under normalized key
+		return err // Return found error
+	}
+
+	var address struct {
+		Address summercashCommon.Address `json:"address"`
+	} // Init address buffer
+
+	if err = json.Unmarshal(encoded, &address); err != nil { // Decode address
+		return err // Return found error
+	}
+
+	if index := tx.Bucket(addressesBucket); index != nil && string(index.Get(address.Address.Bytes())) == name { // Check address indexed under legacy username
+		if err = index.Put(address.Address.Bytes(), []byte(normalized)); err != nil { // Reindex address
+			return err // Return found error
+		}
+	}
+
+	return claimUsernameSkeleton(tx, normalized) // Index account
+}
+
+/* END INTERNAL METHODS */
@@ -0,0 +1,140 @@
+// Package accounts defines account-related helper methods and types.
+// The accounts database, for example, is defined in this package.
+package accounts
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/SummerCash/summercash-wallet-server/storage"
+
+	summercashCommon "github.com/SummerCash/go-summercash/common"
+	"github.com/SummerCash/summercash-wallet-server/crypto"
+)
+
+/* BEGIN EXPORTED METHODS TESTS */
+
+// TestValidateUsername tests the functionality of the ValidateUsername() helper method.
+func TestValidateUsername(t *testing.T) {
+	for name, expected := range map[string]error{
+		"alice":                              nil,                // Plain
+		" Alice.B_1 ":                        nil,                // Case-folded and trimmed
+		"al":                                 ErrUsernameLength,  // Too short
+		"alice smith":                        ErrInvalidUsername, // Space
+		"_alice":                             ErrInvalidUsername, // Leading separator
+		"аlice":                              ErrInvalidUsername, // Cyrillic look-alike
+		"0123456789012345678901234567890123": ErrUsernameLength,  // Too long
+	} {
+		if err := ValidateUsername(name); err != expected { // Validate
+			t.Errorf("expected %v for username %q, got %v", expected, name, err) // Log error
+		}
+	}
+}
+
+// TestIsReservedUsername tests the functionality of the IsReservedUsername() helper method.
+func TestIsReservedUsername(t *testing.T) {
+	for _, name := range []string{"faucet", "Faucet", "fauc.et", "adm1n", "r00t"} { // Iterate through reserved look-alikes
+		if !IsReservedUsername(name) { // Check not reserved
+			t.Errorf("username %q should be reserved", name) // Log error
+		}
+	}
+
+	if IsReservedUsername("alice") { // Check reserved
+		t.Error("username alice should not be reserved") // Log error
+	}
+}
+
+// TestAddNewAccountUsernamePolicy tests that AddNewAccount() and QueryAccountByUsername() apply the username policy.
+func TestAddNewAccountUsernamePolicy(t *testing.T) {
+	purgeDeletedAccountsImmediately(t) // Skip deletion grace period
+
+	db := newTestDB(t) // Open test db
+
+	defer db.CloseDB() // Close db
+
+	account, err := db.AddNewAccount("Alice", "test", testAddress(0).String()) // Add account
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	if account.Name != "alice" { // Check not normalized
+		t.Fatalf("expected normalized username alice, got %s", account.Name) // Panic
+	}
+
+	if _, err = db.QueryAccountByUsername("ALICE"); err != nil { // Query with different case
+		t.Fatal(err) // Panic
+	}
+
+	for name, expected := range map[string]error{
+		"alice":  ErrAccountAlreadyExists, // Duplicate
+		"al1ce":  ErrUsernameConfusable,   // Look-alike of existing account
+		"faucet": ErrUsernameReserved,     // Reserved
+	} {
+		if _, err = db.AddNewAccount(name, "test", testAddress(1).String()); err != expected { // Add account
+			t.Errorf("expected %v for username %s, got %v", expected, name, err) // Log error
+		}
+	}
+
+	if _, err = db.AddReservedAccount("faucet", "test", testAddress(1).String()); err != nil { // Add reserved account
+		t.Fatal(err) // Panic
+	}
+
+	if err = db.DeleteAccount("alice", "test", ""); err != nil { // Delete account
+		t.Fatal(err) // Panic
+	}
+
+	if _, err = db.AddNewAccount("al1ce", "test", testAddress(2).String()); err != nil { // Add look-alike of deleted account
+		t.Fatal(err) // Panic
+	}
+}
+
+/* END EXPORTED METHODS TESTS */
+
+/* BEGIN INTERNAL METHODS TESTS */
+
+// TestMigrateNormalizeUsernames tests the functionality of the migrateNormalizeUsernames() helper method.
+func TestMigrateNormalizeUsernames(t *testing.T) {
+	db := newTestDB(t) // Open test db
+
+	defer db.CloseDB() // Close db
+
+	err := db.store.Update(func(tx storage.Tx) error {
+		for i, name := range []string{"Bob", "carol", "Carol"} { // Iterate through legacy usernames
+			record, _ := json.Marshal(struct {
+				Name    string                   `json:"name"`
+				Address summercashCommon.Address `json:"address"`
+			}{name, testAddress(i)}) // Marshal legacy account
+
+			if err := tx.Bucket(accountsBucket).Put(crypto.Sha3([]byte(name)), record); err != nil { // Put legacy account
+				return err // Return found error
+			}
+
+			if err := tx.Bucket(addressesBucket).Put(testAddress(i).Bytes(), []byte(name)); err != nil { // Index legacy account
+				return err // Return found error
+			}
+		}
+
+		return migrateNormalizeUsernames(tx) // Migrate
+	}) // Simulate legacy records
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	account, err := db.QueryAccountByAddress(testAddress(0)) // Query rekeyed account
+
+	if err != nil || account.Name != "bob" { // Check not rekeyed
+		t.Fatalf("expected Bob to be rekeyed under bob, got %v (%v)", account, err) // Panic
+	}
+
+	if account, err = db.QueryAccountByUsername("Carol"); err != nil || account.Address != testAddress(2) { // Query colliding account by legacy username
+		t.Fatal("colliding account should remain reachable under its legacy username") // Panic
+	}
+
+	if account, err = db.QueryAccountByUsername("CAROL"); err != nil || account.Address != testAddress(1) { // Query normalized account
+		t.Fatal("normalized account should keep its username") // Panic
+	}
+}
+
+/* END INTERNAL METHODS TESTS */
@@ -0,0 +1,63 @@
+// Package accounts defines account-related helper methods and types.
+// The accounts database, for example, is defined in this package.
+package accounts
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	summercashCommon "github.com/SummerCash/go-summercash/common"
+	"github.com/SummerCash/summercash-wallet-server/storage"
+)
+
+// ErrWatchOnlyAccount is an error definition describing an attempt to sign with a watch-only account.
+var ErrWatchOnlyAccount = errors.New("account is watch-only, and its private key is not held by the server; sign the transaction elsewhere and submit it instead")
+
+/* BEGIN EXPORTED METHODS */
+
+// ResolveSendingAddress resolves the name or hex address of one of a given account's addresses to its address,
+// without unlocking its private key. An empty string resolves to the account's primary address.
+func (db *DB) ResolveSendingAddress(username string, from string) (summercashCommon.Address, error) {
+	account, err := db.QueryAccountByUsername(username) // Query account
+
+	if err != nil { // Check for errors
+		return summercashCommon.Address{}, err // Return found error
+	}
+
+	return account.resolveOwnAddress(from) // Resolve address
+}
+
+/* END EXPORTED METHODS */
+
+/* BEGIN INTERNAL METHODS */
+
+// migrateFlagWatchOnlyAccounts flags account records that were added with an existing address, and whose private key
+// the server has never held (neither encrypted, nor as a plaintext keystore awaiting encryption), as watch-only.
+func migrateFlagWatchOnlyAccounts(tx storage.Tx) error {
+	return forEachAccountRecord(tx, func(record map[string]json.RawMessage) (bool, error) {
+		if keyEncryption, ok := record["key_encryption"]; ok && string(keyEncryption) != "null" { // Check has encrypted key
+			return false, nil // Skip account
+		}
+
+		var address summercashCommon.Address // Init address buffer
+
+		if err := json.Unmarshal(record["address"], &address); err != nil { // Decode address
+			return false, nil // Skip account
+		}
+
+		legacyKeystorePath := filepath.FromSlash(fmt.Sprintf("%s/keystore/account_%s.json", summercashCommon.DataDir, address.String())) // Get plaintext keystore path
+
+		if _, err := os.Stat(legacyKeystorePath); !os.IsNotExist(err) { // Check has plaintext keystore
+			return false, nil // Skip account
+		}
+
+		record["watch_only"] = json.RawMessage("true") // Set watch-only
+
+		return true, nil // Record changed
+	}) // Flag watch-only accounts
+}
+
+/* END INTERNAL METHODS */
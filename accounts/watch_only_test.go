@@ -0,0 +1,46 @@
+// Package accounts defines account-related helper methods and types.
+// The accounts database, for example, is defined in this package.
+package accounts
+
+import "testing"
+
+/* BEGIN EXPORTED METHODS TESTS */
+
+// TestWatchOnlyAccount tests that accounts added with an existing address are watch-only, and refuse to sign.
+func TestWatchOnlyAccount(t *testing.T) {
+	db := newTestDB(t) // Open test db
+
+	defer db.CloseDB() // Close db
+
+	account, err := db.AddNewAccount("test", "test", testAddress(0).String()) // Add watch-only account
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	if !account.WatchOnly { // Check not watch-only
+		t.Fatal("account added with an existing address should be watch-only") // Panic
+	}
+
+	if _, err = db.UnlockPrivateKey("test", "wrong", OperationSend, ""); err != ErrPasswordInvalid { // Unlock with wrong password
+		t.Fatalf("expected ErrPasswordInvalid, got %v", err) // Panic
+	}
+
+	if _, err = db.UnlockPrivateKey("test", "test", OperationSend, ""); err != ErrWatchOnlyAccount { // Unlock watch-only private key
+		t.Fatalf("expected ErrWatchOnlyAccount, got %v", err) // Panic
+	}
+
+	if _, err = db.CreateSubAddress("test", "test", "savings"); err != ErrWatchOnlyAccount { // Create sub-address without a data key
+		t.Fatalf("expected ErrWatchOnlyAccount, got %v", err) // Panic
+	}
+
+	if address, err := db.ResolveSendingAddress("test", ""); err != nil || address != testAddress(0) { // Resolve primary address
+		t.Fatalf("expected primary address, got %s (%v)", address.String(), err) // Panic
+	}
+
+	if _, err = db.ResolveSendingAddress("test", testAddress(1).String()); err != ErrSubAddressDoesNotExist { // Resolve foreign address
+		t.Fatalf("expected ErrSubAddressDoesNotExist, got %v", err) // Panic
+	}
+}
+
+/* END EXPORTED METHODS TESTS */
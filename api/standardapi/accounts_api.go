@@ -6,27 +6,24 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/base64"
-	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"math/big"
 	"net/http"
 	"os"
 	"strings"
 	"time"
 
-	"github.com/boltdb/bolt"
 	"github.com/valyala/fasthttp"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
 
-	summercashAccounts "github.com/SummerCash/go-summercash/accounts"
 	summercashCommon "github.com/SummerCash/go-summercash/common"
 	"github.com/SummerCash/go-summercash/types"
 	"github.com/SummerCash/summercash-wallet-server/accounts"
 	"github.com/SummerCash/summercash-wallet-server/common"
-	"github.com/SummerCash/summercash-wallet-server/crypto"
 )
 
 var (
@@ -49,7 +46,31 @@ type calcBalanceResponse struct {
 
 // getUserTransactionsResponse represents a response to a GetUserTransactions request.
 type getUserTransactionsResponse struct {
-	Transactions []*types.StringTransaction `json:"transactions"` // Account transactions
+	Transactions []*userTransaction `json:"transactions"` // Account transactions
+}
+
+// userTransaction represents a transaction in a GetUserTransactions response, along with the labels the user has given its counterparties.
+type userTransaction struct {
+	*types.StringTransaction // Transaction
+
+	SenderContact    string `json:"sender_contact,omitempty"`    // Sender contact label
+	RecipientContact string `json:"recipient_contact,omitempty"` // Recipient contact label
+}
+
+// issueAccountTokenResponse represents a response to an IssueAccountToken or RefreshAccountToken request.
+type issueAccountTokenResponse struct {
+	*accounts.IssuedToken // Issued tokens
+
+	Address string `json:"address"` // Account address
+}
+
+// newRecoverableAccountResponse represents a response to a NewAccount request for a recoverable account.
+type newRecoverableAccountResponse struct {
+	Name string `json:"name"` // Account username
+
+	Address string `json:"address"` // Account address
+
+	RecoveryPhrase string `json:"recovery_phrase"` // Recovery phrase; shown only once
 }
 
 // authenticateUserResponse represents a response to an AuthenticateUser request.
@@ -76,15 +97,20 @@ func (api *JSONHTTPAPI) SetupAccountRoutes() error {
 	api.Router.POST(fmt.Sprintf("%s/:username/authenticatetoken", accountsAPIRoot), api.AuthenticateUserToken) // Set AuthenticateUserToken post
 	api.Router.DELETE(fmt.Sprintf("%s/:username", accountsAPIRoot), api.DeleteUser)                            // Set DeleteUser delete
 	api.Router.POST(fmt.Sprintf("%s/:username/token", accountsAPIRoot), api.IssueAccountToken)                 // Set IssueAccountToken post
+	api.Router.POST(fmt.Sprintf("%s/:username/refreshtoken", accountsAPIRoot), api.RefreshAccountToken)        // Set RefreshAccountToken post
 	api.Router.POST(fmt.Sprintf("%s/:username/pushtoken", accountsAPIRoot), api.SetAccountPushToken)           // Set AccountPushToken
 	api.Router.POST(fmt.Sprintf("%s/oauth/login", oauthAPIRoot), api.OauthLogin)                               // Set Authorize post
 	api.Router.POST(fmt.Sprintf("%s/oauth/callback", oauthAPIRoot), api.OauthCallback)                         // Set Oauth post
 	api.Router.POST(fmt.Sprintf("%s/:username/getPrivatekey", accountsAPIRoot), api.GetAccountPrivateKey)      // Set get PK post
+	api.Router.POST(fmt.Sprintf("%s/:username/recover", accountsAPIRoot), api.RecoverAccount)                  // Set RecoverAccount post
+	api.Router.POST(fmt.Sprintf("%s/:username/restore", accountsAPIRoot), api.RestoreUser)                     // Set RestoreUser post
+	api.Router.POST(fmt.Sprintf("%s/:username/import", accountsAPIRoot), api.ImportAccount)                    // Set ImportAccount post
 
 	return nil // No error occurred, return nil
 }
 
 // GetAccountPrivateKey handles a GetAccountPrivateKey request.
+// The private key is never returned in the clear; it is exported as a keystore encrypted under the given export passphrase (see accounts.Keystore).
 func (api *JSONHTTPAPI) GetAccountPrivateKey(ctx *fasthttp.RequestCtx) {
 	ctx.Response.Header.Set("Access-Control-Allow-Origin", "*")             // Allow CORS
 	ctx.Response.Header.Set("Access-Control-Allow-Headers", "Content-Type") // Allow Content-Type header
@@ -97,34 +123,58 @@ func (api *JSONHTTPAPI) GetAccountPrivateKey(ctx *fasthttp.RequestCtx) {
 		panic(err) // Panic
 	}
 
-	if !api.AccountsDatabase.Auth(account.Name, string(common.GetCtxValue(ctx, "password"))) { // Check invalid credentials
-		logger.Errorf("errored while handling GetAccountPrivateKey request: %s", accounts.ErrPasswordInvalid) // Log error
+	keystore, err := api.accountsDB(ctx).ExportPrivateKey(account.Name, string(common.GetCtxValue(ctx, "from")), string(common.GetCtxValue(ctx, "password")), string(common.GetCtxValue(ctx, "second_factor")), string(common.GetCtxValue(ctx, "passphrase"))) // Export private key
+	if err != nil {                                                                                                                                                                                                                                            // Check for errors
+		logger.Errorf("errored while handling GetAccountPrivateKey request: %s", err.Error()) // Log error
 
-		panic(accounts.ErrPasswordInvalid) // Panic
+		panic(err) // Panic
 	}
 
-	summercashAccount, err := summercashAccounts.ReadAccountFromMemory(account.Address) // Read account from memory
-	if err != nil {                                                                     // Check for errors
-		logger.Errorf("errored while handling GetAccountPrivateKey request: %s", accounts.ErrPasswordInvalid) // Log error
+	ctx.Response.Header.Set("Cache-Control", "no-store")                                                                               // Keep the keystore out of caches
+	ctx.Response.Header.Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"account_%s.json\"", keystore.Address.String())) // Offer the keystore as a file
+
+	fmt.Fprintf(ctx, string(keystore.Bytes())) // Write keystore
+}
+
+// ImportAccount handles an ImportAccount request.
+// The private key is given as "private_key" (PEM-encoded, optionally hex-encoded), or as "keystore" along with its "passphrase".
+func (api *JSONHTTPAPI) ImportAccount(ctx *fasthttp.RequestCtx) {
+	ctx.Response.Header.Set("Access-Control-Allow-Origin", "*")             // Allow CORS
+	ctx.Response.Header.Set("Access-Control-Allow-Headers", "Content-Type") // Allow Content-Type header
+	ctx.Response.Header.Set("Content-Type", "application/json")             // Set content type
+
+	key := common.GetCtxValue(ctx, "private_key") // Get private key
 
-		panic(accounts.ErrPasswordInvalid) // Panic
+	if keystore := jsonValueFromCtx(ctx, "keystore"); len(keystore) != 0 { // Check has keystore
+		key = keystore // Set keystore
 	}
 
-	err = summercashAccount.MakeEncodingSafe() // Make encoding safe
-	if err != nil {                            // Check for errors
-		logger.Errorf("errored while handling GetAccountPrivateKey request: %s", err.Error()) // Log error
+	account, err := api.accountsDB(ctx).ImportAccount(ctx.UserValue("username").(string), string(common.GetCtxValue(ctx, "password")), key, string(common.GetCtxValue(ctx, "passphrase"))) // Import account
+
+	if err != nil { // Check for errors
+		logger.Errorf("errored while handling ImportAccount request with username %s: %s", ctx.UserValue("username"), err.Error()) // Log error
 
 		panic(err) // Panic
 	}
 
-	fmt.Fprintf(ctx, hex.EncodeToString(summercashAccount.SerializedPrivateKey)) // Write pk
+	fmt.Fprintf(ctx, account.String()) // Respond with account string
+}
 
-	err = summercashAccount.RecoverSafeEncoding() // Recover encoding
-	if err != nil {                               // Check for errors
-		logger.Errorf("errored while handling GetAccountPrivateKey request: %s", err.Error()) // Log error
+// RecoverAccount handles a RecoverAccount request.
+func (api *JSONHTTPAPI) RecoverAccount(ctx *fasthttp.RequestCtx) {
+	ctx.Response.Header.Set("Access-Control-Allow-Origin", "*")             // Allow CORS
+	ctx.Response.Header.Set("Access-Control-Allow-Headers", "Content-Type") // Allow Content-Type header
+	ctx.Response.Header.Set("Content-Type", "application/json")             // Set content type
+
+	account, err := api.accountsDB(ctx).RecoverAccount(ctx.UserValue("username").(string), string(common.GetCtxValue(ctx, "recovery_phrase")), string(common.GetCtxValue(ctx, "new_password"))) // Recover account
+
+	if err != nil { // Check for errors
+		logger.Errorf("errored while handling RecoverAccount request with username %s: %s", ctx.UserValue("username"), err.Error()) // Log error
 
 		panic(err) // Panic
 	}
+
+	fmt.Fprintf(ctx, account.String()) // Respond with account string
 }
 
 // OauthLogin handles an OauthLogin request.
@@ -167,12 +217,15 @@ func (api *JSONHTTPAPI) NewAccount(ctx *fasthttp.RequestCtx) {
 	ctx.Response.Header.Set("Content-Type", "application/json")             // Set content type
 
 	var account *accounts.Account // Initialize account buffer
+	var recoveryPhrase string     // Initialize recovery phrase buffer
 	var err error                 // Initialize error buffer
 
 	if address := common.GetCtxValue(ctx, "address"); address != nil { // Check address specified
-		account, err = api.AccountsDatabase.AddNewAccount(ctx.UserValue("username").(string), string(common.GetCtxValue(ctx, "password")), string(address)) // Add user
+		account, err = api.accountsDB(ctx).AddNewAccount(ctx.UserValue("username").(string), string(common.GetCtxValue(ctx, "password")), string(address)) // Add user
+	} else if string(common.GetCtxValue(ctx, "recoverable")) == "true" { // Check wants recovery phrase
+		account, recoveryPhrase, err = api.accountsDB(ctx).CreateNewRecoverableAccount(ctx.UserValue("username").(string), string(common.GetCtxValue(ctx, "password"))) // Create new recoverable account
 	} else {
-		account, err = api.AccountsDatabase.CreateNewAccount(ctx.UserValue("username").(string), string(common.GetCtxValue(ctx, "password"))) // Create new account
+		account, err = api.accountsDB(ctx).CreateNewAccount(ctx.UserValue("username").(string), string(common.GetCtxValue(ctx, "password"))) // Create new account
 	}
 
 	if err != nil { // Check for errors
@@ -181,6 +234,14 @@ func (api *JSONHTTPAPI) NewAccount(ctx *fasthttp.RequestCtx) {
 		panic(err) // Panic
 	}
 
+	if recoveryPhrase != "" { // Check has recovery phrase
+		ctx.Response.Header.Set("Cache-Control", "no-store") // The recovery phrase is only ever shown once
+
+		fmt.Fprintf(ctx, (&newRecoverableAccountResponse{Name: account.Name, Address: account.Address.String(), RecoveryPhrase: recoveryPhrase}).string()) // Respond with account and recovery phrase
+
+		return // Stop execution
+	}
+
 	fmt.Fprintf(ctx, account.String()) // Respond with account string
 }
 
@@ -190,37 +251,17 @@ func (api *JSONHTTPAPI) SetAccountPushToken(ctx *fasthttp.RequestCtx) {
 	ctx.Response.Header.Set("Access-Control-Allow-Headers", "Content-Type") // Allow Content-Type header
 	ctx.Response.Header.Set("Content-Type", "application/json")             // Set content type
 
-	account, err := api.AccountsDatabase.QueryAccountByUsername(string(common.GetCtxValue(ctx, "username"))) // Query account
-
-	if err != nil { // Check for errors
-		logger.Errorf("errored while handling SetAccountPushToken request with username %s: %s", ctx.UserValue("username"), err.Error()) // Log error
-
-		panic(err) // Panic
-	}
-
-	if !api.AccountsDatabase.Auth(string(common.GetCtxValue(ctx, "username")), string(common.GetCtxValue(ctx, "password"))) { // Check not valid auth
-		err = errors.New("invalid token") // Set error
+	if err := api.accountsDB(ctx).AuthenticateScoped(string(common.GetCtxValue(ctx, "username")), string(common.GetCtxValue(ctx, "password")), accounts.ScopeRead); err != nil { // Check not valid auth
+		if err != accounts.ErrAccountLockedOut { // Check not locked out
+			err = errors.New("invalid token") // Set error
+		}
 
 		logger.Errorf("errored while handling SetAccountPushToken request with username %s: %s", ctx.UserValue("username"), err.Error()) // Log error
 
 		panic(err) // Panic
 	}
 
-	for _, token := range (*account).FcmTokens { // Iterate through account tokens
-		if token == string(common.GetCtxValue(ctx, "fcm_token")) { // Check token already exists
-			err = errors.New("token already exists") // Set error
-
-			logger.Errorf("errored while handling SetAccountPushToken request with username %s: %s", ctx.UserValue("username"), err.Error()) // Log error
-
-			panic(err) // Panic
-		}
-	}
-
-	(*account).FcmTokens = append((*account).FcmTokens, string(common.GetCtxValue(ctx, "fcm_token"))) // Append fcm token
-
-	err = api.AccountsDatabase.DB.Update(func(tx *bolt.Tx) error {
-		return tx.Bucket([]byte("accounts")).Put(crypto.Sha3([]byte(common.GetCtxValue(ctx, "username"))), account.Bytes()) // Put new value
-	})
+	err := api.AccountsDatabase.AddAccountPushToken(string(common.GetCtxValue(ctx, "username")), string(common.GetCtxValue(ctx, "fcm_token"))) // Add fcm token
 
 	if err != nil { // Check for errors
 		logger.Errorf("errored while handling SetAccountPushToken request with username %s: %s", ctx.UserValue("username"), err.Error()) // Log error
@@ -271,21 +312,46 @@ func (api *JSONHTTPAPI) IssueAccountToken(ctx *fasthttp.RequestCtx) {
 		panic(err) // Panic
 	}
 
-	if !api.AccountsDatabase.Auth(string(common.GetCtxValue(ctx, "username")), string(common.GetCtxValue(ctx, "password"))) { // Check cannot auth
-		logger.Errorf("errored while handling IssueToken request with username %s", ctx.UserValue("username")) // Log error
+	var scopes []string // Init scopes buffer
 
-		panic(errors.New("invalid username or password")) // panic
+	if requestedScopes := string(common.GetCtxValue(ctx, "scopes")); requestedScopes != "" { // Check scopes requested
+		scopes = strings.Split(requestedScopes, ",") // Split scopes
 	}
 
-	token, err := api.AccountsDatabase.IssueAccountToken(string(common.GetCtxValue(ctx, "username")), string(common.GetCtxValue(ctx, "password"))) // Issue token
+	token, err := api.accountsDB(ctx).IssueAccountToken(string(common.GetCtxValue(ctx, "username")), string(common.GetCtxValue(ctx, "password")), string(common.GetCtxValue(ctx, "device")), scopes) // Issue token
 
 	if err != nil { // Check for errors
-		logger.Errorf("errored while handling IssueToken request with username %s", ctx.UserValue("username")) // Log error
+		logger.Errorf("errored while handling IssueToken request with username %s: %s", ctx.UserValue("username"), err.Error()) // Log error
+
+		panic(err) // Panic
+	}
+
+	fmt.Fprintf(ctx, (&issueAccountTokenResponse{IssuedToken: token, Address: user.Address.String()}).string()) // Respond with tokens and user address
+}
+
+// RefreshAccountToken handles a RefreshAccountToken request.
+func (api *JSONHTTPAPI) RefreshAccountToken(ctx *fasthttp.RequestCtx) {
+	ctx.Response.Header.Set("Access-Control-Allow-Origin", "*")             // Allow CORS
+	ctx.Response.Header.Set("Access-Control-Allow-Headers", "Content-Type") // Allow Content-Type header
+	ctx.Response.Header.Set("Content-Type", "application/json")             // Set content type
+
+	user, err := api.AccountsDatabase.QueryAccountByUsername(string(common.GetCtxValue(ctx, "username"))) // Get user
+
+	if err != nil { // Check for errors
+		logger.Errorf("errored while handling RefreshToken request with username %s: %s", ctx.UserValue("username"), err.Error()) // Log error
 
 		panic(err) // Panic
 	}
 
-	fmt.Fprintf(ctx, fmt.Sprintf(`{"token": "%s", "address": "%s"}`, token, user.Address.String())) // Respond with token and user address
+	token, err := api.accountsDB(ctx).RefreshAccountToken(user.Name, string(common.GetCtxValue(ctx, "refresh_token"))) // Refresh token
+
+	if err != nil { // Check for errors
+		logger.Errorf("errored while handling RefreshToken request with username %s: %s", ctx.UserValue("username"), err.Error()) // Log error
+
+		panic(err) // Panic
+	}
+
+	fmt.Fprintf(ctx, (&issueAccountTokenResponse{IssuedToken: token, Address: user.Address.String()}).string()) // Respond with tokens and user address
 }
 
 // GetLastUserTxHash handles a GetLastUserTxHash request.
@@ -350,7 +416,7 @@ func (api *JSONHTTPAPI) RestAccountPassword(ctx *fasthttp.RequestCtx) {
 	ctx.Response.Header.Set("Access-Control-Allow-Headers", "Content-Type") // Allow Content-Type header
 	ctx.Response.Header.Set("Content-Type", "application/json")             // Set content type
 
-	err := api.AccountsDatabase.ResetAccountPassword(ctx.UserValue("username").(string), string(common.GetCtxValue(ctx, "old_password")), string(common.GetCtxValue(ctx, "new_password"))) // Reset password
+	err := api.accountsDB(ctx).ResetAccountPassword(ctx.UserValue("username").(string), string(common.GetCtxValue(ctx, "old_password")), string(common.GetCtxValue(ctx, "new_password")), string(common.GetCtxValue(ctx, "revoke_tokens")) == "true") // Reset password
 
 	if err != nil { // Check for errors
 		logger.Errorf("errored while handling RestAccountPassword request with username %s: %s", ctx.UserValue("username"), err.Error()) // Log error
@@ -370,6 +436,7 @@ func (api *JSONHTTPAPI) RestAccountPassword(ctx *fasthttp.RequestCtx) {
 }
 
 // QueryAccount handles a QueryAccount request.
+// Querying "everyone" lists every discoverable account (see accounts.Account.Discoverable).
 func (api *JSONHTTPAPI) QueryAccount(ctx *fasthttp.RequestCtx) {
 	ctx.Response.Header.Set("Access-Control-Allow-Origin", "*")             // Allow CORS
 	ctx.Response.Header.Set("Access-Control-Allow-Headers", "Content-Type") // Allow Content-Type header
@@ -378,23 +445,15 @@ func (api *JSONHTTPAPI) QueryAccount(ctx *fasthttp.RequestCtx) {
 	if ctx.UserValue("username").(string) == "everyone" { // Check is @everyone
 		var users []string // Initialize users buffer
 
-		api.AccountsDatabase.DB.View(func(tx *bolt.Tx) error {
-			b := tx.Bucket([]byte("accounts")) // Get accounts bucket
-
-			c := b.Cursor() // Initialize cursor
+		allAccounts, _ := api.AccountsDatabase.QueryAllAccounts() // Query all accounts
 
-			for k, v := c.First(); k != nil; k, v = c.Next() { // Iterate through keys
-				account, err := accounts.AccountFromBytes(v) // Resolve user
-
-				if err != nil { // Check for errors
-					continue // Continue
-				}
-
-				users = append(users, account.String()) // Append user
+		for _, account := range allAccounts { // Iterate through accounts
+			if !account.Discoverable() { // Check hidden from the directory (or pending deletion)
+				continue // Skip
 			}
 
-			return nil
-		}) // View bucket
+			users = append(users, account.String()) // Append user
+		}
 
 		fmt.Fprintf(ctx, fmt.Sprintf(`{"accounts": [%s]}`, strings.Join(users, ", "))) // Write users
 
@@ -413,12 +472,32 @@ func (api *JSONHTTPAPI) QueryAccount(ctx *fasthttp.RequestCtx) {
 }
 
 // CalculateAccountBalance handles a CalculateAccountBalance request.
+// If the request is authenticated (with the account password, or a token carrying the read scope), the balances of the account's sub-addresses are included.
 func (api *JSONHTTPAPI) CalculateAccountBalance(ctx *fasthttp.RequestCtx) {
 	ctx.Response.Header.Set("Access-Control-Allow-Origin", "*")             // Allow CORS
 	ctx.Response.Header.Set("Access-Control-Allow-Headers", "Content-Type") // Allow Content-Type header
 	ctx.Response.Header.Set("Content-Type", "application/json")             // Set content type
 
-	balance, err := api.AccountsDatabase.GetUserBalance(ctx.UserValue("username").(string)) // Get balance
+	authenticated := false // Init authenticated buffer
+
+	if password := string(common.GetCtxValue(ctx, "password")); password != "" { // Check authenticated
+		if err := api.accountsDB(ctx).AuthenticateScoped(ctx.UserValue("username").(string), password, accounts.ScopeRead); err != nil { // Check cannot authenticate
+			logger.Errorf("errored while handling GetUserBalance request with username %s: %s", ctx.UserValue("username"), err.Error()) // Log error
+
+			panic(err) // Panic
+		}
+
+		authenticated = true // Set authenticated
+	}
+
+	var balance *big.Float // Init balance buffer
+	var err error          // Init error buffer
+
+	if authenticated { // Check authenticated
+		balance, err = api.AccountsDatabase.GetUserCombinedBalance(ctx.UserValue("username").(string)) // Get combined balance
+	} else {
+		balance, err = api.AccountsDatabase.GetUserBalance(ctx.UserValue("username").(string)) // Get balance
+	}
 
 	if err != nil { // Check for errors
 		logger.Errorf("errored while handling GetUserBalance request with username %s: %s", ctx.UserValue("username"), err.Error()) // Log error
@@ -436,12 +515,44 @@ func (api *JSONHTTPAPI) CalculateAccountBalance(ctx *fasthttp.RequestCtx) {
 }
 
 // GetUserTransactions handles a GetUserTransactions request.
+// If the request is authenticated (with the account password, or a token carrying the read scope), counterparties in the account's address book are labeled,
+// and the transactions of the account's sub-addresses are included.
 func (api *JSONHTTPAPI) GetUserTransactions(ctx *fasthttp.RequestCtx) {
 	ctx.Response.Header.Set("Access-Control-Allow-Origin", "*")             // Allow CORS
 	ctx.Response.Header.Set("Access-Control-Allow-Headers", "Content-Type") // Allow Content-Type header
 	ctx.Response.Header.Set("Content-Type", "application/json")             // Set content type
 
-	userTransactions, err := api.AccountsDatabase.GetUserTransactions(ctx.UserValue("username").(string)) // Get user transactions
+	var contactLabels map[summercashCommon.Address]string // Init contact labels buffer
+
+	authenticated := false // Init authenticated buffer
+
+	if password := string(common.GetCtxValue(ctx, "password")); password != "" { // Check authenticated
+		if err := api.accountsDB(ctx).AuthenticateScoped(ctx.UserValue("username").(string), password, accounts.ScopeRead); err != nil { // Check cannot authenticate
+			logger.Errorf("errored while handling GetUserTransactions request with username %s: %s", ctx.UserValue("username"), err.Error()) // Log error
+
+			panic(err) // Panic
+		}
+
+		labels, err := api.AccountsDatabase.ContactLabels(ctx.UserValue("username").(string)) // Get contact labels
+
+		if err != nil { // Check for errors
+			logger.Errorf("errored while handling GetUserTransactions request with username %s: %s", ctx.UserValue("username"), err.Error()) // Log error
+
+			panic(err) // Panic
+		}
+
+		contactLabels = labels // Set contact labels
+		authenticated = true   // Set authenticated
+	}
+
+	var userTransactions []*types.Transaction // Init transactions buffer
+	var err error                             // Init error buffer
+
+	if authenticated { // Check authenticated
+		userTransactions, err = api.AccountsDatabase.GetUserCombinedTransactions(ctx.UserValue("username").(string)) // Get combined transactions
+	} else {
+		userTransactions, err = api.AccountsDatabase.GetUserTransactions(ctx.UserValue("username").(string)) // Get user transactions
+	}
 
 	if err != nil { // Check for errors
 		logger.Errorf("errored while handling GetUserTransactions request with username %s: %s", ctx.UserValue("username"), err.Error()) // Log error
@@ -449,7 +560,7 @@ func (api *JSONHTTPAPI) GetUserTransactions(ctx *fasthttp.RequestCtx) {
 		panic(err) // panic
 	}
 
-	var stringTransactions []*types.StringTransaction // Init string tx buffer
+	var stringTransactions []*userTransaction // Init string tx buffer
 
 	for _, transaction := range userTransactions { // Iterate through user txs
 		var sender string = transaction.Sender.String() // Get sender string value
@@ -488,7 +599,11 @@ func (api *JSONHTTPAPI) GetUserTransactions(ctx *fasthttp.RequestCtx) {
 			HashHex:                 transaction.Hash.String(),                                                  // Set hash hex
 		} // Init string transaction
 
-		stringTransactions = append(stringTransactions, stringTransaction) // Append string tx
+		stringTransactions = append(stringTransactions, &userTransaction{
+			StringTransaction: stringTransaction,                     // Set transaction
+			SenderContact:     contactLabels[*transaction.Sender],    // Set sender contact label
+			RecipientContact:  contactLabels[*transaction.Recipient], // Set recipient contact label
+		}) // Append string tx
 	}
 
 	getUserTransactionsResponse := &getUserTransactionsResponse{
@@ -512,10 +627,14 @@ func (api *JSONHTTPAPI) AuthenticateUser(ctx *fasthttp.RequestCtx) {
 		panic(err) // panic
 	}
 
-	if !api.AccountsDatabase.Auth(ctx.UserValue("username").(string), string(common.GetCtxValue(ctx, "password"))) { // Check cannot authenticate
-		logger.Errorf("errored while handling AuthenticateUser request with username %s", ctx.UserValue("username")) // Log error
+	if err = api.accountsDB(ctx).Authenticate(ctx.UserValue("username").(string), string(common.GetCtxValue(ctx, "password"))); err != nil { // Check cannot authenticate
+		logger.Errorf("errored while handling AuthenticateUser request with username %s: %s", ctx.UserValue("username"), err.Error()) // Log error
 
-		panic(errors.New("invalid username or password")) // panic
+		if err != accounts.ErrAccountLockedOut { // Check not locked out
+			err = errors.New("invalid username or password") // Don't reveal why authentication failed
+		}
+
+		panic(err) // panic
 	}
 
 	fmt.Fprintf(ctx, account.String()) // Respond with user details
@@ -527,7 +646,7 @@ func (api *JSONHTTPAPI) DeleteUser(ctx *fasthttp.RequestCtx) {
 	ctx.Response.Header.Set("Access-Control-Allow-Headers", "Content-Type") // Allow Content-Type header
 	ctx.Response.Header.Set("Content-Type", "application/json")             // Set content type
 
-	err := api.AccountsDatabase.DeleteAccount(ctx.UserValue("username").(string), string(common.GetCtxValue(ctx, "password"))) // Delete account
+	err := api.accountsDB(ctx).DeleteAccount(ctx.UserValue("username").(string), string(common.GetCtxValue(ctx, "password")), string(common.GetCtxValue(ctx, "second_factor"))) // Delete account
 
 	if err != nil { // Check for errors
 		logger.Errorf("errored while handling DeleteUser request with username %s: %s", ctx.UserValue("username"), err.Error()) // Log error
@@ -535,9 +654,32 @@ func (api *JSONHTTPAPI) DeleteUser(ctx *fasthttp.RequestCtx) {
 		panic(err) // Panic
 	}
 
+	if accounts.DeletionGracePeriod > 0 { // Check deletion scheduled
+		fmt.Fprintf(ctx, `{"message": "account scheduled for deletion; it can be restored within %s"}`, accounts.DeletionGracePeriod) // Respond with success
+
+		return // Done
+	}
+
 	fmt.Fprintf(ctx, fmt.Sprintf("{%smessage%s: %sAccount deleted successfully%s}", `"`, `"`, `"`, `"`)) // Respond with success
 }
 
+// RestoreUser handles a RestoreUser request.
+func (api *JSONHTTPAPI) RestoreUser(ctx *fasthttp.RequestCtx) {
+	ctx.Response.Header.Set("Access-Control-Allow-Origin", "*")             // Allow CORS
+	ctx.Response.Header.Set("Access-Control-Allow-Headers", "Content-Type") // Allow Content-Type header
+	ctx.Response.Header.Set("Content-Type", "application/json")             // Set content type
+
+	err := api.accountsDB(ctx).RestoreAccount(ctx.UserValue("username").(string), string(common.GetCtxValue(ctx, "password"))) // Restore account
+
+	if err != nil { // Check for errors
+		logger.Errorf("errored while handling RestoreUser request with username %s: %s", ctx.UserValue("username"), err.Error()) // Log error
+
+		panic(err) // Panic
+	}
+
+	fmt.Fprintf(ctx, `{"message": "account restored successfully"}`) // Respond with success
+}
+
 /* END EXPORTED METHODS */
 
 /* BEGIN INTERNAL METHODS */
@@ -556,6 +698,20 @@ func (response *getUserTransactionsResponse) string() string {
 	return string(marshaledval) // Return value
 }
 
+// string marshals an issueAccountTokenResponse into a JSON-formatted string.
+func (response *issueAccountTokenResponse) string() string {
+	marshaledVal, _ := json.MarshalIndent(*response, "", "  ") // Marshal value
+
+	return string(marshaledVal) // Return value
+}
+
+// string marshals a newRecoverableAccountResponse into a JSON-formatted string.
+func (response *newRecoverableAccountResponse) string() string {
+	marshaledVal, _ := json.MarshalIndent(*response, "", "  ") // Marshal value
+
+	return string(marshaledVal) // Return value
+}
+
 // string marshals an authenticateUserResponse into a JSON-formatted string.
 func (response *authenticateUserResponse) string() string {
 	marshaledval, _ := json.MarshalIndent(*response, "", "  ") // Marshal value
@@ -0,0 +1,280 @@
+// Package standardapi defines the summercash-wallet-server API.
+package standardapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/SummerCash/summercash-wallet-server/accounts"
+	"github.com/SummerCash/summercash-wallet-server/common"
+)
+
+// adminAccountsResponse represents a response to a SearchAccounts request.
+type adminAccountsResponse struct {
+	Accounts []*adminAccountResponse `json:"accounts"` // Matching accounts, sorted by username
+}
+
+// adminAccountResponse represents a single account in a SearchAccounts response.
+type adminAccountResponse struct {
+	Name string `json:"name"` // Username
+
+	Address string `json:"address"` // Hex primary address
+
+	Role string `json:"role"` // Role
+
+	Email string `json:"email,omitempty"` // Verified email address
+
+	Frozen bool `json:"frozen"` // Whether sends are frozen
+
+	FreezeReason string `json:"freeze_reason,omitempty"` // Reason the account was frozen
+
+	FrozenBy string `json:"frozen_by,omitempty"` // Username of the admin that froze the account
+
+	FrozenAt time.Time `json:"frozen_at,omitempty"` // Freeze time (zero if not frozen)
+
+	WatchOnly bool `json:"watch_only"` // Whether the account is watch-only
+
+	TwoFactor bool `json:"two_factor"` // Whether two-factor authentication is enabled
+
+	PasswordResetRequired bool `json:"password_reset_required"` // Whether a password reset is required
+
+	DeleteAfter time.Time `json:"delete_after,omitempty"` // Purge time (zero if not pending deletion)
+}
+
+/* BEGIN EXPORTED METHODS */
+
+// SetupAdminRoutes sets up all the admin api-related routes.
+func (api *JSONHTTPAPI) SetupAdminRoutes() error {
+	adminAPIRoot := "/api/admin" // Get admin API root path
+
+	api.Router.GET(fmt.Sprintf("%s/accounts", adminAPIRoot), api.SearchAccounts)                               // Set SearchAccounts get
+	api.Router.POST(fmt.Sprintf("%s/accounts/:username/freeze", adminAPIRoot), api.FreezeAccount)              // Set FreezeAccount post
+	api.Router.POST(fmt.Sprintf("%s/accounts/:username/unfreeze", adminAPIRoot), api.UnfreezeAccount)          // Set UnfreezeAccount post
+	api.Router.POST(fmt.Sprintf("%s/accounts/:username/reset_password", adminAPIRoot), api.ForcePasswordReset) // Set ForcePasswordReset post
+	api.Router.POST(fmt.Sprintf("%s/accounts/:username/revoke_tokens", adminAPIRoot), api.RevokeAccountTokens) // Set RevokeAccountTokens post
+	api.Router.GET(fmt.Sprintf("%s/stats", adminAPIRoot), api.GetAccountStats)                                 // Set GetAccountStats get
+
+	return nil // No error occurred, return nil
+}
+
+// SearchAccounts handles a SearchAccounts request.
+// Accounts may be filtered by "query" (a username substring, or an address prefix), "role" and "frozen", and limited by "limit".
+func (api *JSONHTTPAPI) SearchAccounts(ctx *fasthttp.RequestCtx) {
+	ctx.Response.Header.Set("Access-Control-Allow-Origin", "*")             // Allow CORS
+	ctx.Response.Header.Set("Access-Control-Allow-Headers", "Content-Type") // Allow Content-Type header
+	ctx.Response.Header.Set("Content-Type", "application/json")             // Set content type
+
+	if _, err := api.authenticateAdmin(ctx); err != nil { // Check cannot authenticate
+		logger.Errorf("errored while handling SearchAccounts request: %s", err.Error()) // Log error
+
+		panic(err) // Panic
+	}
+
+	filter := &accounts.AccountFilter{
+		Query:      string(common.GetCtxValue(ctx, "query")),            // Set query
+		Role:       string(common.GetCtxValue(ctx, "role")),             // Set role
+		FrozenOnly: string(common.GetCtxValue(ctx, "frozen")) == "true", // Set frozen only
+	} // Init filter
+
+	if limit := string(common.GetCtxValue(ctx, "limit")); limit != "" { // Check has limit
+		var err error // Init error buffer
+
+		if (*filter).Limit, err = strconv.Atoi(limit); err != nil { // Parse limit
+			logger.Errorf("errored while handling SearchAccounts request: %s", err.Error()) // Log error
+
+			panic(err) // Panic
+		}
+	}
+
+	matches, err := api.AccountsDatabase.SearchAccounts(filter) // Search accounts
+
+	if err != nil { // Check for errors
+		logger.Errorf("errored while handling SearchAccounts request: %s", err.Error()) // Log error
+
+		panic(err) // Panic
+	}
+
+	response := &adminAccountsResponse{Accounts: []*adminAccountResponse{}} // Init response
+
+	for _, account := range matches { // Iterate through matches
+		response.Accounts = append(response.Accounts, &adminAccountResponse{
+			Name:                  account.Name,                  // Set name
+			Address:               account.Address.String(),      // Set address
+			Role:                  account.RoleName(),            // Set role
+			Email:                 account.Email,                 // Set email
+			Frozen:                account.Frozen,                // Set frozen
+			FreezeReason:          account.FreezeReason,          // Set freeze reason
+			FrozenBy:              account.FrozenBy,              // Set freezing admin
+			FrozenAt:              account.FrozenAt,              // Set freeze time
+			WatchOnly:             account.WatchOnly,             // Set watch-only
+			TwoFactor:             account.TwoFactorEnabled(),    // Set two-factor
+			PasswordResetRequired: account.PasswordResetRequired, // Set reset required
+			DeleteAfter:           account.DeleteAfter,           // Set purge time
+		}) // Append account
+	}
+
+	fmt.Fprintf(ctx, response.string()) // Respond with accounts
+}
+
+// FreezeAccount handles a FreezeAccount request.
+// The request must give the reason the account is frozen in its "reason" value.
+func (api *JSONHTTPAPI) FreezeAccount(ctx *fasthttp.RequestCtx) {
+	ctx.Response.Header.Set("Access-Control-Allow-Origin", "*")             // Allow CORS
+	ctx.Response.Header.Set("Access-Control-Allow-Headers", "Content-Type") // Allow Content-Type header
+	ctx.Response.Header.Set("Content-Type", "application/json")             // Set content type
+
+	username := ctx.UserValue("username").(string) // Get username
+
+	admin, err := api.authenticateAdmin(ctx) // Authenticate admin
+
+	if err != nil { // Check for errors
+		logger.Errorf("errored while handling FreezeAccount request with username %s: %s", username, err.Error()) // Log error
+
+		panic(err) // Panic
+	}
+
+	err = api.accountsDB(ctx).FreezeAccount(admin, username, string(common.GetCtxValue(ctx, "reason"))) // Freeze account
+
+	if err != nil { // Check for errors
+		logger.Errorf("errored while handling FreezeAccount request with username %s: %s", username, err.Error()) // Log error
+
+		panic(err) // Panic
+	}
+
+	fmt.Fprintf(ctx, `{"message": "account frozen successfully"}`) // Respond with success
+}
+
+// UnfreezeAccount handles an UnfreezeAccount request.
+func (api *JSONHTTPAPI) UnfreezeAccount(ctx *fasthttp.RequestCtx) {
+	ctx.Response.Header.Set("Access-Control-Allow-Origin", "*")             // Allow CORS
+	ctx.Response.Header.Set("Access-Control-Allow-Headers", "Content-Type") // Allow Content-Type header
+	ctx.Response.Header.Set("Content-Type", "application/json")             // Set content type
+
+	username := ctx.UserValue("username").(string) // Get username
+
+	admin, err := api.authenticateAdmin(ctx) // Authenticate admin
+
+	if err != nil { // Check for errors
+		logger.Errorf("errored while handling UnfreezeAccount request with username %s: %s", username, err.Error()) // Log error
+
+		panic(err) // Panic
+	}
+
+	err = api.accountsDB(ctx).UnfreezeAccount(admin, username) // Unfreeze account
+
+	if err != nil { // Check for errors
+		logger.Errorf("errored while handling UnfreezeAccount request with username %s: %s", username, err.Error()) // Log error
+
+		panic(err) // Panic
+	}
+
+	fmt.Fprintf(ctx, `{"message": "account unfrozen successfully"}`) // Respond with success
+}
+
+// ForcePasswordReset handles a ForcePasswordReset request.
+func (api *JSONHTTPAPI) ForcePasswordReset(ctx *fasthttp.RequestCtx) {
+	ctx.Response.Header.Set("Access-Control-Allow-Origin", "*")             // Allow CORS
+	ctx.Response.Header.Set("Access-Control-Allow-Headers", "Content-Type") // Allow Content-Type header
+	ctx.Response.Header.Set("Content-Type", "application/json")             // Set content type
+
+	username := ctx.UserValue("username").(string) // Get username
+
+	admin, err := api.authenticateAdmin(ctx) // Authenticate admin
+
+	if err != nil { // Check for errors
+		logger.Errorf("errored while handling ForcePasswordReset request with username %s: %s", username, err.Error()) // Log error
+
+		panic(err) // Panic
+	}
+
+	err = api.accountsDB(ctx).ForcePasswordReset(admin, username) // Force password reset
+
+	if err != nil { // Check for errors
+		logger.Errorf("errored while handling ForcePasswordReset request with username %s: %s", username, err.Error()) // Log error
+
+		panic(err) // Panic
+	}
+
+	fmt.Fprintf(ctx, `{"message": "password reset required successfully"}`) // Respond with success
+}
+
+// RevokeAccountTokens handles a RevokeAccountTokens request.
+func (api *JSONHTTPAPI) RevokeAccountTokens(ctx *fasthttp.RequestCtx) {
+	ctx.Response.Header.Set("Access-Control-Allow-Origin", "*")             // Allow CORS
+	ctx.Response.Header.Set("Access-Control-Allow-Headers", "Content-Type") // Allow Content-Type header
+	ctx.Response.Header.Set("Content-Type", "application/json")             // Set content type
+
+	username := ctx.UserValue("username").(string) // Get username
+
+	admin, err := api.authenticateAdmin(ctx) // Authenticate admin
+
+	if err != nil { // Check for errors
+		logger.Errorf("errored while handling RevokeAccountTokens request with username %s: %s", username, err.Error()) // Log error
+
+		panic(err) // Panic
+	}
+
+	revoked, err := api.accountsDB(ctx).RevokeAccountTokens(admin, username) // Revoke tokens
+
+	if err != nil { // Check for errors
+		logger.Errorf("errored while handling RevokeAccountTokens request with username %s: %s", username, err.Error()) // Log error
+
+		panic(err) // Panic
+	}
+
+	fmt.Fprintf(ctx, `{"revoked": %d}`, revoked) // Respond with revoked count
+}
+
+// GetAccountStats handles a GetAccountStats request.
+func (api *JSONHTTPAPI) GetAccountStats(ctx *fasthttp.RequestCtx) {
+	ctx.Response.Header.Set("Access-Control-Allow-Origin", "*")             // Allow CORS
+	ctx.Response.Header.Set("Access-Control-Allow-Headers", "Content-Type") // Allow Content-Type header
+	ctx.Response.Header.Set("Content-Type", "application/json")             // Set content type
+
+	if _, err := api.authenticateAdmin(ctx); err != nil { // Check cannot authenticate
+		logger.Errorf("errored while handling GetAccountStats request: %s", err.Error()) // Log error
+
+		panic(err) // Panic
+	}
+
+	stats, err := api.AccountsDatabase.QueryAccountStats() // Query stats
+
+	if err != nil { // Check for errors
+		logger.Errorf("errored while handling GetAccountStats request: %s", err.Error()) // Log error
+
+		panic(err) // Panic
+	}
+
+	marshaledVal, _ := json.MarshalIndent(*stats, "", "  ") // Marshal stats
+
+	fmt.Fprintf(ctx, string(marshaledVal)) // Respond with stats
+}
+
+/* END EXPORTED METHODS */
+
+/* BEGIN INTERNAL METHODS */
+
+// authenticateAdmin authenticates the account named in a request's "admin" value with the request's "password" value (the account's password,
+// or an access token carrying the admin scope), and checks that it has the admin role. Returns the admin's username.
+func (api *JSONHTTPAPI) authenticateAdmin(ctx *fasthttp.RequestCtx) (string, error) {
+	admin := string(common.GetCtxValue(ctx, "admin")) // Get admin username
+
+	if err := api.accountsDB(ctx).AuthenticateAdmin(admin, string(common.GetCtxValue(ctx, "password"))); err != nil { // Authenticate admin
+		return "", err // Return found error
+	}
+
+	return accounts.NormalizeUsername(admin), nil // Return admin username
+}
+
+// string marshals an adminAccountsResponse into a string.
+func (response *adminAccountsResponse) string() string {
+	marshaledVal, _ := json.MarshalIndent(*response, "", "  ") // Marshal
+
+	return string(marshaledVal) // Return response
+}
+
+/* END INTERNAL METHODS */
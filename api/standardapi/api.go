@@ -128,6 +128,72 @@ func (api *JSONHTTPAPI) StartServing() error {
 		return err // Return found error
 	}
 
+	err = api.SetupSessionRoutes() // Start serving sessions API
+
+	if err != nil { // Check for errors
+		return err // Return found error
+	}
+
+	err = api.SetupContactRoutes() // Start serving contacts API
+
+	if err != nil { // Check for errors
+		return err // Return found error
+	}
+
+	err = api.SetupSubAddressRoutes() // Start serving sub-addresses API
+
+	if err != nil { // Check for errors
+		return err // Return found error
+	}
+
+	err = api.SetupDirectoryRoutes() // Start serving directory API
+
+	if err != nil { // Check for errors
+		return err // Return found error
+	}
+
+	err = api.SetupSpendingLimitRoutes() // Start serving spending limits API
+
+	if err != nil { // Check for errors
+		return err // Return found error
+	}
+
+	err = api.SetupEmailRoutes() // Start serving email API
+
+	if err != nil { // Check for errors
+		return err // Return found error
+	}
+
+	err = api.SetupTwoFactorRoutes() // Start serving two-factor API
+
+	if err != nil { // Check for errors
+		return err // Return found error
+	}
+
+	err = api.SetupAdminRoutes() // Start serving admin API
+
+	if err != nil { // Check for errors
+		return err // Return found error
+	}
+
+	err = api.SetupBackupRoutes() // Start serving backup API
+
+	if err != nil { // Check for errors
+		return err // Return found error
+	}
+
+	err = api.SetupLockoutRoutes() // Start serving lockout API
+
+	if err != nil { // Check for errors
+		return err // Return found error
+	}
+
+	err = api.SetupAuditRoutes() // Start serving audit API
+
+	if err != nil { // Check for errors
+		return err // Return found error
+	}
+
 	err = api.SetupTransactionsRoutes() // Start serving transactions API
 
 	if err != nil { // Check for errors
@@ -181,6 +247,31 @@ func (api *JSONHTTPAPI) HandlePanic(ctx *fasthttp.RequestCtx, panic interface{})
 
 /* BEGIN INTERNAL METHODS */
 
+// accountsDB gets a handle to the accounts database that attributes failed logins to the source address of a given request.
+func (api *JSONHTTPAPI) accountsDB(ctx *fasthttp.RequestCtx) *accounts.DB {
+	return api.AccountsDatabase.FromClient(ctx.RemoteIP().String(), string(ctx.UserAgent())) // Return handle
+}
+
+// jsonValueFromCtx reads the raw JSON value of a given field from a request, unquoting it if it is a string (e.g. a string holding a JSON object).
+// Form values are returned as-is. GetCtxValue can't be used for JSON objects, since it strips the quotes that they depend on.
+func jsonValueFromCtx(ctx *fasthttp.RequestCtx, key string) []byte {
+	if formData := ctx.FormValue(key); formData != nil { // Check has form data
+		return formData // Return form data
+	}
+
+	jsonMap := make(map[string]json.RawMessage) // Init JSON map buffer
+
+	json.Unmarshal(ctx.PostBody(), &jsonMap) // Unmarshal
+
+	var unquoted string // Init unquoted value buffer
+
+	if err := json.Unmarshal(jsonMap[key], &unquoted); err == nil { // Check is string
+		return []byte(unquoted) // Return unquoted value
+	}
+
+	return jsonMap[key] // Return raw value
+}
+
 // string marshals an error response into a string.
 func (response *errorResponse) string() string {
 	marshaledVal, _ := json.MarshalIndent(*response, "", "  ") // marshal
@@ -0,0 +1,144 @@
+// Package standardapi defines the summercash-wallet-server API.
+package standardapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/SummerCash/summercash-wallet-server/accounts"
+	"github.com/SummerCash/summercash-wallet-server/common"
+)
+
+// auditEventsResponse represents a response to an audit events request.
+type auditEventsResponse struct {
+	Events []*accounts.AuditEvent `json:"events"` // Audit events, newest first
+}
+
+/* BEGIN EXPORTED METHODS */
+
+// SetupAuditRoutes sets up all the audit api-related routes.
+func (api *JSONHTTPAPI) SetupAuditRoutes() error {
+	api.Router.GET("/api/accounts/:username/audit", api.GetAccountAuditEvents) // Set GetAccountAuditEvents get
+	api.Router.GET("/api/admin/audit", api.QueryAuditEvents)                   // Set QueryAuditEvents get
+
+	return nil // No error occurred, return nil
+}
+
+// GetAccountAuditEvents handles a GetAccountAuditEvents request.
+// The account's own events may be narrowed down by action, source, outcome, since, until and limit (see QueryAuditEvents).
+func (api *JSONHTTPAPI) GetAccountAuditEvents(ctx *fasthttp.RequestCtx) {
+	ctx.Response.Header.Set("Access-Control-Allow-Origin", "*")             // Allow CORS
+	ctx.Response.Header.Set("Access-Control-Allow-Headers", "Content-Type") // Allow Content-Type header
+	ctx.Response.Header.Set("Content-Type", "application/json")             // Set content type
+
+	username := ctx.UserValue("username").(string) // Get username
+
+	if err := api.accountsDB(ctx).AuthenticateScoped(username, string(common.GetCtxValue(ctx, "password")), accounts.ScopeRead); err != nil { // Check cannot authenticate
+		logger.Errorf("errored while handling GetAccountAuditEvents request with username %s: %s", username, err.Error()) // Log error
+
+		panic(err) // Panic
+	}
+
+	filter, err := auditFilterFromCtx(ctx) // Parse filter
+
+	if err != nil { // Check for errors
+		logger.Errorf("errored while handling GetAccountAuditEvents request with username %s: %s", username, err.Error()) // Log error
+
+		panic(err) // Panic
+	}
+
+	(*filter).Username = username // Only match own events
+
+	events, err := api.AccountsDatabase.QueryAuditEvents(filter) // Query events
+
+	if err != nil { // Check for errors
+		logger.Errorf("errored while handling GetAccountAuditEvents request with username %s: %s", username, err.Error()) // Log error
+
+		panic(err) // Panic
+	}
+
+	fmt.Fprintf(ctx, (&auditEventsResponse{Events: events}).string()) // Respond with events
+}
+
+// QueryAuditEvents handles a QueryAuditEvents request.
+// Events across all accounts may be filtered by username, action, source, outcome, since and until (RFC 3339 times), and limited by limit.
+// The request must be authenticated as an account with the admin role (see authenticateAdmin).
+func (api *JSONHTTPAPI) QueryAuditEvents(ctx *fasthttp.RequestCtx) {
+	ctx.Response.Header.Set("Access-Control-Allow-Origin", "*")             // Allow CORS
+	ctx.Response.Header.Set("Access-Control-Allow-Headers", "Content-Type") // Allow Content-Type header
+	ctx.Response.Header.Set("Content-Type", "application/json")             // Set content type
+
+	if _, err := api.authenticateAdmin(ctx); err != nil { // Check cannot authenticate
+		logger.Errorf("errored while handling QueryAuditEvents request: %s", err.Error()) // Log error
+
+		panic(err) // Panic
+	}
+
+	filter, err := auditFilterFromCtx(ctx) // Parse filter
+
+	if err != nil { // Check for errors
+		logger.Errorf("errored while handling QueryAuditEvents request: %s", err.Error()) // Log error
+
+		panic(err) // Panic
+	}
+
+	(*filter).Username = string(common.GetCtxValue(ctx, "username")) // Set username
+
+	events, err := api.AccountsDatabase.QueryAuditEvents(filter) // Query events
+
+	if err != nil { // Check for errors
+		logger.Errorf("errored while handling QueryAuditEvents request: %s", err.Error()) // Log error
+
+		panic(err) // Panic
+	}
+
+	fmt.Fprintf(ctx, (&auditEventsResponse{Events: events}).string()) // Respond with events
+}
+
+/* END EXPORTED METHODS */
+
+/* BEGIN INTERNAL METHODS */
+
+// auditFilterFromCtx parses the action, source, outcome, since, until and limit audit filter criteria of a given request.
+func auditFilterFromCtx(ctx *fasthttp.RequestCtx) (*accounts.AuditFilter, error) {
+	filter := &accounts.AuditFilter{
+		Action:  string(common.GetCtxValue(ctx, "action")),  // Set action
+		Source:  string(common.GetCtxValue(ctx, "source")),  // Set source
+		Outcome: string(common.GetCtxValue(ctx, "outcome")), // Set outcome
+	} // Init filter
+
+	var err error // Init error buffer
+
+	if since := string(common.GetCtxValue(ctx, "since")); since != "" { // Check has since
+		if (*filter).Since, err = time.Parse(time.RFC3339, since); err != nil { // Parse since
+			return nil, err // Return found error
+		}
+	}
+
+	if until := string(common.GetCtxValue(ctx, "until")); until != "" { // Check has until
+		if (*filter).Until, err = time.Parse(time.RFC3339, until); err != nil { // Parse until
+			return nil, err // Return found error
+		}
+	}
+
+	if limit := string(common.GetCtxValue(ctx, "limit")); limit != "" { // Check has limit
+		if (*filter).Limit, err = strconv.Atoi(limit); err != nil { // Parse limit
+			return nil, err // Return found error
+		}
+	}
+
+	return filter, nil // Return filter
+}
+
+// string marshals an audit events response into a string.
+func (response *auditEventsResponse) string() string {
+	marshaledVal, _ := json.MarshalIndent(*response, "", "  ") // marshal
+
+	return string(marshaledVal) // Return response
+}
+
+/* END INTERNAL METHODS */
@@ -0,0 +1,68 @@
+// Package standardapi defines the summercash-wallet-server API.
+package standardapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/SummerCash/summercash-wallet-server/backup"
+	"github.com/SummerCash/summercash-wallet-server/common"
+)
+
+// createBackupResponse represents a response to a CreateBackup request.
+type createBackupResponse struct {
+	Path string `json:"path"` // Archive path
+
+	Manifest *backup.Manifest `json:"manifest"` // Archive manifest
+}
+
+/* BEGIN EXPORTED METHODS */
+
+// SetupBackupRoutes sets up all the backup api-related routes.
+func (api *JSONHTTPAPI) SetupBackupRoutes() error {
+	backupAPIRoot := "/api/admin/backup" // Get backup API root path
+
+	api.Router.POST(backupAPIRoot, api.CreateBackup) // Set CreateBackup post
+
+	return nil // No error occurred, return nil
+}
+
+// CreateBackup handles a CreateBackup request.
+// The request must be authenticated as an account with the admin role (see authenticateAdmin).
+func (api *JSONHTTPAPI) CreateBackup(ctx *fasthttp.RequestCtx) {
+	ctx.Response.Header.Set("Access-Control-Allow-Origin", "*")             // Allow CORS
+	ctx.Response.Header.Set("Access-Control-Allow-Headers", "Content-Type") // Allow Content-Type header
+	ctx.Response.Header.Set("Content-Type", "application/json")             // Set content type
+
+	if _, err := api.authenticateAdmin(ctx); err != nil { // Check cannot authenticate
+		logger.Errorf("errored while handling CreateBackup request: %s", err.Error()) // Log error
+
+		panic(err) // Panic
+	}
+
+	archivePath, manifest, err := backup.CreateFile(api.AccountsDatabase, filepath.Join(common.DataDir, "backups")) // Write backup
+
+	if err != nil { // Check for errors
+		logger.Errorf("errored while handling CreateBackup request: %s", err.Error()) // Log error
+
+		panic(err) // Panic
+	}
+
+	fmt.Fprintf(ctx, (&createBackupResponse{Path: archivePath, Manifest: manifest}).string()) // Respond with backup
+}
+
+/* END EXPORTED METHODS */
+
+/* BEGIN INTERNAL METHODS */
+
+// string marshals a createBackupResponse into a string.
+func (response *createBackupResponse) string() string {
+	marshaledVal, _ := json.MarshalIndent(*response, "", "  ") // Marshal
+
+	return string(marshaledVal) // Return response
+}
+
+/* END INTERNAL METHODS */
@@ -0,0 +1,167 @@
+// Package standardapi defines the summercash-wallet-server API.
+package standardapi
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/SummerCash/summercash-wallet-server/accounts"
+	"github.com/SummerCash/summercash-wallet-server/common"
+)
+
+// listContactsResponse represents a response to a ListContacts request.
+type listContactsResponse struct {
+	Contacts []*accounts.Contact `json:"contacts"` // Account contacts
+}
+
+/* BEGIN EXPORTED METHODS */
+
+// SetupContactRoutes sets up all the contact api-related routes.
+func (api *JSONHTTPAPI) SetupContactRoutes() error {
+	contactsAPIRoot := "/api/accounts/:username/contacts" // Get contacts API root path
+
+	api.Router.GET(contactsAPIRoot, api.ListContacts)                               // Set ListContacts get
+	api.Router.POST(contactsAPIRoot, api.AddContact)                                // Set AddContact post
+	api.Router.PUT(fmt.Sprintf("%s/:label", contactsAPIRoot), api.UpdateContact)    // Set UpdateContact put
+	api.Router.DELETE(fmt.Sprintf("%s/:label", contactsAPIRoot), api.RemoveContact) // Set RemoveContact delete
+
+	return nil // No error occurred, return nil
+}
+
+// ListContacts handles a ListContacts request.
+func (api *JSONHTTPAPI) ListContacts(ctx *fasthttp.RequestCtx) {
+	ctx.Response.Header.Set("Access-Control-Allow-Origin", "*")             // Allow CORS
+	ctx.Response.Header.Set("Access-Control-Allow-Headers", "Content-Type") // Allow Content-Type header
+	ctx.Response.Header.Set("Content-Type", "application/json")             // Set content type
+
+	username := ctx.UserValue("username").(string) // Get username
+
+	if err := api.accountsDB(ctx).AuthenticateScoped(username, string(common.GetCtxValue(ctx, "password")), accounts.ScopeRead); err != nil { // Check cannot authenticate
+		logger.Errorf("errored while handling ListContacts request with username %s: %s", username, err.Error()) // Log error
+
+		panic(err) // Panic
+	}
+
+	contacts, err := api.AccountsDatabase.ListContacts(username) // List contacts
+
+	if err != nil { // Check for errors
+		logger.Errorf("errored while handling ListContacts request with username %s: %s", username, err.Error()) // Log error
+
+		panic(err) // Panic
+	}
+
+	fmt.Fprintf(ctx, (&listContactsResponse{Contacts: contacts}).string()) // Respond with contacts
+}
+
+// AddContact handles an AddContact request.
+// Since contacts decide where sends go, changing them requires the send scope.
+func (api *JSONHTTPAPI) AddContact(ctx *fasthttp.RequestCtx) {
+	ctx.Response.Header.Set("Access-Control-Allow-Origin", "*")             // Allow CORS
+	ctx.Response.Header.Set("Access-Control-Allow-Headers", "Content-Type") // Allow Content-Type header
+	ctx.Response.Header.Set("Content-Type", "application/json")             // Set content type
+
+	username := ctx.UserValue("username").(string) // Get username
+
+	if err := api.accountsDB(ctx).AuthenticateScoped(username, string(common.GetCtxValue(ctx, "password")), accounts.ScopeSend); err != nil { // Check cannot authenticate
+		logger.Errorf("errored while handling AddContact request with username %s: %s", username, err.Error()) // Log error
+
+		panic(err) // Panic
+	}
+
+	contact, err := api.AccountsDatabase.AddContact(username, contactFromCtx(ctx, string(common.GetCtxValue(ctx, "label")))) // Add contact
+
+	if err != nil { // Check for errors
+		logger.Errorf("errored while handling AddContact request with username %s: %s", username, err.Error()) // Log error
+
+		panic(err) // Panic
+	}
+
+	marshaledContact, _ := json.MarshalIndent(contact, "", "  ") // Marshal contact
+
+	fmt.Fprintf(ctx, string(marshaledContact)) // Respond with contact
+}
+
+// UpdateContact handles an UpdateContact request.
+// The contact is replaced in full; a new label may be given to relabel it.
+func (api *JSONHTTPAPI) UpdateContact(ctx *fasthttp.RequestCtx) {
+	ctx.Response.Header.Set("Access-Control-Allow-Origin", "*")             // Allow CORS
+	ctx.Response.Header.Set("Access-Control-Allow-Headers", "Content-Type") // Allow Content-Type header
+	ctx.Response.Header.Set("Content-Type", "application/json")             // Set content type
+
+	username := ctx.UserValue("username").(string) // Get username
+	label := ctx.UserValue("label").(string)       // Get label
+
+	if err := api.accountsDB(ctx).AuthenticateScoped(username, string(common.GetCtxValue(ctx, "password")), accounts.ScopeSend); err != nil { // Check cannot authenticate
+		logger.Errorf("errored while handling UpdateContact request with username %s: %s", username, err.Error()) // Log error
+
+		panic(err) // Panic
+	}
+
+	newLabel := string(common.GetCtxValue(ctx, "new_label")) // Get new label
+
+	if newLabel == "" { // Check not relabeling
+		newLabel = label // Keep label
+	}
+
+	contact, err := api.AccountsDatabase.UpdateContact(username, label, contactFromCtx(ctx, newLabel)) // Update contact
+
+	if err != nil { // Check for errors
+		logger.Errorf("errored while handling UpdateContact request with username %s: %s", username, err.Error()) // Log error
+
+		panic(err) // Panic
+	}
+
+	marshaledContact, _ := json.MarshalIndent(contact, "", "  ") // Marshal contact
+
+	fmt.Fprintf(ctx, string(marshaledContact)) // Respond with contact
+}
+
+// RemoveContact handles a RemoveContact request.
+func (api *JSONHTTPAPI) RemoveContact(ctx *fasthttp.RequestCtx) {
+	ctx.Response.Header.Set("Access-Control-Allow-Origin", "*")             // Allow CORS
+	ctx.Response.Header.Set("Access-Control-Allow-Headers", "Content-Type") // Allow Content-Type header
+	ctx.Response.Header.Set("Content-Type", "application/json")             // Set content type
+
+	username := ctx.UserValue("username").(string) // Get username
+
+	if err := api.accountsDB(ctx).AuthenticateScoped(username, string(common.GetCtxValue(ctx, "password")), accounts.ScopeSend); err != nil { // Check cannot authenticate
+		logger.Errorf("errored while handling RemoveContact request with username %s: %s", username, err.Error()) // Log error
+
+		panic(err) // Panic
+	}
+
+	err := api.AccountsDatabase.RemoveContact(username, ctx.UserValue("label").(string)) // Remove contact
+
+	if err != nil { // Check for errors
+		logger.Errorf("errored while handling RemoveContact request with username %s: %s", username, err.Error()) // Log error
+
+		panic(err) // Panic
+	}
+
+	fmt.Fprintf(ctx, `{"message": "contact removed successfully"}`) // Respond with success
+}
+
+/* END EXPORTED METHODS */
+
+/* BEGIN INTERNAL METHODS */
+
+// contactFromCtx reads the address, username (as contact_username, since username names the account) and note of a contact with a given label from a request.
+func contactFromCtx(ctx *fasthttp.RequestCtx, label string) *accounts.Contact {
+	return &accounts.Contact{
+		Label:    label,                                               // Set label
+		Address:  string(common.GetCtxValue(ctx, "address")),          // Set address
+		Username: string(common.GetCtxValue(ctx, "contact_username")), // Set username
+		Note:     string(common.GetCtxValue(ctx, "note")),             // Set note
+	} // Return contact
+}
+
+// string marshals a list contacts response into a string.
+func (response *listContactsResponse) string() string {
+	marshaledVal, _ := json.MarshalIndent(*response, "", "  ") // marshal
+
+	return string(marshaledVal) // Return response
+}
+
+/* END INTERNAL METHODS */
@@ -0,0 +1,127 @@
+// Package standardapi defines the summercash-wallet-server API.
+package standardapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/SummerCash/summercash-wallet-server/accounts"
+	"github.com/SummerCash/summercash-wallet-server/common"
+)
+
+// directoryResponse represents a response to a SearchDirectory request.
+type directoryResponse struct {
+	Accounts []*directoryEntryResponse `json:"accounts"` // Matching accounts, sorted by username
+
+	NextCursor string `json:"next_cursor,omitempty"` // Cursor of the next page (empty if this is the last page)
+}
+
+// directoryEntryResponse represents a single account in a SearchDirectory response.
+type directoryEntryResponse struct {
+	Username string `json:"username"` // Username
+
+	Address string `json:"address"` // Hex primary address
+}
+
+/* BEGIN EXPORTED METHODS */
+
+// SetupDirectoryRoutes sets up all the directory api-related routes.
+func (api *JSONHTTPAPI) SetupDirectoryRoutes() error {
+	api.Router.GET("/api/directory", api.SearchDirectory)                               // Set SearchDirectory get
+	api.Router.POST("/api/accounts/:username/discoverable", api.SetAccountDiscoverable) // Set SetAccountDiscoverable post
+
+	return nil // No error occurred, return nil
+}
+
+// SearchDirectory handles a SearchDirectory request.
+// The requesting account must be authenticated by its "username" and "password" (or an access token carrying the read scope).
+// Discoverable accounts are listed by "prefix", a page of at most "limit" accounts at a time, continuing after "cursor".
+func (api *JSONHTTPAPI) SearchDirectory(ctx *fasthttp.RequestCtx) {
+	ctx.Response.Header.Set("Access-Control-Allow-Origin", "*")             // Allow CORS
+	ctx.Response.Header.Set("Access-Control-Allow-Headers", "Content-Type") // Allow Content-Type header
+	ctx.Response.Header.Set("Content-Type", "application/json")             // Set content type
+
+	username := string(common.GetCtxValue(ctx, "username")) // Get username
+
+	if err := api.accountsDB(ctx).AuthenticateScoped(username, string(common.GetCtxValue(ctx, "password")), accounts.ScopeRead); err != nil { // Check cannot authenticate
+		logger.Errorf("errored while handling SearchDirectory request with username %s: %s", username, err.Error()) // Log error
+
+		panic(err) // Panic
+	}
+
+	limit := 0 // Init limit buffer
+
+	if limitValue := string(common.GetCtxValue(ctx, "limit")); limitValue != "" { // Check has limit
+		var err error // Init error buffer
+
+		if limit, err = strconv.Atoi(limitValue); err != nil { // Parse limit
+			logger.Errorf("errored while handling SearchDirectory request with username %s: %s", username, err.Error()) // Log error
+
+			panic(err) // Panic
+		}
+	}
+
+	page, err := api.AccountsDatabase.SearchDirectory(string(common.GetCtxValue(ctx, "prefix")), string(common.GetCtxValue(ctx, "cursor")), limit) // Search directory
+
+	if err != nil { // Check for errors
+		logger.Errorf("errored while handling SearchDirectory request with username %s: %s", username, err.Error()) // Log error
+
+		panic(err) // Panic
+	}
+
+	response := &directoryResponse{
+		Accounts:   []*directoryEntryResponse{}, // Init accounts
+		NextCursor: page.NextCursor,             // Set cursor
+	} // Init response
+
+	for _, entry := range page.Entries { // Iterate through entries
+		response.Accounts = append(response.Accounts, &directoryEntryResponse{
+			Username: entry.Username,         // Set username
+			Address:  entry.Address.String(), // Set address
+		}) // Append entry
+	}
+
+	fmt.Fprintf(ctx, response.string()) // Respond with page
+}
+
+// SetAccountDiscoverable handles a SetAccountDiscoverable request.
+// The account is listed in the directory if "discoverable" is true, and hidden from it otherwise.
+func (api *JSONHTTPAPI) SetAccountDiscoverable(ctx *fasthttp.RequestCtx) {
+	ctx.Response.Header.Set("Access-Control-Allow-Origin", "*")             // Allow CORS
+	ctx.Response.Header.Set("Access-Control-Allow-Headers", "Content-Type") // Allow Content-Type header
+	ctx.Response.Header.Set("Content-Type", "application/json")             // Set content type
+
+	username := ctx.UserValue("username").(string) // Get username
+
+	discoverable, err := strconv.ParseBool(string(common.GetCtxValue(ctx, "discoverable"))) // Parse discoverable
+
+	if err != nil { // Check for errors
+		logger.Errorf("errored while handling SetAccountDiscoverable request with username %s: %s", username, err.Error()) // Log error
+
+		panic(err) // Panic
+	}
+
+	if err = api.accountsDB(ctx).SetDiscoverable(username, string(common.GetCtxValue(ctx, "password")), discoverable); err != nil { // Set discoverable
+		logger.Errorf("errored while handling SetAccountDiscoverable request with username %s: %s", username, err.Error()) // Log error
+
+		panic(err) // Panic
+	}
+
+	fmt.Fprintf(ctx, `{"discoverable": %t}`, discoverable) // Respond with setting
+}
+
+/* END EXPORTED METHODS */
+
+/* BEGIN INTERNAL METHODS */
+
+// string marshals a directoryResponse into a string.
+func (response *directoryResponse) string() string {
+	marshaledVal, _ := json.MarshalIndent(*response, "", "  ") // Marshal
+
+	return string(marshaledVal) // Return response
+}
+
+/* END INTERNAL METHODS */
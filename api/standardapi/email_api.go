@@ -0,0 +1,137 @@
+// Package standardapi defines the summercash-wallet-server API.
+package standardapi
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/SummerCash/summercash-wallet-server/accounts"
+	"github.com/SummerCash/summercash-wallet-server/common"
+)
+
+// emailResponse represents a response to an email request.
+type emailResponse struct {
+	Email string `json:"email"` // Verified email address (empty if none)
+
+	PendingEmail string `json:"pending_email,omitempty"` // Email address awaiting verification
+}
+
+/* BEGIN EXPORTED METHODS */
+
+// SetupEmailRoutes sets up all the email api-related routes.
+func (api *JSONHTTPAPI) SetupEmailRoutes() error {
+	emailAPIRoot := "/api/accounts/:username/email" // Get email API root path
+
+	api.Router.GET(emailAPIRoot, api.GetEmail)                               // Set GetEmail get
+	api.Router.POST(emailAPIRoot, api.SetEmail)                              // Set SetEmail post
+	api.Router.POST(fmt.Sprintf("%s/verify", emailAPIRoot), api.VerifyEmail) // Set VerifyEmail post
+
+	return nil // No error occurred, return nil
+}
+
+// GetEmail handles a GetEmail request.
+// The account must be authenticated by its "password" (or an access token carrying the read scope).
+func (api *JSONHTTPAPI) GetEmail(ctx *fasthttp.RequestCtx) {
+	ctx.Response.Header.Set("Access-Control-Allow-Origin", "*")             // Allow CORS
+	ctx.Response.Header.Set("Access-Control-Allow-Headers", "Content-Type") // Allow Content-Type header
+	ctx.Response.Header.Set("Content-Type", "application/json")             // Set content type
+
+	username := ctx.UserValue("username").(string) // Get username
+
+	if err := api.accountsDB(ctx).AuthenticateScoped(username, string(common.GetCtxValue(ctx, "password")), accounts.ScopeRead); err != nil { // Check cannot authenticate
+		logger.Errorf("errored while handling GetEmail request with username %s: %s", username, err.Error()) // Log error
+
+		panic(err) // Panic
+	}
+
+	account, err := api.AccountsDatabase.QueryAccountByUsername(username) // Query account
+
+	if err != nil { // Check for errors
+		logger.Errorf("errored while handling GetEmail request with username %s: %s", username, err.Error()) // Log error
+
+		panic(err) // Panic
+	}
+
+	fmt.Fprintf(ctx, newEmailResponse(account).string()) // Respond with email
+}
+
+// SetEmail handles a SetEmail request.
+// The account must be authenticated by its "password" (or an access token carrying the admin scope).
+// A verification token is mailed to "email", which becomes the account's email once verified. An empty "email" removes the account's email.
+func (api *JSONHTTPAPI) SetEmail(ctx *fasthttp.RequestCtx) {
+	ctx.Response.Header.Set("Access-Control-Allow-Origin", "*")             // Allow CORS
+	ctx.Response.Header.Set("Access-Control-Allow-Headers", "Content-Type") // Allow Content-Type header
+	ctx.Response.Header.Set("Content-Type", "application/json")             // Set content type
+
+	username := ctx.UserValue("username").(string) // Get username
+
+	if err := api.accountsDB(ctx).SetEmail(username, string(common.GetCtxValue(ctx, "password")), string(common.GetCtxValue(ctx, "email"))); err != nil { // Set email
+		logger.Errorf("errored while handling SetEmail request with username %s: %s", username, err.Error()) // Log error
+
+		panic(err) // Panic
+	}
+
+	account, err := api.AccountsDatabase.QueryAccountByUsername(username) // Query account
+
+	if err != nil { // Check for errors
+		logger.Errorf("errored while handling SetEmail request with username %s: %s", username, err.Error()) // Log error
+
+		panic(err) // Panic
+	}
+
+	fmt.Fprintf(ctx, newEmailResponse(account).string()) // Respond with email
+}
+
+// VerifyEmail handles a VerifyEmail request.
+// The email address awaiting verification becomes the account's email, given the "token" mailed to it.
+func (api *JSONHTTPAPI) VerifyEmail(ctx *fasthttp.RequestCtx) {
+	ctx.Response.Header.Set("Access-Control-Allow-Origin", "*")             // Allow CORS
+	ctx.Response.Header.Set("Access-Control-Allow-Headers", "Content-Type") // Allow Content-Type header
+	ctx.Response.Header.Set("Content-Type", "application/json")             // Set content type
+
+	username := ctx.UserValue("username").(string) // Get username
+
+	if err := api.accountsDB(ctx).VerifyEmail(username, string(common.GetCtxValue(ctx, "token"))); err != nil { // Verify email
+		logger.Errorf("errored while handling VerifyEmail request with username %s: %s", username, err.Error()) // Log error
+
+		panic(err) // Panic
+	}
+
+	account, err := api.AccountsDatabase.QueryAccountByUsername(username) // Query account
+
+	if err != nil { // Check for errors
+		logger.Errorf("errored while handling VerifyEmail request with username %s: %s", username, err.Error()) // Log error
+
+		panic(err) // Panic
+	}
+
+	fmt.Fprintf(ctx, newEmailResponse(account).string()) // Respond with email
+}
+
+/* END EXPORTED METHODS */
+
+/* BEGIN INTERNAL METHODS */
+
+// newEmailResponse initializes a new email response from a given account.
+func newEmailResponse(account *accounts.Account) *emailResponse {
+	response := &emailResponse{
+		Email: account.Email, // Set email
+	} // Init response
+
+	if account.EmailVerification != nil { // Check has pending email
+		response.PendingEmail = account.EmailVerification.Email // Set pending email
+	}
+
+	return response // Return response
+}
+
+// string marshals an emailResponse into a string.
+func (response *emailResponse) string() string {
+	marshaledVal, _ := json.MarshalIndent(*response, "", "  ") // Marshal
+
+	return string(marshaledVal) // Return response
+}
+
+/* END INTERNAL METHODS */
@@ -9,6 +9,7 @@ import (
 
 	"github.com/valyala/fasthttp"
 
+	"github.com/SummerCash/summercash-wallet-server/accounts"
 	"github.com/SummerCash/summercash-wallet-server/common"
 )
 
@@ -49,6 +50,8 @@ func (api *JSONHTTPAPI) Claim(ctx *fasthttp.RequestCtx) {
 
 	err = (*api.Faucet).Claim(account, amount) // Claim
 
+	api.accountsDB(ctx).RecordAuditEvent(account.Name, accounts.AuditActionFaucetClaim, string(common.GetCtxValue(ctx, "amount")), err) // Record claim
+
 	if err != nil { // Check for errors
 		logger.Errorf("errored while handling Claim request with username %s: %s", string(common.GetCtxValue(ctx, "username")), err.Error()) // Log error
 
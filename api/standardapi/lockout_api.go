@@ -0,0 +1,72 @@
+// Package standardapi defines the summercash-wallet-server API.
+package standardapi
+
+import (
+	"fmt"
+
+	"github.com/valyala/fasthttp"
+)
+
+/* BEGIN EXPORTED METHODS */
+
+// SetupLockoutRoutes sets up all the lockout api-related routes.
+func (api *JSONHTTPAPI) SetupLockoutRoutes() error {
+	api.Router.POST("/api/admin/accounts/:username/unlock", api.UnlockAccount) // Set UnlockAccount post
+	api.Router.POST("/api/admin/sources/:source/unlock", api.UnlockSource)     // Set UnlockSource post
+
+	return nil // No error occurred, return nil
+}
+
+// UnlockAccount handles an UnlockAccount request.
+// The request must be authenticated as an account with the admin role (see authenticateAdmin).
+func (api *JSONHTTPAPI) UnlockAccount(ctx *fasthttp.RequestCtx) {
+	ctx.Response.Header.Set("Access-Control-Allow-Origin", "*")             // Allow CORS
+	ctx.Response.Header.Set("Access-Control-Allow-Headers", "Content-Type") // Allow Content-Type header
+	ctx.Response.Header.Set("Content-Type", "application/json")             // Set content type
+
+	if _, err := api.authenticateAdmin(ctx); err != nil { // Check cannot authenticate
+		logger.Errorf("errored while handling UnlockAccount request: %s", err.Error()) // Log error
+
+		panic(err) // Panic
+	}
+
+	username := ctx.UserValue("username").(string) // Get username
+
+	err := api.accountsDB(ctx).UnlockAccount(username) // Unlock account
+
+	if err != nil { // Check for errors
+		logger.Errorf("errored while handling UnlockAccount request with username %s: %s", username, err.Error()) // Log error
+
+		panic(err) // Panic
+	}
+
+	fmt.Fprintf(ctx, `{"message": "account unlocked successfully"}`) // Respond with success
+}
+
+// UnlockSource handles an UnlockSource request.
+// The request must be authenticated as an account with the admin role (see authenticateAdmin).
+func (api *JSONHTTPAPI) UnlockSource(ctx *fasthttp.RequestCtx) {
+	ctx.Response.Header.Set("Access-Control-Allow-Origin", "*")             // Allow CORS
+	ctx.Response.Header.Set("Access-Control-Allow-Headers", "Content-Type") // Allow Content-Type header
+	ctx.Response.Header.Set("Content-Type", "application/json")             // Set content type
+
+	if _, err := api.authenticateAdmin(ctx); err != nil { // Check cannot authenticate
+		logger.Errorf("errored while handling UnlockSource request: %s", err.Error()) // Log error
+
+		panic(err) // Panic
+	}
+
+	source := ctx.UserValue("source").(string) // Get source address
+
+	err := api.AccountsDatabase.UnlockSource(source) // Unlock source
+
+	if err != nil { // Check for errors
+		logger.Errorf("errored while handling UnlockSource request with source %s: %s", source, err.Error()) // Log error
+
+		panic(err) // Panic
+	}
+
+	fmt.Fprintf(ctx, `{"message": "source unlocked successfully"}`) // Respond with success
+}
+
+/* END EXPORTED METHODS */
@@ -0,0 +1,118 @@
+// Package standardapi defines the summercash-wallet-server API.
+package standardapi
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/SummerCash/summercash-wallet-server/accounts"
+	"github.com/SummerCash/summercash-wallet-server/common"
+)
+
+// listSessionsResponse represents a response to a ListSessions request.
+type listSessionsResponse struct {
+	Sessions []*accounts.Session `json:"sessions"` // Account sessions
+}
+
+/* BEGIN EXPORTED METHODS */
+
+// SetupSessionRoutes sets up all the session api-related routes.
+func (api *JSONHTTPAPI) SetupSessionRoutes() error {
+	sessionsAPIRoot := "/api/accounts/:username/sessions" // Get sessions API root path
+
+	api.Router.GET(sessionsAPIRoot, api.ListSessions)                                 // Set ListSessions get
+	api.Router.DELETE(sessionsAPIRoot, api.RevokeOtherSessions)                       // Set RevokeOtherSessions delete
+	api.Router.DELETE(fmt.Sprintf("%s/:session", sessionsAPIRoot), api.RevokeSession) // Set RevokeSession delete
+
+	return nil // No error occurred, return nil
+}
+
+// ListSessions handles a ListSessions request.
+func (api *JSONHTTPAPI) ListSessions(ctx *fasthttp.RequestCtx) {
+	ctx.Response.Header.Set("Access-Control-Allow-Origin", "*")             // Allow CORS
+	ctx.Response.Header.Set("Access-Control-Allow-Headers", "Content-Type") // Allow Content-Type header
+	ctx.Response.Header.Set("Content-Type", "application/json")             // Set content type
+
+	username := ctx.UserValue("username").(string) // Get username
+
+	if err := api.accountsDB(ctx).AuthenticateScoped(username, string(common.GetCtxValue(ctx, "password")), accounts.ScopeRead); err != nil { // Check cannot authenticate
+		logger.Errorf("errored while handling ListSessions request with username %s: %s", username, err.Error()) // Log error
+
+		panic(err) // Panic
+	}
+
+	sessions, err := api.AccountsDatabase.ListSessions(username, string(common.GetCtxValue(ctx, "password"))) // List sessions
+
+	if err != nil { // Check for errors
+		logger.Errorf("errored while handling ListSessions request with username %s: %s", username, err.Error()) // Log error
+
+		panic(err) // Panic
+	}
+
+	fmt.Fprintf(ctx, (&listSessionsResponse{Sessions: sessions}).string()) // Respond with sessions
+}
+
+// RevokeSession handles a RevokeSession request.
+func (api *JSONHTTPAPI) RevokeSession(ctx *fasthttp.RequestCtx) {
+	ctx.Response.Header.Set("Access-Control-Allow-Origin", "*")             // Allow CORS
+	ctx.Response.Header.Set("Access-Control-Allow-Headers", "Content-Type") // Allow Content-Type header
+	ctx.Response.Header.Set("Content-Type", "application/json")             // Set content type
+
+	username := ctx.UserValue("username").(string) // Get username
+
+	if err := api.accountsDB(ctx).AuthenticateScoped(username, string(common.GetCtxValue(ctx, "password")), accounts.ScopeRead); err != nil { // Check cannot authenticate
+		logger.Errorf("errored while handling RevokeSession request with username %s: %s", username, err.Error()) // Log error
+
+		panic(err) // Panic
+	}
+
+	err := api.AccountsDatabase.RevokeSession(username, ctx.UserValue("session").(string)) // Revoke session
+
+	if err != nil { // Check for errors
+		logger.Errorf("errored while handling RevokeSession request with username %s: %s", username, err.Error()) // Log error
+
+		panic(err) // Panic
+	}
+
+	fmt.Fprintf(ctx, `{"message": "session revoked successfully"}`) // Respond with success
+}
+
+// RevokeOtherSessions handles a RevokeOtherSessions request.
+func (api *JSONHTTPAPI) RevokeOtherSessions(ctx *fasthttp.RequestCtx) {
+	ctx.Response.Header.Set("Access-Control-Allow-Origin", "*")             // Allow CORS
+	ctx.Response.Header.Set("Access-Control-Allow-Headers", "Content-Type") // Allow Content-Type header
+	ctx.Response.Header.Set("Content-Type", "application/json")             // Set content type
+
+	username := ctx.UserValue("username").(string) // Get username
+
+	if err := api.accountsDB(ctx).AuthenticateScoped(username, string(common.GetCtxValue(ctx, "password")), accounts.ScopeRead); err != nil { // Check cannot authenticate
+		logger.Errorf("errored while handling RevokeOtherSessions request with username %s: %s", username, err.Error()) // Log error
+
+		panic(err) // Panic
+	}
+
+	revoked, err := api.AccountsDatabase.RevokeOtherSessions(username, string(common.GetCtxValue(ctx, "password"))) // Revoke other sessions
+
+	if err != nil { // Check for errors
+		logger.Errorf("errored while handling RevokeOtherSessions request with username %s: %s", username, err.Error()) // Log error
+
+		panic(err) // Panic
+	}
+
+	fmt.Fprintf(ctx, `{"revoked": %d}`, revoked) // Respond with number of revoked tokens
+}
+
+/* END EXPORTED METHODS */
+
+/* BEGIN INTERNAL METHODS */
+
+// string marshals a listSessionsResponse into a JSON-formatted string.
+func (response *listSessionsResponse) string() string {
+	marshaledVal, _ := json.MarshalIndent(*response, "", "  ") // Marshal value
+
+	return string(marshaledVal) // Return value
+}
+
+/* END INTERNAL METHODS */
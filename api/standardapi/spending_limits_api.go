@@ -0,0 +1,113 @@
+// Package standardapi defines the summercash-wallet-server API.
+package standardapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/SummerCash/summercash-wallet-server/accounts"
+	"github.com/SummerCash/summercash-wallet-server/common"
+)
+
+// spendingLimitsResponse represents a response to a spending limits request.
+type spendingLimitsResponse struct {
+	Limits *accounts.SpendingLimits `json:"spending_limits"` // Spending limits
+
+	Cooldown string `json:"cooldown"` // Duration after which raised limits take effect
+}
+
+/* BEGIN EXPORTED METHODS */
+
+// SetupSpendingLimitRoutes sets up all the spending limit api-related routes.
+func (api *JSONHTTPAPI) SetupSpendingLimitRoutes() error {
+	spendingLimitsAPIRoot := "/api/accounts/:username/spending_limits" // Get spending limits API root path
+
+	api.Router.GET(spendingLimitsAPIRoot, api.GetSpendingLimits) // Set GetSpendingLimits get
+	api.Router.POST(spendingLimitsAPIRoot, api.SetSpendingLimit) // Set SetSpendingLimit post
+
+	return nil // No error occurred, return nil
+}
+
+// GetSpendingLimits handles a GetSpendingLimits request.
+// The account must be authenticated by its "password" (or an access token carrying the read scope).
+func (api *JSONHTTPAPI) GetSpendingLimits(ctx *fasthttp.RequestCtx) {
+	ctx.Response.Header.Set("Access-Control-Allow-Origin", "*")             // Allow CORS
+	ctx.Response.Header.Set("Access-Control-Allow-Headers", "Content-Type") // Allow Content-Type header
+	ctx.Response.Header.Set("Content-Type", "application/json")             // Set content type
+
+	username := ctx.UserValue("username").(string) // Get username
+
+	if err := api.accountsDB(ctx).AuthenticateScoped(username, string(common.GetCtxValue(ctx, "password")), accounts.ScopeRead); err != nil { // Check cannot authenticate
+		logger.Errorf("errored while handling GetSpendingLimits request with username %s: %s", username, err.Error()) // Log error
+
+		panic(err) // Panic
+	}
+
+	account, err := api.AccountsDatabase.QueryAccountByUsername(username) // Query account
+
+	if err != nil { // Check for errors
+		logger.Errorf("errored while handling GetSpendingLimits request with username %s: %s", username, err.Error()) // Log error
+
+		panic(err) // Panic
+	}
+
+	fmt.Fprintf(ctx, newSpendingLimitsResponse(account.SpendingLimits).string()) // Respond with limits
+}
+
+// SetSpendingLimit handles a SetSpendingLimit request.
+// The account must be authenticated by its "password" (or an access token carrying the admin scope).
+// The "limit" (per_transaction or daily) is set to "amount" (0 for unlimited). Lowered limits take effect immediately,
+// while raised limits take effect after the server's cooldown.
+func (api *JSONHTTPAPI) SetSpendingLimit(ctx *fasthttp.RequestCtx) {
+	ctx.Response.Header.Set("Access-Control-Allow-Origin", "*")             // Allow CORS
+	ctx.Response.Header.Set("Access-Control-Allow-Headers", "Content-Type") // Allow Content-Type header
+	ctx.Response.Header.Set("Content-Type", "application/json")             // Set content type
+
+	username := ctx.UserValue("username").(string) // Get username
+
+	amount, err := strconv.ParseFloat(string(common.GetCtxValue(ctx, "amount")), 64) // Parse amount
+
+	if err != nil { // Check for errors
+		logger.Errorf("errored while handling SetSpendingLimit request with username %s: %s", username, err.Error()) // Log error
+
+		panic(err) // Panic
+	}
+
+	limits, err := api.accountsDB(ctx).SetSpendingLimit(username, string(common.GetCtxValue(ctx, "password")), string(common.GetCtxValue(ctx, "limit")), amount) // Set limit
+
+	if err != nil { // Check for errors
+		logger.Errorf("errored while handling SetSpendingLimit request with username %s: %s", username, err.Error()) // Log error
+
+		panic(err) // Panic
+	}
+
+	fmt.Fprintf(ctx, newSpendingLimitsResponse(limits).string()) // Respond with limits
+}
+
+/* END EXPORTED METHODS */
+
+/* BEGIN INTERNAL METHODS */
+
+// newSpendingLimitsResponse initializes a new spending limits response from a given set of limits (nil if unlimited).
+func newSpendingLimitsResponse(limits *accounts.SpendingLimits) *spendingLimitsResponse {
+	if limits == nil { // Check unlimited
+		limits = &accounts.SpendingLimits{} // Init limits
+	}
+
+	return &spendingLimitsResponse{
+		Limits:   limits,                                  // Set limits
+		Cooldown: accounts.SpendingLimitCooldown.String(), // Set cooldown
+	} // Return response
+}
+
+// string marshals a spendingLimitsResponse into a string.
+func (response *spendingLimitsResponse) string() string {
+	marshaledVal, _ := json.MarshalIndent(*response, "", "  ") // Marshal
+
+	return string(marshaledVal) // Return response
+}
+
+/* END INTERNAL METHODS */
@@ -0,0 +1,135 @@
+// Package standardapi defines the summercash-wallet-server API.
+package standardapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/SummerCash/summercash-wallet-server/accounts"
+	"github.com/SummerCash/summercash-wallet-server/common"
+)
+
+// listAddressesResponse represents a response to a ListAddresses request.
+type listAddressesResponse struct {
+	Addresses []*addressResponse `json:"addresses"` // Account addresses, primary first
+
+	Balance float64 `json:"balance"` // Combined balance
+}
+
+// addressResponse represents a single address in a ListAddresses or CreateSubAddress response.
+type addressResponse struct {
+	Name string `json:"name,omitempty"` // Sub-address name (empty for the primary address)
+
+	Address string `json:"address"` // Hex address
+
+	Primary bool `json:"primary"` // Whether or not the address is the account's primary address
+
+	Balance float64 `json:"balance"` // Address balance
+
+	CreatedAt time.Time `json:"created_at,omitempty"` // Creation time (zero for the primary address)
+}
+
+/* BEGIN EXPORTED METHODS */
+
+// SetupSubAddressRoutes sets up all the sub-address api-related routes.
+func (api *JSONHTTPAPI) SetupSubAddressRoutes() error {
+	addressesAPIRoot := "/api/accounts/:username/addresses" // Get addresses API root path
+
+	api.Router.GET(addressesAPIRoot, api.ListAddresses)     // Set ListAddresses get
+	api.Router.POST(addressesAPIRoot, api.CreateSubAddress) // Set CreateSubAddress post
+
+	return nil // No error occurred, return nil
+}
+
+// ListAddresses handles a ListAddresses request.
+func (api *JSONHTTPAPI) ListAddresses(ctx *fasthttp.RequestCtx) {
+	ctx.Response.Header.Set("Access-Control-Allow-Origin", "*")             // Allow CORS
+	ctx.Response.Header.Set("Access-Control-Allow-Headers", "Content-Type") // Allow Content-Type header
+	ctx.Response.Header.Set("Content-Type", "application/json")             // Set content type
+
+	username := ctx.UserValue("username").(string) // Get username
+
+	if err := api.accountsDB(ctx).AuthenticateScoped(username, string(common.GetCtxValue(ctx, "password")), accounts.ScopeRead); err != nil { // Check cannot authenticate
+		logger.Errorf("errored while handling ListAddresses request with username %s: %s", username, err.Error()) // Log error
+
+		panic(err) // Panic
+	}
+
+	account, err := api.AccountsDatabase.QueryAccountByUsername(username) // Query account
+
+	if err != nil { // Check for errors
+		logger.Errorf("errored while handling ListAddresses request with username %s: %s", username, err.Error()) // Log error
+
+		panic(err) // Panic
+	}
+
+	response := &listAddressesResponse{
+		Addresses: []*addressResponse{api.newAddressResponse(&accounts.SubAddress{Address: account.Address})}, // Set primary address
+	} // Init response
+
+	(*response.Addresses[0]).Primary = true // Mark primary
+
+	for _, subAddress := range account.SubAddresses { // Iterate through sub-addresses
+		response.Addresses = append(response.Addresses, api.newAddressResponse(subAddress)) // Append sub-address
+	}
+
+	for _, address := range response.Addresses { // Iterate through addresses
+		(*response).Balance += address.Balance // Add balance
+	}
+
+	fmt.Fprintf(ctx, response.string()) // Respond with addresses
+}
+
+// CreateSubAddress handles a CreateSubAddress request.
+func (api *JSONHTTPAPI) CreateSubAddress(ctx *fasthttp.RequestCtx) {
+	ctx.Response.Header.Set("Access-Control-Allow-Origin", "*")             // Allow CORS
+	ctx.Response.Header.Set("Access-Control-Allow-Headers", "Content-Type") // Allow Content-Type header
+	ctx.Response.Header.Set("Content-Type", "application/json")             // Set content type
+
+	username := ctx.UserValue("username").(string) // Get username
+
+	subAddress, err := api.accountsDB(ctx).CreateSubAddress(username, string(common.GetCtxValue(ctx, "password")), string(common.GetCtxValue(ctx, "name"))) // Create sub-address
+
+	if err != nil { // Check for errors
+		logger.Errorf("errored while handling CreateSubAddress request with username %s: %s", username, err.Error()) // Log error
+
+		panic(err) // Panic
+	}
+
+	fmt.Fprintf(ctx, api.newAddressResponse(subAddress).string()) // Respond with sub-address
+}
+
+/* END EXPORTED METHODS */
+
+/* BEGIN INTERNAL METHODS */
+
+// newAddressResponse initializes a new address response for a given sub-address, calculating its balance.
+func (api *JSONHTTPAPI) newAddressResponse(subAddress *accounts.SubAddress) *addressResponse {
+	balance, _ := api.AccountsDatabase.GetAddressBalance(subAddress.Address).Float64() // Get balance
+
+	return &addressResponse{
+		Name:      subAddress.Name,             // Set name
+		Address:   subAddress.Address.String(), // Set address
+		Balance:   balance,                     // Set balance
+		CreatedAt: subAddress.CreatedAt,        // Set creation time
+	} // Return response
+}
+
+// string marshals a list addresses response into a string.
+func (response *listAddressesResponse) string() string {
+	marshaledVal, _ := json.MarshalIndent(*response, "", "  ") // marshal
+
+	return string(marshaledVal) // Return response
+}
+
+// string marshals an address response into a string.
+func (response *addressResponse) string() string {
+	marshaledVal, _ := json.MarshalIndent(*response, "", "  ") // marshal
+
+	return string(marshaledVal) // Return response
+}
+
+/* END INTERNAL METHODS */
@@ -11,7 +11,8 @@ import (
 	"github.com/NaySoftware/go-fcm"
 	"github.com/valyala/fasthttp"
 
-	summercashCommon "github.com/SummerCash/go-summercash/common"
+	"github.com/SummerCash/go-summercash/types"
+	"github.com/SummerCash/summercash-wallet-server/accounts"
 	"github.com/SummerCash/summercash-wallet-server/common"
 	"github.com/SummerCash/summercash-wallet-server/transactions"
 )
@@ -22,7 +23,9 @@ import (
 func (api *JSONHTTPAPI) SetupTransactionsRoutes() error {
 	transactionsAPIRoot := "/api/transactions" // Get transactions API root path
 
-	api.Router.POST(fmt.Sprintf("%s/NewTransaction", transactionsAPIRoot), api.NewTransaction) // Set NewTransaction post
+	api.Router.POST(fmt.Sprintf("%s/NewTransaction", transactionsAPIRoot), api.NewTransaction)                   // Set NewTransaction post
+	api.Router.POST(fmt.Sprintf("%s/NewUnsignedTransaction", transactionsAPIRoot), api.NewUnsignedTransaction)   // Set NewUnsignedTransaction post
+	api.Router.POST(fmt.Sprintf("%s/SubmitSignedTransaction", transactionsAPIRoot), api.SubmitSignedTransaction) // Set SubmitSignedTransaction post
 
 	return nil // No error occurred, return nil
 }
@@ -33,121 +36,164 @@ func (api *JSONHTTPAPI) NewTransaction(ctx *fasthttp.RequestCtx) {
 	ctx.Response.Header.Set("Access-Control-Allow-Headers", "Content-Type") // Allow Content-Type header
 	ctx.Response.Header.Set("Content-Type", "application/json")             // Set content type
 
-	var recipient summercashCommon.Address // Init recipient buffer
-	var err error                          // Init error buffer
+	if accounts.IsReservedUsername(string(common.GetCtxValue(ctx, "username"))) { // Check wants to send from faucet (or another reserved account)
+		logger.Errorf("user with address %s tried to send tx from reserved account %s", ctx.RemoteAddr().String(), string(common.GetCtxValue(ctx, "username"))) // Log error
 
-	if string(common.GetCtxValue(ctx, "username")) == "faucet" { // Check wants to send from faucet
-		logger.Errorf("user with address %s tried to send tx from faucet account", ctx.RemoteAddr().String()) // Log error
-
-		panic(errors.New("cannot send transaction from faucet wallet")) // Panic
+		panic(errors.New("cannot send transaction from a reserved account")) // Panic
 	}
 
-	if !strings.Contains(string(common.GetCtxValue(ctx, "recipient")), "0x") { // Check is sending to username
-		recipientAccount, err := api.AccountsDatabase.QueryAccountByUsername(string(common.GetCtxValue(ctx, "recipient"))) // Query account
+	amount, err := strconv.ParseFloat(string(common.GetCtxValue(ctx, "amount")), 64) // Parse amount
 
-		if err != nil { // Check for errors
-			logger.Errorf("errored while handling NewTransaction request with username %s: %s", string(common.GetCtxValue(ctx, "username")), err.Error()) // Log error
+	if err != nil { // Check for errors
+		logger.Errorf("errored while handling NewTransaction request with username %s: %s", string(common.GetCtxValue(ctx, "username")), err.Error()) // Log error
 
-			panic(err) // Panic
-		}
+		panic(err) // Panic
+	}
 
-		recipient = recipientAccount.Address // Set address
-	} else {
-		recipient, err = summercashCommon.StringToAddress(string(common.GetCtxValue(ctx, "recipient"))) // Parse recipient
+	transaction, err := transactions.NewTransaction(api.accountsDB(ctx), string(common.GetCtxValue(ctx, "username")), string(common.GetCtxValue(ctx, "password")), string(common.GetCtxValue(ctx, "second_factor")), string(common.GetCtxValue(ctx, "from")), string(common.GetCtxValue(ctx, "recipient")), amount, common.GetCtxValue(ctx, "payload")) // Initialize transaction
 
-		if err != nil { // Check for errors
-			logger.Errorf("errored while handling NewTransaction request with username %s: %s", string(common.GetCtxValue(ctx, "username")), err.Error()) // Log error
+	if err != nil { // Check for errors
+		logger.Errorf("errored while handling NewTransaction request with username %s: %s", string(common.GetCtxValue(ctx, "username")), err.Error()) // Log error
 
-			panic(err) // Panic
-		}
+		panic(err) // Panic
 	}
 
+	recipientAccount, err := api.AccountsDatabase.QueryAccountByAddress(*transaction.Recipient) // Query recipient account (if the recipient is a contact label, it may not have one)
+
+	if !strings.Contains(string(common.GetCtxValue(ctx, "recipient")), "0x") && err == nil { // Check is username or contact recipient
+		api.notifyTransaction(string(common.GetCtxValue(ctx, "username")), recipientAccount, transaction) // Notify sender and recipient
+	}
+
+	fmt.Fprintf(ctx, transaction.String()) // Write tx string value
+}
+
+// NewUnsignedTransaction handles a NewUnsignedTransaction request.
+// The unsigned transaction is returned to be signed outside of the server (e.g. for a watch-only account), and submitted with SubmitSignedTransaction.
+func (api *JSONHTTPAPI) NewUnsignedTransaction(ctx *fasthttp.RequestCtx) {
+	ctx.Response.Header.Set("Access-Control-Allow-Origin", "*")             // Allow CORS
+	ctx.Response.Header.Set("Access-Control-Allow-Headers", "Content-Type") // Allow Content-Type header
+	ctx.Response.Header.Set("Content-Type", "application/json")             // Set content type
+
+	username := string(common.GetCtxValue(ctx, "username")) // Get username
+
 	amount, err := strconv.ParseFloat(string(common.GetCtxValue(ctx, "amount")), 64) // Parse amount
 
 	if err != nil { // Check for errors
-		logger.Errorf("errored while handling NewTransaction request with username %s: %s", string(common.GetCtxValue(ctx, "username")), err.Error()) // Log error
+		logger.Errorf("errored while handling NewUnsignedTransaction request with username %s: %s", username, err.Error()) // Log error
 
 		panic(err) // Panic
 	}
 
-	transaction, err := transactions.NewTransaction(api.AccountsDatabase, string(common.GetCtxValue(ctx, "username")), string(common.GetCtxValue(ctx, "password")), &recipient, amount, common.GetCtxValue(ctx, "payload")) // Initialize transaction
+	transaction, err := transactions.NewUnsignedTransaction(api.accountsDB(ctx), username, string(common.GetCtxValue(ctx, "password")), string(common.GetCtxValue(ctx, "from")), string(common.GetCtxValue(ctx, "recipient")), amount, common.GetCtxValue(ctx, "payload")) // Initialize transaction
 
 	if err != nil { // Check for errors
-		logger.Errorf("errored while handling NewTransaction request with username %s: %s", string(common.GetCtxValue(ctx, "username")), err.Error()) // Log error
+		logger.Errorf("errored while handling NewUnsignedTransaction request with username %s: %s", username, err.Error()) // Log error
 
 		panic(err) // Panic
 	}
 
-	if !strings.Contains(string(common.GetCtxValue(ctx, "recipient")), "0x") && os.Getenv("FCM_KEY") != "" { // Check is username recipient
-		recipientAccount, err := api.AccountsDatabase.QueryAccountByUsername(string(common.GetCtxValue(ctx, "recipient"))) // Query account
+	fmt.Fprintf(ctx, transaction.String()) // Write tx string value
+}
 
-		if err != nil { // Check for errors
-			logger.Errorf("errored while handling NewTransaction request with username %s: %s", string(common.GetCtxValue(ctx, "username")), err.Error()) // Log error
+// SubmitSignedTransaction handles a SubmitSignedTransaction request.
+// The signed transaction may be given as a JSON object, or as a string holding its JSON encoding.
+func (api *JSONHTTPAPI) SubmitSignedTransaction(ctx *fasthttp.RequestCtx) {
+	ctx.Response.Header.Set("Access-Control-Allow-Origin", "*")             // Allow CORS
+	ctx.Response.Header.Set("Access-Control-Allow-Headers", "Content-Type") // Allow Content-Type header
+	ctx.Response.Header.Set("Content-Type", "application/json")             // Set content type
 
-			panic(err) // Panic
-		}
+	username := string(common.GetCtxValue(ctx, "username")) // Get username
 
-		amount, _ := transaction.Amount.Float64() // Get tx amount
+	transaction, err := transactions.ParseSignedTransaction(jsonValueFromCtx(ctx, "transaction")) // Parse transaction
 
-		data := map[string]string{
-			"msg": "New Transaction",
-			"sum": fmt.Sprintf("Received %f SMC from %s.", amount, transaction.Sender.String()),
-		}
+	if err != nil { // Check for errors
+		logger.Errorf("errored while handling SubmitSignedTransaction request with username %s: %s", username, err.Error()) // Log error
 
-		if api.WebsocketManager != nil && api.UseWebsocket { // Check uses websockets
-			recipient := string(common.GetCtxValue(ctx, "recipient")) // Get recipient username
+		panic(err) // Panic
+	}
 
-			sender := string(common.GetCtxValue(ctx, "username")) // Get sender username
+	err = transactions.SubmitSignedTransaction(api.accountsDB(ctx), username, string(common.GetCtxValue(ctx, "password")), transaction) // Submit transaction
 
-			if !strings.Contains(recipient, "0x") { // Check recipient has username
-				recipientBalance, err := api.AccountsDatabase.GetUserBalance(recipient) // Calculate recipient balance
+	if err != nil { // Check for errors
+		logger.Errorf("errored while handling SubmitSignedTransaction request with username %s: %s", username, err.Error()) // Log error
 
-				if err != nil { // Check for errors
-					logger.Errorf("errored while handling NewTransaction request with username %s: %s", string(common.GetCtxValue(ctx, "username")), err.Error()) // Log error
+		panic(err) // Panic
+	}
 
-					panic(err) // Panic
-				}
+	if recipientAccount, err := api.AccountsDatabase.QueryAccountByAddress(*transaction.Recipient); err == nil { // Check recipient has account
+		api.notifyTransaction(username, recipientAccount, transaction) // Notify sender and recipient
+	}
 
-				recipientFloatBalance, _ := recipientBalance.Float64() // Get float value
+	fmt.Fprintf(ctx, transaction.String()) // Write tx string value
+}
 
-				payload := []byte(fmt.Sprintf("%f:%s", recipientFloatBalance, transaction.String())) // Initialize payload
+/* END EXPORTED METHODS */
 
-				for _, session := range api.WebsocketManager.Clients[recipient] { // Iterate through recipient WS sessions
-					session.Write(payload) // Write payload
-				}
-			}
+/* BEGIN INTERNAL METHODS */
+
+// notifyTransaction pushes a given transaction to the websocket sessions of its sender and recipient, and sends the recipient
+// a push notification, if a Firebase Cloud Messaging key is configured.
+func (api *JSONHTTPAPI) notifyTransaction(sender string, recipientAccount *accounts.Account, transaction *types.Transaction) {
+	if os.Getenv("FCM_KEY") == "" { // Check cannot notify
+		return // Nothing to do
+	}
+
+	amount, _ := transaction.Amount.Float64() // Get tx amount
 
-			if !strings.Contains(sender, "0x") { // Check sender has username
-				senderBalance, err := api.AccountsDatabase.GetUserBalance(sender) // Calculate sender balance
+	data := map[string]string{
+		"msg": "New Transaction",
+		"sum": fmt.Sprintf("Received %f SMC from %s.", amount, transaction.Sender.String()),
+	}
+
+	if api.WebsocketManager != nil && api.UseWebsocket { // Check uses websockets
+		recipient := recipientAccount.Name // Get recipient username
 
-				if err != nil { // Check for errors
-					logger.Errorf("errored while handling NewTransaction request with username %s: %s", string(common.GetCtxValue(ctx, "username")), err.Error()) // Log error
+		if !strings.Contains(recipient, "0x") { // Check recipient has username
+			recipientBalance, err := api.AccountsDatabase.GetUserBalance(recipient) // Calculate recipient balance
 
-					panic(err) // Panic
-				}
+			if err != nil { // Check for errors
+				logger.Errorf("errored while notifying transaction %s with username %s: %s", transaction.Hash.String(), sender, err.Error()) // Log error
+
+				panic(err) // Panic
+			}
 
-				senderFloatBalance, _ := senderBalance.Float64() // Get float value
+			recipientFloatBalance, _ := recipientBalance.Float64() // Get float value
 
-				payload := []byte(fmt.Sprintf("%f:%s", senderFloatBalance, transaction.String())) // Initialize payload
+			payload := []byte(fmt.Sprintf("%f:%s", recipientFloatBalance, transaction.String())) // Initialize payload
 
-				for _, session := range api.WebsocketManager.Clients[sender] { // Iterate through sender WS sessions
-					session.Write(payload) // Write payload
-				}
+			for _, session := range api.WebsocketManager.Clients[recipient] { // Iterate through recipient WS sessions
+				session.Write(payload) // Write payload
 			}
 		}
 
-		client := fcm.NewFcmClient(os.Getenv("FCM_KEY")) // Init client
+		if !strings.Contains(sender, "0x") { // Check sender has username
+			senderBalance, err := api.AccountsDatabase.GetUserBalance(sender) // Calculate sender balance
 
-		client.NewFcmRegIdsMsg(recipientAccount.FcmTokens, data) // Init message
+			if err != nil { // Check for errors
+				logger.Errorf("errored while notifying transaction %s with username %s: %s", transaction.Hash.String(), sender, err.Error()) // Log error
 
-		_, err = client.Send() // Send notification
+				panic(err) // Panic
+			}
+
+			senderFloatBalance, _ := senderBalance.Float64() // Get float value
 
-		if err != nil { // Check for errors
-			logger.Errorf("errored while handling NewTransaction request with username %s: %s", string(common.GetCtxValue(ctx, "username")), err.Error()) // Log error
+			payload := []byte(fmt.Sprintf("%f:%s", senderFloatBalance, transaction.String())) // Initialize payload
+
+			for _, session := range api.WebsocketManager.Clients[sender] { // Iterate through sender WS sessions
+				session.Write(payload) // Write payload
+			}
 		}
 	}
 
-	fmt.Fprintf(ctx, transaction.String()) // Write tx string value
+	client := fcm.NewFcmClient(os.Getenv("FCM_KEY")) // Init client
+
+	client.NewFcmRegIdsMsg(recipientAccount.FcmTokens, data) // Init message
+
+	_, err := client.Send() // Send notification
+
+	if err != nil { // Check for errors
+		logger.Errorf("errored while notifying transaction %s with username %s: %s", transaction.Hash.String(), sender, err.Error()) // Log error
+	}
 }
 
-/* END EXPORTED METHODS */
+/* END INTERNAL METHODS */
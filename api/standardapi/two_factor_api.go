@@ -0,0 +1,124 @@
+// Package standardapi defines the summercash-wallet-server API.
+package standardapi
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/SummerCash/summercash-wallet-server/common"
+)
+
+// backupCodesResponse represents a response to a ConfirmTwoFactorEnrollment or RegenerateBackupCodes request.
+type backupCodesResponse struct {
+	BackupCodes []string `json:"backup_codes"` // One-time backup codes; shown only once
+}
+
+/* BEGIN EXPORTED METHODS */
+
+// SetupTwoFactorRoutes sets up all the two-factor authentication api-related routes.
+func (api *JSONHTTPAPI) SetupTwoFactorRoutes() error {
+	twoFactorAPIRoot := "/api/accounts/:username/2fa" // Get two-factor API root path
+
+	api.Router.POST(twoFactorAPIRoot, api.BeginTwoFactorEnrollment)                              // Set BeginTwoFactorEnrollment post
+	api.Router.POST(fmt.Sprintf("%s/confirm", twoFactorAPIRoot), api.ConfirmTwoFactorEnrollment) // Set ConfirmTwoFactorEnrollment post
+	api.Router.POST(fmt.Sprintf("%s/backup_codes", twoFactorAPIRoot), api.RegenerateBackupCodes) // Set RegenerateBackupCodes post
+	api.Router.DELETE(twoFactorAPIRoot, api.DisableTwoFactor)                                    // Set DisableTwoFactor delete
+
+	return nil // No error occurred, return nil
+}
+
+// BeginTwoFactorEnrollment handles a BeginTwoFactorEnrollment request.
+func (api *JSONHTTPAPI) BeginTwoFactorEnrollment(ctx *fasthttp.RequestCtx) {
+	ctx.Response.Header.Set("Access-Control-Allow-Origin", "*")             // Allow CORS
+	ctx.Response.Header.Set("Access-Control-Allow-Headers", "Content-Type") // Allow Content-Type header
+	ctx.Response.Header.Set("Content-Type", "application/json")             // Set content type
+	ctx.Response.Header.Set("Cache-Control", "no-store")                    // Never cache the TOTP secret
+
+	username := ctx.UserValue("username").(string) // Get username
+
+	enrollment, err := api.accountsDB(ctx).BeginTwoFactorEnrollment(username, string(common.GetCtxValue(ctx, "password"))) // Begin enrollment
+
+	if err != nil { // Check for errors
+		logger.Errorf("errored while handling BeginTwoFactorEnrollment request with username %s: %s", username, err.Error()) // Log error
+
+		panic(err) // Panic
+	}
+
+	marshaledVal, _ := json.MarshalIndent(enrollment, "", "  ") // Marshal enrollment
+
+	fmt.Fprintf(ctx, string(marshaledVal)) // Respond with enrollment
+}
+
+// ConfirmTwoFactorEnrollment handles a ConfirmTwoFactorEnrollment request.
+func (api *JSONHTTPAPI) ConfirmTwoFactorEnrollment(ctx *fasthttp.RequestCtx) {
+	ctx.Response.Header.Set("Access-Control-Allow-Origin", "*")             // Allow CORS
+	ctx.Response.Header.Set("Access-Control-Allow-Headers", "Content-Type") // Allow Content-Type header
+	ctx.Response.Header.Set("Content-Type", "application/json")             // Set content type
+	ctx.Response.Header.Set("Cache-Control", "no-store")                    // Never cache backup codes
+
+	username := ctx.UserValue("username").(string) // Get username
+
+	backupCodes, err := api.accountsDB(ctx).ConfirmTwoFactorEnrollment(username, string(common.GetCtxValue(ctx, "password")), string(common.GetCtxValue(ctx, "second_factor"))) // Confirm enrollment
+
+	if err != nil { // Check for errors
+		logger.Errorf("errored while handling ConfirmTwoFactorEnrollment request with username %s: %s", username, err.Error()) // Log error
+
+		panic(err) // Panic
+	}
+
+	fmt.Fprintf(ctx, (&backupCodesResponse{BackupCodes: backupCodes}).string()) // Respond with backup codes
+}
+
+// RegenerateBackupCodes handles a RegenerateBackupCodes request.
+func (api *JSONHTTPAPI) RegenerateBackupCodes(ctx *fasthttp.RequestCtx) {
+	ctx.Response.Header.Set("Access-Control-Allow-Origin", "*")             // Allow CORS
+	ctx.Response.Header.Set("Access-Control-Allow-Headers", "Content-Type") // Allow Content-Type header
+	ctx.Response.Header.Set("Content-Type", "application/json")             // Set content type
+	ctx.Response.Header.Set("Cache-Control", "no-store")                    // Never cache backup codes
+
+	username := ctx.UserValue("username").(string) // Get username
+
+	backupCodes, err := api.accountsDB(ctx).RegenerateBackupCodes(username, string(common.GetCtxValue(ctx, "password")), string(common.GetCtxValue(ctx, "second_factor"))) // Regenerate backup codes
+
+	if err != nil { // Check for errors
+		logger.Errorf("errored while handling RegenerateBackupCodes request with username %s: %s", username, err.Error()) // Log error
+
+		panic(err) // Panic
+	}
+
+	fmt.Fprintf(ctx, (&backupCodesResponse{BackupCodes: backupCodes}).string()) // Respond with backup codes
+}
+
+// DisableTwoFactor handles a DisableTwoFactor request.
+func (api *JSONHTTPAPI) DisableTwoFactor(ctx *fasthttp.RequestCtx) {
+	ctx.Response.Header.Set("Access-Control-Allow-Origin", "*")             // Allow CORS
+	ctx.Response.Header.Set("Access-Control-Allow-Headers", "Content-Type") // Allow Content-Type header
+	ctx.Response.Header.Set("Content-Type", "application/json")             // Set content type
+
+	username := ctx.UserValue("username").(string) // Get username
+
+	err := api.accountsDB(ctx).DisableTwoFactor(username, string(common.GetCtxValue(ctx, "password")), string(common.GetCtxValue(ctx, "second_factor"))) // Disable two-factor authentication
+
+	if err != nil { // Check for errors
+		logger.Errorf("errored while handling DisableTwoFactor request with username %s: %s", username, err.Error()) // Log error
+
+		panic(err) // Panic
+	}
+
+	fmt.Fprintf(ctx, `{"message": "two-factor authentication disabled successfully"}`) // Respond with success
+}
+
+/* END EXPORTED METHODS */
+
+/* BEGIN INTERNAL METHODS */
+
+// string marshals a backupCodesResponse into a JSON-formatted string.
+func (response *backupCodesResponse) string() string {
+	marshaledVal, _ := json.MarshalIndent(*response, "", "  ") // Marshal value
+
+	return string(marshaledVal) // Return value
+}
+
+/* END INTERNAL METHODS */
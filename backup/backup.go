@@ -0,0 +1,460 @@
+// Package backup implements online backups of the accounts database and its keystores, along with their restoration.
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/juju/loggo"
+
+	summercashCommon "github.com/SummerCash/go-summercash/common"
+	"github.com/SummerCash/summercash-wallet-server/accounts"
+	"github.com/SummerCash/summercash-wallet-server/common"
+	"github.com/SummerCash/summercash-wallet-server/faucet"
+	"github.com/SummerCash/summercash-wallet-server/storage"
+)
+
+var (
+	// ErrInvalidArchive is an error definition describing a malformed or tampered backup archive.
+	ErrInvalidArchive = errors.New("invalid backup archive")
+
+	// logger is the backup package logger.
+	logger = loggo.GetLogger("backup")
+)
+
+const (
+	// manifestName is the name of the manifest entry in a backup archive.
+	manifestName = "manifest.json"
+
+	// dbName is the name of the accounts database dump entry in a backup archive.
+	// Databases are archived as a logical dump, so that an archive can be restored into any storage backend.
+	dbName = "db/accounts.jsonl"
+)
+
+// Manifest describes the contents of a backup archive.
+type Manifest struct {
+	CreatedAt time.Time `json:"created_at"` // Backup time
+
+	SchemaVersion uint64 `json:"schema_version"` // Schema version of the backed up database
+
+	Files []*File `json:"files"` // Archived files
+}
+
+// File describes a single file in a backup archive.
+type File struct {
+	Name string `json:"name"` // Path of the file, relative to the data directory
+
+	Size int64 `json:"size"` // File size in bytes
+
+	SHA256 string `json:"sha256"` // Hex-encoded SHA-256 checksum of the file
+}
+
+/* BEGIN EXPORTED METHODS */
+
+// Create writes a gzipped tar archive holding a consistent copy of the accounts database, the faucet keystore,
+// and the keystores of every account (and sub-address) in the database to a given writer.
+// The database is copied within a single read transaction, so the server may keep serving requests while a backup is made.
+func Create(db *accounts.DB, w io.Writer) (*Manifest, error) {
+	gzipWriter := gzip.NewWriter(w) // Init gzip writer
+
+	tarWriter := tar.NewWriter(gzipWriter) // Init tar writer
+
+	manifest := &Manifest{CreatedAt: time.Now().UTC()} // Init manifest
+
+	err := db.Snapshot(func(snapshot *accounts.Snapshot) error {
+		(*manifest).SchemaVersion = snapshot.SchemaVersion // Set schema version
+
+		file, err := archiveDump(tarWriter, snapshot.Tx) // Archive db
+
+		if err != nil { // Check for errors
+			return err // Return found error
+		}
+
+		(*manifest).Files = append(manifest.Files, file) // Add db to manifest
+
+		keystores := []string{faucet.KeystorePath()} // Init keystore paths
+
+		for _, account := range snapshot.Accounts { // Iterate through accounts
+			for _, address := range account.Addresses() { // Iterate through the account's primary address and sub-addresses
+				keystores = append(keystores, accounts.KeystorePath(address)) // Add address keystore
+			}
+
+			keystores = append(keystores, legacyKeystorePath(account)) // Add legacy keystore
+		}
+
+		for _, keystore := range keystores { // Iterate through keystores
+			file, err := archiveFile(tarWriter, keystore) // Archive keystore
+
+			if err != nil { // Check for errors
+				return err // Return found error
+			}
+
+			if file != nil { // Check keystore exists
+				(*manifest).Files = append(manifest.Files, file) // Add keystore to manifest
+			}
+		}
+
+		return nil // No error occurred, return nil
+	}) // Archive snapshot
+
+	if err != nil { // Check for errors
+		return nil, err // Return found error
+	}
+
+	manifestBytes, err := json.MarshalIndent(manifest, "", "  ") // Marshal manifest
+
+	if err != nil { // Check for errors
+		return nil, err // Return found error
+	}
+
+	_, err = writeEntry(tarWriter, manifestName, int64(len(manifestBytes)), func(entry io.Writer) error {
+		_, err := entry.Write(manifestBytes) // Write manifest
+
+		return err // Return error
+	}) // Archive manifest
+
+	if err != nil { // Check for errors
+		return nil, err // Return found error
+	}
+
+	if err = tarWriter.Close(); err != nil { // Flush tar
+		return nil, err // Return found error
+	}
+
+	return manifest, gzipWriter.Close() // Flush gzip
+}
+
+// CreateFile writes a backup archive (see Create) to a new, timestamped file in a given directory.
+// Returns the path of the archive.
+func CreateFile(db *accounts.DB, dir string) (string, *Manifest, error) {
+	if err := common.CreateDirIfDoesNotExit(dir); err != nil { // Create backup dir
+		return "", nil, err // Return found error
+	}
+
+	archivePath := filepath.Join(filepath.FromSlash(dir), fmt.Sprintf("backup_%s.tar.gz", time.Now().UTC().Format("2006-01-02_15-04-05"))) // Get archive path
+
+	archive, err := os.OpenFile(archivePath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600) // Create archive
+
+	if err != nil { // Check for errors
+		return "", nil, err // Return found error
+	}
+
+	manifest, err := Create(db, archive) // Write backup
+
+	if closeErr := archive.Close(); err == nil { // Check no write error
+		err = closeErr // Set close error
+	}
+
+	if err != nil { // Check for errors
+		os.Remove(archivePath) // Remove partial archive
+
+		return "", nil, err // Return found error
+	}
+
+	logger.Infof("wrote backup with %d files to %s", len(manifest.Files), archivePath) // Log backup
+
+	return archivePath, manifest, nil // Return archive path
+}
+
+// Restore restores the accounts database and keystores from the backup archive at a given path.
+// The archive is fully extracted and verified against its manifest, and the database's integrity is checked,
+// before any file is swapped in. Replaced files are kept alongside their restored counterparts with a .pre-restore suffix.
+// The server must not be running while a backup is restored.
+func Restore(archivePath string) (*Manifest, error) {
+	stagingDir := filepath.Join(common.DataDir, fmt.Sprintf("restore_%d", time.Now().UnixNano())) // Get staging dir
+
+	if err := common.CreateDirIfDoesNotExit(stagingDir); err != nil { // Create staging dir
+		return nil, err // Return found error
+	}
+
+	defer os.RemoveAll(stagingDir) // Remove staging dir
+
+	manifest, err := extract(archivePath, stagingDir) // Extract archive
+
+	if err != nil { // Check for errors
+		return nil, err // Return found error
+	}
+
+	stagedDB, err := loadDump(stagingDir) // Load db dump into a new db
+
+	if err != nil { // Check for errors
+		return nil, err // Return found error
+	}
+
+	if _, err = accounts.CheckDBFile(accounts.Backend, stagedDB); err != nil { // Check db integrity
+		return nil, err // Return found error
+	}
+
+	for _, file := range manifest.Files { // Iterate through files
+		source, target := filepath.Join(stagingDir, filepath.FromSlash(file.Name)), filepath.Join(common.DataDir, filepath.FromSlash(file.Name)) // Get restore paths
+
+		if file.Name == dbName { // Check is db
+			source, target = stagedDB, accounts.DBPath(accounts.Backend) // Restore loaded db instead of dump
+		}
+
+		if err = common.CreateDirIfDoesNotExit(filepath.Dir(target)); err != nil { // Create target dir
+			return nil, err // Return found error
+		}
+
+		if err = os.Rename(target, target+".pre-restore"); err != nil && !os.IsNotExist(err) { // Keep replaced file
+			return nil, err // Return found error
+		}
+
+		if err = os.Rename(source, target); err != nil { // Swap in restored file
+			return nil, err // Return found error
+		}
+	}
+
+	logger.Infof("restored %d files from %s", len(manifest.Files), archivePath) // Log restore
+
+	return manifest, nil // Return manifest
+}
+
+/* END EXPORTED METHODS */
+
+/* BEGIN INTERNAL METHODS */
+
+// extract extracts the backup archive at a given path into a given directory, and verifies each file against the archive manifest.
+func extract(archivePath string, dir string) (*Manifest, error) {
+	archive, err := os.Open(archivePath) // Open archive
+
+	if err != nil { // Check for errors
+		return nil, err // Return found error
+	}
+
+	defer archive.Close() // Close archive
+
+	gzipReader, err := gzip.NewReader(archive) // Init gzip reader
+
+	if err != nil { // Check for errors
+		return nil, err // Return found error
+	}
+
+	tarReader := tar.NewReader(gzipReader) // Init tar reader
+
+	var manifest *Manifest // Init manifest buffer
+
+	checksums := make(map[string]string) // Init extracted file checksums
+
+	for {
+		header, err := tarReader.Next() // Read header
+
+		if err == io.EOF { // Check done
+			break // Break
+		} else if err != nil { // Check for errors
+			return nil, err // Return found error
+		}
+
+		if header.Name == manifestName { // Check is manifest
+			manifest = &Manifest{} // Init manifest
+
+			if err = json.NewDecoder(tarReader).Decode(manifest); err != nil { // Decode manifest
+				return nil, err // Return found error
+			}
+
+			continue // Next entry
+		}
+
+		if header.Typeflag != tar.TypeReg || !validName(header.Name) { // Check unexpected entry
+			return nil, ErrInvalidArchive // Return error
+		}
+
+		target := filepath.Join(dir, filepath.FromSlash(header.Name)) // Get extraction path
+
+		if err = common.CreateDirIfDoesNotExit(filepath.Dir(target)); err != nil { // Create dir
+			return nil, err // Return found error
+		}
+
+		file, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600) // Create file
+
+		if err != nil { // Check for errors
+			return nil, err // Return found error
+		}
+
+		hash := sha256.New() // Init hash
+
+		_, err = io.Copy(io.MultiWriter(file, hash), tarReader) // Extract file
+
+		file.Close() // Close file
+
+		if err != nil { // Check for errors
+			return nil, err // Return found error
+		}
+
+		checksums[header.Name] = hex.EncodeToString(hash.Sum(nil)) // Set checksum
+	}
+
+	if manifest == nil || len(manifest.Files) != len(checksums) { // Check missing manifest or unlisted files
+		return nil, ErrInvalidArchive // Return error
+	}
+
+	hasDB := false // Init has db
+
+	for _, file := range manifest.Files { // Iterate through manifest files
+		if checksum, ok := checksums[file.Name]; !ok || checksum != file.SHA256 { // Check missing or corrupted
+			logger.Errorf("checksum mismatch for archived file %s", file.Name) // Log mismatch
+
+			return nil, ErrInvalidArchive // Return error
+		}
+
+		hasDB = hasDB || file.Name == dbName // Check is db
+	}
+
+	if !hasDB { // Check no db
+		return nil, ErrInvalidArchive // Return error
+	}
+
+	return manifest, nil // Return manifest
+}
+
+// writeEntry writes a single file entry of a given size to a tar archive, using a given function to produce its contents.
+func writeEntry(tarWriter *tar.Writer, name string, size int64, write func(entry io.Writer) error) (*File, error) {
+	err := tarWriter.WriteHeader(&tar.Header{
+		Name:    name,             // Set name
+		Mode:    0600,             // Set mode
+		Size:    size,             // Set size
+		ModTime: time.Now().UTC(), // Set mod time
+		Format:  tar.FormatPAX,    // Set format
+	}) // Write header
+
+	if err != nil { // Check for errors
+		return nil, err // Return found error
+	}
+
+	hash := sha256.New() // Init hash
+
+	if err = write(io.MultiWriter(tarWriter, hash)); err != nil { // Write contents
+		return nil, err // Return found error
+	}
+
+	return &File{
+		Name:   name,                              // Set name
+		Size:   size,                              // Set size
+		SHA256: hex.EncodeToString(hash.Sum(nil)), // Set checksum
+	}, nil // Return file
+}
+
+// archiveFile writes the file at a given path to a tar archive, under its path relative to the data directory.
+// Returns nil if the file does not exist.
+func archiveFile(tarWriter *tar.Writer, filePath string) (*File, error) {
+	file, err := os.Open(filePath) // Open file
+
+	if os.IsNotExist(err) { // Check does not exist
+		return nil, nil // Nothing to archive
+	} else if err != nil { // Check for errors
+		return nil, err // Return found error
+	}
+
+	defer file.Close() // Close file
+
+	info, err := file.Stat() // Stat file
+
+	if err != nil { // Check for errors
+		return nil, err // Return found error
+	}
+
+	name, err := filepath.Rel(common.DataDir, filePath) // Get relative path
+
+	if err != nil { // Check for errors
+		return nil, err // Return found error
+	}
+
+	return writeEntry(tarWriter, filepath.ToSlash(name), info.Size(), func(entry io.Writer) error {
+		_, err := io.Copy(entry, file) // Copy file
+
+		return err // Return error
+	}) // Archive file
+}
+
+// archiveDump writes a logical dump of the accounts database, as seen by a given transaction, to a tar archive.
+func archiveDump(tarWriter *tar.Writer, tx storage.Tx) (*File, error) {
+	dump, err := ioutil.TempFile("", "smc_db_dump") // Create temp file, as the dump size must be known before it is archived
+
+	if err != nil { // Check for errors
+		return nil, err // Return found error
+	}
+
+	defer os.Remove(dump.Name()) // Remove temp file
+	defer dump.Close()           // Close temp file
+
+	if err = storage.Dump(tx, dump); err != nil { // Dump db
+		return nil, err // Return found error
+	}
+
+	size, err := dump.Seek(0, io.SeekCurrent) // Get dump size
+
+	if err != nil { // Check for errors
+		return nil, err // Return found error
+	}
+
+	if _, err = dump.Seek(0, io.SeekStart); err != nil { // Rewind
+		return nil, err // Return found error
+	}
+
+	return writeEntry(tarWriter, dbName, size, func(entry io.Writer) error {
+		_, err := io.Copy(entry, dump) // Copy dump
+
+		return err // Return error
+	}) // Archive dump
+}
+
+// loadDump loads the extracted accounts database dump in a given staging directory into a new database of the configured storage backend.
+// Returns the path of the new database.
+func loadDump(stagingDir string) (string, error) {
+	dump, err := os.Open(filepath.Join(stagingDir, filepath.FromSlash(dbName))) // Open dump
+
+	if err != nil { // Check for errors
+		return "", err // Return found error
+	}
+
+	defer dump.Close() // Close dump
+
+	dbPath := filepath.Join(stagingDir, filepath.Base(accounts.DBPath(accounts.Backend))) // Get staged db path
+
+	store, err := storage.Open(accounts.Backend, dbPath) // Create db
+
+	if err != nil { // Check for errors
+		return "", err // Return found error
+	}
+
+	err = store.Update(func(tx storage.Tx) error {
+		return storage.Load(tx, dump) // Load dump
+	}) // Load db
+
+	if closeErr := store.Close(); err == nil { // Check no load error
+		err = closeErr // Set close error
+	}
+
+	if err != nil { // Check for errors
+		logger.Errorf("failed to load archived db dump: %s", err.Error()) // Log error
+
+		return "", ErrInvalidArchive // Return error
+	}
+
+	return dbPath, nil // Return db path
+}
+
+// validName checks that an archived file name is a clean, relative path that stays within the data directory.
+func validName(name string) bool {
+	return name != "" && !path.IsAbs(name) && path.Clean(name) == name && name != ".." && !strings.HasPrefix(name, "../") // Check valid
+}
+
+// legacyKeystorePath gets the path of the plaintext go-summercash keystore of a given account, which is present
+// until the account's private key is encrypted on its next login.
+func legacyKeystorePath(account *accounts.Account) string {
+	return filepath.FromSlash(fmt.Sprintf("%s/keystore/account_%s.json", summercashCommon.DataDir, account.Address.String())) // Return path
+}
+
+/* END INTERNAL METHODS */
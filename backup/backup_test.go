@@ -0,0 +1,232 @@
+// Package backup implements online backups of the accounts database and its keystores, along with their restoration.
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	summercashCommon "github.com/SummerCash/go-summercash/common"
+	"github.com/SummerCash/go-summercash/config"
+	"github.com/SummerCash/summercash-wallet-server/accounts"
+	"github.com/SummerCash/summercash-wallet-server/common"
+	"github.com/SummerCash/summercash-wallet-server/crypto"
+	"github.com/SummerCash/summercash-wallet-server/faucet"
+)
+
+/* BEGIN EXPORTED METHODS TESTS */
+
+// TestRestore tests the functionality of the CreateFile() and Restore() helper methods.
+func TestRestore(t *testing.T) {
+	setTestDataDir(t) // Use temp data dir
+
+	db, err := accounts.OpenDB() // Open db
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	account, err := db.AddNewAccount("test", "test", "0x0000000000000000000000000000000000000001") // Add account
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	if err = common.CreateDirIfDoesNotExit(filepath.Dir(accounts.KeystorePath(account.Address))); err != nil { // Create keystore dir
+		t.Fatal(err) // Panic
+	}
+
+	if err = ioutil.WriteFile(accounts.KeystorePath(account.Address), []byte("keystore"), 0600); err != nil { // Write mock account keystore
+		t.Fatal(err) // Panic
+	}
+
+	if err = (&config.ChainConfig{}).WriteToMemory(); err != nil { // Write chain config, which account chains are initialized from
+		t.Fatal(err) // Panic
+	}
+
+	if _, err = db.CreateNewAccount("wallet", "test"); err != nil { // Create account holding its private key
+		t.Fatal(err) // Panic
+	}
+
+	subAddress, err := db.CreateSubAddress("wallet", "test", "savings") // Create sub-address
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	subKeystore, err := ioutil.ReadFile(accounts.KeystorePath(subAddress.Address)) // Read sub-address keystore
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	privateKey, err := ecdsa.GenerateKey(elliptic.P521(), rand.Reader) // Generate faucet key
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	if err = faucet.WriteKeystore(privateKey, []byte("passphrase")); err != nil { // Write faucet keystore
+		t.Fatal(err) // Panic
+	}
+
+	archivePath, manifest, err := CreateFile(db, filepath.Join(common.DataDir, "backups")) // Write backup
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	if len(manifest.Files) != 5 { // Check missing files
+		t.Fatalf("expected db and 4 keystores (faucet, test, wallet and its sub-address) in backup, got %d files", len(manifest.Files)) // Panic
+	}
+
+	if err = db.DeleteAccount("test", "test", ""); err != nil { // Delete account
+		t.Fatal(err) // Panic
+	}
+
+	os.Remove(accounts.KeystorePath(account.Address))    // Delete keystore
+	os.Remove(accounts.KeystorePath(subAddress.Address)) // Delete sub-address keystore
+
+	db.CloseDB() // Close db
+
+	if _, err = Restore(archivePath); err != nil { // Restore
+		t.Fatal(err) // Panic
+	}
+
+	db, err = accounts.OpenDB() // Reopen db
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	defer db.CloseDB() // Close db
+
+	if _, err = db.QueryAccountByUsername("test"); err != nil { // Check account restored
+		t.Fatal(err) // Panic
+	}
+
+	if keystore, err := ioutil.ReadFile(accounts.KeystorePath(account.Address)); err != nil || string(keystore) != "keystore" { // Check keystore restored
+		t.Fatal("account keystore should have been restored") // Panic
+	}
+
+	if keystore, err := ioutil.ReadFile(accounts.KeystorePath(subAddress.Address)); err != nil || !bytes.Equal(keystore, subKeystore) { // Check sub-address keystore restored
+		t.Fatal("sub-address keystore should have been restored") // Panic
+	}
+
+	if _, _, err = db.UnlockAddressPrivateKey("wallet", "savings", "test", accounts.OperationSend, ""); err != nil { // Check restored sub-address keystore unlocks
+		t.Fatal(err) // Panic
+	}
+
+	if _, err = faucet.ReadKeystore([]byte("passphrase")); err != nil { // Check faucet keystore restored
+		t.Fatal(err) // Panic
+	}
+}
+
+// TestRestoreTampered tests that Restore() refuses an archive whose contents do not match its manifest.
+func TestRestoreTampered(t *testing.T) {
+	setTestDataDir(t) // Use temp data dir
+
+	db, err := accounts.OpenDB() // Open db
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	archive := new(bytes.Buffer) // Init archive buffer
+
+	_, err = Create(db, archive) // Write backup
+
+	db.CloseDB() // Close db
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	tamperedPath := filepath.Join(common.DataDir, "tampered.tar.gz") // Get tampered archive path
+
+	if err = ioutil.WriteFile(tamperedPath, tamper(t, archive.Bytes()), 0600); err != nil { // Write tampered archive
+		t.Fatal(err) // Panic
+	}
+
+	if _, err = Restore(tamperedPath); err != ErrInvalidArchive { // Restore
+		t.Fatalf("expected ErrInvalidArchive, got %v", err) // Panic
+	}
+}
+
+/* END EXPORTED METHODS TESTS */
+
+/* BEGIN TEST HELPERS */
+
+// setTestDataDir points the data directories at a temporary directory for the duration of a test.
+func setTestDataDir(t *testing.T) {
+	dir := t.TempDir() // Make temp dir (removed after test)
+
+	dataDir, dbDir, summercashDataDir, scryptN := common.DataDir, common.DBDir, summercashCommon.DataDir, crypto.DefaultScryptN // Get current settings
+
+	common.DataDir, common.DBDir, summercashCommon.DataDir = dir, filepath.Join(dir, "db"), dir // Set data dirs
+	crypto.DefaultScryptN = 1 << 10                                                             // Use cheap scrypt cost
+
+	t.Cleanup(func() {
+		common.DataDir, common.DBDir, summercashCommon.DataDir, crypto.DefaultScryptN = dataDir, dbDir, summercashDataDir, scryptN // Restore settings
+	}) // Restore settings after test
+}
+
+// tamper flips a byte in the db entry of a given backup archive, leaving its manifest untouched.
+func tamper(t *testing.T, archive []byte) []byte {
+	gzipReader, err := gzip.NewReader(bytes.NewReader(archive)) // Init gzip reader
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	tarReader := tar.NewReader(gzipReader) // Init tar reader
+
+	tampered := new(bytes.Buffer) // Init tampered buffer
+
+	gzipWriter := gzip.NewWriter(tampered) // Init gzip writer
+
+	tarWriter := tar.NewWriter(gzipWriter) // Init tar writer
+
+	for {
+		header, err := tarReader.Next() // Read header
+
+		if err == io.EOF { // Check done
+			break // Break
+		} else if err != nil { // Check for errors
+			t.Fatal(err) // Panic
+		}
+
+		contents, err := ioutil.ReadAll(tarReader) // Read contents
+
+		if err != nil { // Check for errors
+			t.Fatal(err) // Panic
+		}
+
+		if header.Name == dbName { // Check is db
+			contents[len(contents)-1] ^= 0xff // Flip byte
+		}
+
+		if err = tarWriter.WriteHeader(header); err != nil { // Write header
+			t.Fatal(err) // Panic
+		}
+
+		if _, err = tarWriter.Write(contents); err != nil { // Write contents
+			t.Fatal(err) // Panic
+		}
+	}
+
+	tarWriter.Close()  // Flush tar
+	gzipWriter.Close() // Flush gzip
+
+	return tampered.Bytes() // Return tampered archive
+}
+
+/* END TEST HELPERS */
@@ -3,29 +3,9 @@ package crypto
 import (
 	"encoding/hex"
 
-	"golang.org/x/crypto/bcrypt"
-
 	"golang.org/x/crypto/sha3"
 )
 
-// Salt - hash and salt specified byte array
-func Salt(b []byte) []byte {
-	hash, _ := bcrypt.GenerateFromPassword(b, bcrypt.MinCost) // Hash
-
-	return hash // Return hash
-}
-
-// VerifySalted - verify the contents of a salted hash
-func VerifySalted(salted []byte, password string) bool {
-	err := bcrypt.CompareHashAndPassword(salted, []byte(password)) // Verify
-
-	if err != nil { // Check for errors
-		return false // Invalid
-	}
-
-	return true // Valid
-}
-
 // Sha3 - hash specified byte array
 func Sha3(b []byte) []byte {
 	hash := sha3.New256() // Init hasher
@@ -2,7 +2,10 @@ package crypto
 
 import (
 	"bytes"
+	"encoding/hex"
+	"strings"
 	"testing"
+	"time"
 )
 
 // TestSalt tests the functionality of the Salt() method.
@@ -23,6 +26,161 @@ func TestVerifySalted(t *testing.T) {
 	}
 }
 
+// TestArgon2idHasher tests the functionality of the Argon2idHasher.
+func TestArgon2idHasher(t *testing.T) {
+	hasher := &Argon2idHasher{Time: 1, Memory: 1024, Threads: 1, SaltLength: 16, KeyLength: 32} // Init cheap hasher
+
+	hash, err := hasher.Hash([]byte("test")) // Hash
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	if !VerifySalted(hash, "test") || VerifySalted(hash, "wrong") { // Verify salted
+		t.Fatal("argon2id hash did not verify correctly") // Panic
+	}
+
+	if hasher.NeedsRehash(hash) { // Check needs rehash with same params
+		t.Fatal("hash with current parameters should not need a rehash") // Panic
+	}
+
+	if !(&Argon2idHasher{Time: 2, Memory: 1024, Threads: 1, SaltLength: 16, KeyLength: 32}).NeedsRehash(hash) { // Check doesn't need rehash with new params
+		t.Fatal("hash with outdated parameters should need a rehash") // Panic
+	}
+}
+
+// TestBcryptHasher tests the functionality of the BcryptHasher.
+func TestBcryptHasher(t *testing.T) {
+	hash, err := NewBcryptHasher(4).Hash([]byte("test")) // Hash
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	if !VerifySalted(hash, "test") { // Verify salted
+		t.Fatal("bcrypt hash did not verify correctly") // Panic
+	}
+
+	if NewBcryptHasher(4).NeedsRehash(hash) || !NewBcryptHasher(5).NeedsRehash(hash) || !NewArgon2idHasher().NeedsRehash(hash) { // Check rehash detection
+		t.Fatal("bcrypt hash rehash detection failed") // Panic
+	}
+}
+
+// TestSeal tests the functionality of the Seal() and Open() methods.
+func TestSeal(t *testing.T) {
+	params, err := NewScryptParams() // Init KDF params
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	params.N = 1 << 10 // Use cheap cost
+
+	key, err := params.DeriveKey([]byte("test")) // Derive key
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	sealed, err := Seal(key, []byte("plaintext")) // Seal
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	if opened, err := Open(key, sealed); err != nil || !bytes.Equal(opened, []byte("plaintext")) { // Open
+		t.Fatalf("should have opened sealed plaintext: %v", err) // Panic
+	}
+
+	otherKey, _ := params.DeriveKey([]byte("wrong")) // Derive wrong key
+
+	if _, err = Open(otherKey, sealed); err != ErrDecryptionFailed { // Open with wrong key
+		t.Fatal("should not have opened with wrong key") // Panic
+	}
+}
+
+// TestParsePrivateKey tests the functionality of the EncodePrivateKey() and ParsePrivateKey() methods.
+func TestParsePrivateKey(t *testing.T) {
+	key, err := DeriveMnemonicKey("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about", 0) // Derive key
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	encoded, err := EncodePrivateKey(key) // Encode key
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	for _, b := range [][]byte{encoded, []byte(hex.EncodeToString(encoded)), []byte("0x" + hex.EncodeToString(encoded))} { // Iterate through encodings
+		if parsed, err := ParsePrivateKey(b); err != nil || parsed.D.Cmp(key.D) != 0 { // Parse key
+			t.Fatalf("should have parsed encoded key: %v", err) // Panic
+		}
+	}
+
+	if _, err = ParsePrivateKey([]byte("not a key")); err != ErrInvalidPrivateKey { // Parse garbage
+		t.Fatal("invalid key should be rejected") // Panic
+	}
+}
+
+// TestDeriveMnemonicKey tests the functionality of the NewMnemonic() and DeriveMnemonicKey() methods.
+func TestDeriveMnemonicKey(t *testing.T) {
+	mnemonic, err := NewMnemonic() // Generate mnemonic
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	key, err := DeriveMnemonicKey(mnemonic, 0) // Derive key
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	if !key.Curve.IsOnCurve(key.X, key.Y) { // Check invalid public key
+		t.Fatal("derived public key should be on the curve") // Panic
+	}
+
+	if sameKey, err := DeriveMnemonicKey("  "+strings.ToUpper(mnemonic)+" ", 0); err != nil || sameKey.D.Cmp(key.D) != 0 { // Derive key from denormalized mnemonic
+		t.Fatal("derivation should be deterministic") // Panic
+	}
+
+	if otherKey, _ := DeriveMnemonicKey(mnemonic, 1); otherKey.D.Cmp(key.D) == 0 { // Derive key at other index
+		t.Fatal("keys at different indices should differ") // Panic
+	}
+
+	if _, err = DeriveMnemonicKey(mnemonic+" abandon", 0); err != ErrInvalidMnemonic { // Derive key from invalid mnemonic
+		t.Fatal("invalid mnemonic should be rejected") // Panic
+	}
+}
+
+// TestValidateTOTP tests the functionality of the TOTPCode() and ValidateTOTP() methods against the RFC 6238 SHA-1 test vectors.
+func TestValidateTOTP(t *testing.T) {
+	secret := []byte("12345678901234567890") // RFC 6238 SHA-1 secret
+
+	for unix, expected := range map[int64]string{
+		59:         "287082", // T = 0000000000000001
+		1111111109: "081804", // T = 00000000023523EC
+		1234567890: "005924", // T = 000000000273EF07
+		2000000000: "279037", // T = 0000000003F940AA
+	} {
+		if code := TOTPCode(secret, TOTPStep(time.Unix(unix, 0))); code != expected { // Check mismatch
+			t.Errorf("expected code %s at %d, got %s", expected, unix, code) // Log error
+		}
+	}
+
+	now := time.Unix(1111111109, 0) // Get test time
+
+	if step, valid := ValidateTOTP(secret, "081804", now.Add(TOTPPeriod), 1); !valid || step != TOTPStep(now) { // Validate within skew
+		t.Fatal("code within skew window should be valid") // Panic
+	}
+
+	if _, valid := ValidateTOTP(secret, "081804", now.Add(2*TOTPPeriod), 1); valid { // Validate outside skew
+		t.Fatal("code outside skew window should be invalid") // Panic
+	}
+}
+
 // TestSha3 - test functionality of sha3 hashing function
 func TestSha3(t *testing.T) {
 	hashed := Sha3([]byte("test")) // Hash
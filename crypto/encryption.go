@@ -0,0 +1,135 @@
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"io"
+
+	"golang.org/x/crypto/hkdf"
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/crypto/sha3"
+)
+
+// ScryptParams represents a set of scrypt key derivation parameters.
+type ScryptParams struct {
+	N int `json:"n"` // CPU/memory cost
+	R int `json:"r"` // Block size
+	P int `json:"p"` // Parallelism
+
+	Salt []byte `json:"salt"` // Salt
+}
+
+var (
+	// ErrDecryptionFailed is an error definition describing a ciphertext that could not be authenticated with a given key.
+	ErrDecryptionFailed = errors.New("decryption failed")
+
+	// DefaultScryptN is the scrypt CPU/memory cost used to derive new keys.
+	DefaultScryptN = 1 << 15
+)
+
+// KeySize is the size, in bytes, of all symmetric keys (AES-256).
+const KeySize = 32
+
+/* BEGIN EXPORTED METHODS */
+
+// NewScryptParams initializes a new set of scrypt parameters with a random salt.
+func NewScryptParams() (*ScryptParams, error) {
+	salt, err := RandomBytes(32) // Generate salt
+
+	if err != nil { // Check for errors
+		return nil, err // Return found error
+	}
+
+	return &ScryptParams{
+		N:    DefaultScryptN, // Set cost
+		R:    8,              // Set block size
+		P:    1,              // Set parallelism
+		Salt: salt,           // Set salt
+	}, nil // Return params
+}
+
+// DeriveKey derives a symmetric key from a given passphrase using scrypt.
+func (params *ScryptParams) DeriveKey(passphrase []byte) ([]byte, error) {
+	return scrypt.Key(passphrase, params.Salt, params.N, params.R, params.P, KeySize) // Derive key
+}
+
+// ExpandKey derives a symmetric key from a given high-entropy secret using HKDF-SHA3.
+// The info string binds the derived key to its purpose.
+func ExpandKey(secret []byte, salt []byte, info string) ([]byte, error) {
+	key := make([]byte, KeySize) // Init key buffer
+
+	if _, err := io.ReadFull(hkdf.New(sha3.New256, secret, salt, []byte(info)), key); err != nil { // Expand
+		return nil, err // Return found error
+	}
+
+	return key, nil // Return key
+}
+
+// RandomBytes reads a given number of cryptographically secure random bytes.
+func RandomBytes(n int) ([]byte, error) {
+	b := make([]byte, n) // Init buffer
+
+	if _, err := rand.Read(b); err != nil { // Read random
+		return nil, err // Return found error
+	}
+
+	return b, nil // Return bytes
+}
+
+// Seal encrypts and authenticates a given plaintext with a given key using AES-256-GCM.
+// The random nonce is prepended to the returned ciphertext.
+func Seal(key []byte, plaintext []byte) ([]byte, error) {
+	aead, err := newGCM(key) // Init cipher
+
+	if err != nil { // Check for errors
+		return nil, err // Return found error
+	}
+
+	nonce, err := RandomBytes(aead.NonceSize()) // Generate nonce
+
+	if err != nil { // Check for errors
+		return nil, err // Return found error
+	}
+
+	return aead.Seal(nonce, nonce, plaintext, nil), nil // Return nonce || ciphertext
+}
+
+// Open authenticates and decrypts a given ciphertext produced by Seal.
+func Open(key []byte, ciphertext []byte) ([]byte, error) {
+	aead, err := newGCM(key) // Init cipher
+
+	if err != nil { // Check for errors
+		return nil, err // Return found error
+	}
+
+	if len(ciphertext) < aead.NonceSize() { // Check too short
+		return nil, ErrDecryptionFailed // Return error
+	}
+
+	plaintext, err := aead.Open(nil, ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():], nil) // Decrypt
+
+	if err != nil { // Check for errors
+		return nil, ErrDecryptionFailed // Return error
+	}
+
+	return plaintext, nil // Return plaintext
+}
+
+/* END EXPORTED METHODS */
+
+/* BEGIN INTERNAL METHODS */
+
+// newGCM initializes a new AES-GCM cipher with a given key.
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key) // Init block cipher
+
+	if err != nil { // Check for errors
+		return nil, err // Return found error
+	}
+
+	return cipher.NewGCM(block) // Return AEAD
+}
+
+/* END INTERNAL METHODS */
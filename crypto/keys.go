@@ -0,0 +1,51 @@
+package crypto
+
+import (
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"strings"
+)
+
+var (
+	// ErrInvalidPrivateKey is an error definition describing an unparsable private key.
+	ErrInvalidPrivateKey = errors.New("invalid private key")
+)
+
+/* BEGIN EXPORTED METHODS */
+
+// EncodePrivateKey PEM-encodes a given private key.
+func EncodePrivateKey(privateKey *ecdsa.PrivateKey) ([]byte, error) {
+	marshaledPrivateKey, err := x509.MarshalECPrivateKey(privateKey) // Marshal private key
+
+	if err != nil { // Check for errors
+		return nil, err // Return found error
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: marshaledPrivateKey}), nil // Return PEM-encoded private key
+}
+
+// ParsePrivateKey parses a PEM-encoded private key, optionally hex-encoded (as exported by the accounts API).
+func ParsePrivateKey(b []byte) (*ecdsa.PrivateKey, error) {
+	if decoded, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(string(b)), "0x")); err == nil { // Check is hex-encoded
+		b = decoded // Set decoded
+	}
+
+	block, _ := pem.Decode(b) // Decode PEM
+
+	if block == nil { // Check invalid PEM
+		return nil, ErrInvalidPrivateKey // Return error
+	}
+
+	privateKey, err := x509.ParseECPrivateKey(block.Bytes) // Parse private key
+
+	if err != nil { // Check for errors
+		return nil, ErrInvalidPrivateKey // Return error
+	}
+
+	return privateKey, nil // Return private key
+}
+
+/* END EXPORTED METHODS */
@@ -0,0 +1,81 @@
+package crypto
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math/big"
+	"strings"
+
+	"github.com/tyler-smith/go-bip39"
+	"golang.org/x/crypto/hkdf"
+	"golang.org/x/crypto/sha3"
+)
+
+var (
+	// ErrInvalidMnemonic is an error definition describing a recovery phrase with unknown words or an invalid checksum.
+	ErrInvalidMnemonic = errors.New("invalid recovery phrase")
+)
+
+// MnemonicEntropyBits is the entropy, in bits, of new recovery phrases (256 bits make a 24 word phrase).
+const MnemonicEntropyBits = 256
+
+// mnemonicKeyInfo binds keys derived from recovery phrases to the purpose of generating account keys.
+const mnemonicKeyInfo = "summercash-wallet-server mnemonic account key"
+
+/* BEGIN EXPORTED METHODS */
+
+// NewMnemonic generates a new BIP-39 recovery phrase.
+func NewMnemonic() (string, error) {
+	entropy, err := bip39.NewEntropy(MnemonicEntropyBits) // Generate entropy
+
+	if err != nil { // Check for errors
+		return "", err // Return found error
+	}
+
+	return bip39.NewMnemonic(entropy) // Encode entropy
+}
+
+// NormalizeMnemonic lowercases a given recovery phrase and collapses the whitespace between its words.
+func NormalizeMnemonic(mnemonic string) string {
+	return strings.Join(strings.Fields(strings.ToLower(mnemonic)), " ") // Normalize
+}
+
+// DeriveMnemonicKey deterministically derives the P-521 private key at a given index from a given BIP-39 recovery phrase.
+// BIP-32 derivation is only defined for secp256k1, so the key is instead expanded from the BIP-39 seed using HKDF-SHA3.
+func DeriveMnemonicKey(mnemonic string, index uint32) (*ecdsa.PrivateKey, error) {
+	mnemonic = NormalizeMnemonic(mnemonic) // Normalize
+
+	if !bip39.IsMnemonicValid(mnemonic) { // Check invalid
+		return nil, ErrInvalidMnemonic // Return error
+	}
+
+	curve := elliptic.P521() // Get curve
+
+	info := make([]byte, len(mnemonicKeyInfo)+4) // Init info buffer
+
+	copy(info, mnemonicKeyInfo)                                    // Set purpose
+	binary.BigEndian.PutUint32(info[len(mnemonicKeyInfo):], index) // Set index
+
+	scalarBytes := make([]byte, (curve.Params().BitSize+7)/8+16) // Init scalar buffer (extra bytes make the modular bias negligible)
+
+	if _, err := io.ReadFull(hkdf.New(sha3.New512, bip39.NewSeed(mnemonic, ""), nil, info), scalarBytes); err != nil { // Expand seed
+		return nil, err // Return found error
+	}
+
+	n := new(big.Int).Sub(curve.Params().N, big.NewInt(1)) // Get N - 1
+
+	scalar := new(big.Int).Mod(new(big.Int).SetBytes(scalarBytes), n) // Reduce scalar
+	scalar.Add(scalar, big.NewInt(1))                                 // Ensure scalar is in [1, N - 1]
+
+	privateKey := &ecdsa.PrivateKey{D: scalar} // Init private key
+
+	privateKey.PublicKey.Curve = curve                                                    // Set curve
+	privateKey.PublicKey.X, privateKey.PublicKey.Y = curve.ScalarBaseMult(scalar.Bytes()) // Derive public key
+
+	return privateKey, nil // Return private key
+}
+
+/* END EXPORTED METHODS */
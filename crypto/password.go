@@ -0,0 +1,206 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// PasswordHasher defines the methods that a password hashing algorithm must implement.
+// Hashes are self-describing: each encodes the algorithm and parameters it was produced with.
+type PasswordHasher interface {
+	Hash(password []byte) ([]byte, error) // Hash hashes and salts a given password.
+
+	NeedsRehash(hash []byte) bool // NeedsRehash checks whether a given hash was produced by a different algorithm, or with different parameters.
+}
+
+// BcryptHasher is a PasswordHasher using bcrypt at a given cost.
+type BcryptHasher struct {
+	Cost int `json:"cost"` // bcrypt cost
+}
+
+// Argon2idHasher is a PasswordHasher using argon2id with a given set of parameters.
+type Argon2idHasher struct {
+	Time    uint32 `json:"time"`    // Number of passes over memory
+	Memory  uint32 `json:"memory"`  // Memory size in KiB
+	Threads uint8  `json:"threads"` // Degree of parallelism
+
+	SaltLength uint32 `json:"salt_length"` // Salt length in bytes
+	KeyLength  uint32 `json:"key_length"`  // Derived key length in bytes
+}
+
+var (
+	// ErrUnknownPasswordHasher is an error definition describing an unsupported password hashing algorithm.
+	ErrUnknownPasswordHasher = errors.New("unknown password hashing algorithm")
+
+	// ErrInvalidHash is an error definition describing a malformed password hash.
+	ErrInvalidHash = errors.New("invalid password hash")
+)
+
+// argon2idPrefix is the prefix of all argon2id hashes.
+const argon2idPrefix = "$argon2id$"
+
+// DefaultPasswordHasher is the hasher used to hash new passwords.
+var DefaultPasswordHasher PasswordHasher = NewArgon2idHasher()
+
+/* BEGIN EXPORTED METHODS */
+
+// NewBcryptHasher initializes a new bcrypt hasher with a given cost.
+func NewBcryptHasher(cost int) *BcryptHasher {
+	return &BcryptHasher{
+		Cost: cost, // Set cost
+	} // Return hasher
+}
+
+// NewArgon2idHasher initializes a new argon2id hasher with the recommended parameters.
+func NewArgon2idHasher() *Argon2idHasher {
+	return &Argon2idHasher{
+		Time:       3,         // Set passes
+		Memory:     64 * 1024, // Set 64 MiB memory
+		Threads:    2,         // Set parallelism
+		SaltLength: 16,        // Set salt length
+		KeyLength:  32,        // Set key length
+	} // Return hasher
+}
+
+// NewPasswordHasher initializes a new hasher for a given algorithm name (bcrypt or argon2id).
+// The bcrypt cost is only used by the bcrypt hasher.
+func NewPasswordHasher(algorithm string, bcryptCost int) (PasswordHasher, error) {
+	switch algorithm {
+	case "bcrypt":
+		if bcryptCost < bcrypt.MinCost || bcryptCost > bcrypt.MaxCost { // Check invalid cost
+			return nil, bcrypt.InvalidCostError(bcryptCost) // Return error
+		}
+
+		return NewBcryptHasher(bcryptCost), nil // Return bcrypt hasher
+	case "argon2id":
+		return NewArgon2idHasher(), nil // Return argon2id hasher
+	default:
+		return nil, ErrUnknownPasswordHasher // Return error
+	}
+}
+
+// Salt - hash and salt specified byte array using the default password hasher
+func Salt(b []byte) []byte {
+	hash, _ := DefaultPasswordHasher.Hash(b) // Hash
+
+	return hash // Return hash
+}
+
+// VerifySalted - verify the contents of a salted hash, regardless of the algorithm it was produced with
+func VerifySalted(salted []byte, password string) bool {
+	if bytes.HasPrefix(salted, []byte(argon2idPrefix)) { // Check is argon2id
+		return verifyArgon2id(salted, []byte(password)) // Verify
+	}
+
+	err := bcrypt.CompareHashAndPassword(salted, []byte(password)) // Verify
+
+	if err != nil { // Check for errors
+		return false // Invalid
+	}
+
+	return true // Valid
+}
+
+// NeedsRehash - check whether a salted hash should be replaced with one from the default password hasher
+func NeedsRehash(salted []byte) bool {
+	return DefaultPasswordHasher.NeedsRehash(salted) // Check needs rehash
+}
+
+// Hash hashes and salts a given password using bcrypt.
+func (hasher *BcryptHasher) Hash(password []byte) ([]byte, error) {
+	return bcrypt.GenerateFromPassword(password, hasher.Cost) // Hash
+}
+
+// NeedsRehash checks whether a given hash is not a bcrypt hash at the hasher's cost.
+func (hasher *BcryptHasher) NeedsRehash(hash []byte) bool {
+	cost, err := bcrypt.Cost(hash) // Get hash cost
+
+	return err != nil || cost != hasher.Cost // Check not bcrypt or cost differs
+}
+
+// Hash hashes and salts a given password using argon2id.
+// The result is encoded in the PHC string format (e.g. $argon2id$v=19$m=65536,t=3,p=2$salt$key).
+func (hasher *Argon2idHasher) Hash(password []byte) ([]byte, error) {
+	salt := make([]byte, hasher.SaltLength) // Init salt buffer
+
+	if _, err := rand.Read(salt); err != nil { // Read random
+		return nil, err // Return found error
+	}
+
+	key := argon2.IDKey(password, salt, hasher.Time, hasher.Memory, hasher.Threads, hasher.KeyLength) // Derive key
+
+	return []byte(fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", argon2idPrefix, argon2.Version, hasher.Memory, hasher.Time, hasher.Threads, base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key))), nil // Return encoded hash
+}
+
+// NeedsRehash checks whether a given hash is not an argon2id hash with the hasher's parameters.
+func (hasher *Argon2idHasher) NeedsRehash(hash []byte) bool {
+	params, salt, key, err := decodeArgon2id(hash) // Decode hash
+
+	if err != nil { // Check for errors
+		return true // Not argon2id
+	}
+
+	return params.Time != hasher.Time || params.Memory != hasher.Memory || params.Threads != hasher.Threads || uint32(len(salt)) != hasher.SaltLength || uint32(len(key)) != hasher.KeyLength // Check params differ
+}
+
+/* END EXPORTED METHODS */
+
+/* BEGIN INTERNAL METHODS */
+
+// verifyArgon2id verifies a given password against an encoded argon2id hash.
+func verifyArgon2id(hash []byte, password []byte) bool {
+	params, salt, key, err := decodeArgon2id(hash) // Decode hash
+
+	if err != nil { // Check for errors
+		return false // Invalid
+	}
+
+	derivedKey := argon2.IDKey(password, salt, params.Time, params.Memory, params.Threads, uint32(len(key))) // Derive key
+
+	return subtle.ConstantTimeCompare(key, derivedKey) == 1 // Compare keys
+}
+
+// decodeArgon2id decodes the parameters, salt and key of an encoded argon2id hash.
+func decodeArgon2id(hash []byte) (*Argon2idHasher, []byte, []byte, error) {
+	parts := strings.Split(string(hash), "$") // Split hash
+
+	if len(parts) != 6 || "$"+parts[1]+"$" != argon2idPrefix { // Check invalid hash
+		return nil, nil, nil, ErrInvalidHash // Return error
+	}
+
+	var version int // Init version buffer
+
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version { // Check unsupported version
+		return nil, nil, nil, ErrInvalidHash // Return error
+	}
+
+	params := &Argon2idHasher{} // Init params buffer
+
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Time, &params.Threads); err != nil { // Parse params
+		return nil, nil, nil, ErrInvalidHash // Return error
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4]) // Decode salt
+
+	if err != nil { // Check for errors
+		return nil, nil, nil, ErrInvalidHash // Return error
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5]) // Decode key
+
+	if err != nil || len(key) == 0 { // Check for errors
+		return nil, nil, nil, ErrInvalidHash // Return error
+	}
+
+	return params, salt, key, nil // Return decoded hash
+}
+
+/* END INTERNAL METHODS */
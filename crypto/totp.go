@@ -0,0 +1,102 @@
+package crypto
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+const (
+	// TOTPDigits is the number of digits in a TOTP code.
+	TOTPDigits = 6
+
+	// TOTPPeriod is the duration of a single TOTP time step.
+	TOTPPeriod = 30 * time.Second
+
+	// TOTPSecretSize is the size, in bytes, of new TOTP secrets (the RFC 4226 recommended 160 bits).
+	TOTPSecretSize = 20
+)
+
+// totpSecretEncoding is the unpadded base32 encoding authenticator apps expect TOTP secrets in.
+var totpSecretEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+/* BEGIN EXPORTED METHODS */
+
+// NewTOTPSecret generates a new random TOTP secret.
+func NewTOTPSecret() ([]byte, error) {
+	return RandomBytes(TOTPSecretSize) // Generate secret
+}
+
+// EncodeTOTPSecret encodes a given TOTP secret as unpadded base32, so that it can be entered into an authenticator app manually.
+func EncodeTOTPSecret(secret []byte) string {
+	return totpSecretEncoding.EncodeToString(secret) // Encode secret
+}
+
+// TOTPProvisioningURI builds the otpauth:// URI of a given TOTP secret, which authenticator apps enroll from (usually scanned as a QR code).
+func TOTPProvisioningURI(secret []byte, issuer string, accountName string) string {
+	query := url.Values{} // Init query
+
+	query.Set("secret", EncodeTOTPSecret(secret))                  // Set secret
+	query.Set("issuer", issuer)                                    // Set issuer
+	query.Set("algorithm", "SHA1")                                 // Set algorithm
+	query.Set("digits", fmt.Sprintf("%d", TOTPDigits))             // Set digits
+	query.Set("period", fmt.Sprintf("%.0f", TOTPPeriod.Seconds())) // Set period
+
+	label := url.PathEscape(issuer) + ":" + url.PathEscape(accountName) // Build label
+
+	return fmt.Sprintf("otpauth://totp/%s?%s", label, query.Encode()) // Return URI
+}
+
+// TOTPStep gets the RFC 6238 time step of a given time.
+func TOTPStep(t time.Time) uint64 {
+	return uint64(t.Unix()) / uint64(TOTPPeriod.Seconds()) // Return step
+}
+
+// TOTPCode generates the RFC 6238 TOTP code of a given secret at a given time step.
+func TOTPCode(secret []byte, step uint64) string {
+	counter := make([]byte, 8) // Init counter buffer
+
+	binary.BigEndian.PutUint64(counter, step) // Set counter
+
+	mac := hmac.New(sha1.New, secret) // Init HMAC
+	mac.Write(counter)                // Write counter
+
+	sum := mac.Sum(nil) // Get HMAC
+
+	offset := sum[len(sum)-1] & 0x0f // Get dynamic truncation offset
+
+	truncated := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff // Truncate
+
+	modulus := uint32(1) // Init modulus
+
+	for i := 0; i < TOTPDigits; i++ { // Raise modulus to number of digits
+		modulus *= 10 // Add digit
+	}
+
+	return fmt.Sprintf("%0*d", TOTPDigits, truncated%modulus) // Return zero-padded code
+}
+
+// ValidateTOTP checks a given TOTP code against a given secret at a given time, allowing for a given number of steps of clock skew
+// in either direction. Returns the time step the code was generated at, so that callers are able to reject replayed codes.
+func ValidateTOTP(secret []byte, code string, t time.Time, skew uint64) (uint64, bool) {
+	if len(code) != TOTPDigits { // Check invalid length
+		return 0, false // Invalid
+	}
+
+	current := TOTPStep(t) // Get current step
+
+	for step := current - skew; step <= current+skew; step++ { // Iterate through steps in window
+		if subtle.ConstantTimeCompare([]byte(TOTPCode(secret, step)), []byte(code)) == 1 { // Check matches
+			return step, true // Valid
+		}
+	}
+
+	return 0, false // Invalid
+}
+
+/* END EXPORTED METHODS */
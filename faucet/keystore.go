@@ -0,0 +1,191 @@
+// Package faucet outlines the faucet interface and its associated helper methods.
+package faucet
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	summercashCommon "github.com/SummerCash/go-summercash/common"
+	"github.com/SummerCash/go-summercash/types"
+	"github.com/SummerCash/summercash-wallet-server/accounts"
+	"github.com/SummerCash/summercash-wallet-server/common"
+	"github.com/SummerCash/summercash-wallet-server/crypto"
+)
+
+var (
+	// ErrKeystoreAlreadyExists is an error definition describing an attempt to import a key over an existing faucet keystore.
+	ErrKeystoreAlreadyExists = errors.New("faucet keystore already exists")
+
+	// ErrFaucetAccountMismatch is an error definition describing a faucet account whose address does not match the faucet key.
+	ErrFaucetAccountMismatch = errors.New("faucet account address does not match the faucet keystore")
+)
+
+// FaucetUsername is the username of the faucet account.
+const FaucetUsername = "faucet"
+
+/* BEGIN EXPORTED METHODS */
+
+// KeystorePath gets the path of the encrypted faucet keystore.
+func KeystorePath() string {
+	return filepath.FromSlash(fmt.Sprintf("%s/faucet/keystore/faucet.json", common.DataDir)) // Return path
+}
+
+// LoadFaucetKey loads the faucet signing key from the encrypted faucet keystore, creating the keystore if it does not exist.
+// When creating the keystore, an import key is used if given. Otherwise, a legacy faucet key is migrated if one exists,
+// and a new key is generated if not. The faucet account is created if it does not exist.
+func LoadFaucetKey(accountsDB *accounts.DB, passphrase []byte, importKey *ecdsa.PrivateKey) (*ecdsa.PrivateKey, error) {
+	var privateKey *ecdsa.PrivateKey // Init private key buffer
+
+	_, err := os.Stat(KeystorePath()) // Check keystore exists
+
+	switch {
+	case err == nil && importKey != nil: // Refuse to overwrite keystore
+		return nil, ErrKeystoreAlreadyExists // Return error
+	case err == nil:
+		if privateKey, err = ReadKeystore(passphrase); err != nil { // Read keystore
+			return nil, err // Return found error
+		}
+	case !os.IsNotExist(err):
+		return nil, err // Return found error
+	default:
+		if privateKey, err = newFaucetKey(accountsDB, importKey); err != nil { // Get key to store
+			return nil, err // Return found error
+		}
+
+		if err = WriteKeystore(privateKey, passphrase); err != nil { // Write keystore
+			return nil, err // Return found error
+		}
+
+		if err = os.Remove(legacyKeystorePath()); err != nil && !os.IsNotExist(err) { // Remove legacy keystore
+			return nil, err // Return found error
+		}
+	}
+
+	if err = ensureFaucetAccount(accountsDB, privateKey); err != nil { // Create faucet account
+		return nil, err // Return found error
+	}
+
+	return privateKey, nil // Return private key
+}
+
+// ReadKeystore reads and decrypts the faucet keystore with a given passphrase.
+func ReadKeystore(passphrase []byte) (*ecdsa.PrivateKey, error) {
+	keystoreBytes, err := ioutil.ReadFile(KeystorePath()) // Read keystore
+
+	if err != nil { // Check for errors
+		return nil, err // Return found error
+	}
+
+	keystore, err := accounts.KeystoreFromBytes(keystoreBytes) // Unmarshal keystore
+
+	if err != nil { // Check for errors
+		return nil, err // Return found error
+	}
+
+	return keystore.Decrypt(passphrase) // Decrypt private key
+}
+
+// WriteKeystore encrypts a given private key with a given passphrase, and writes it to the faucet keystore.
+func WriteKeystore(privateKey *ecdsa.PrivateKey, passphrase []byte) error {
+	keystore, err := accounts.NewKeystore(privateKey, passphrase) // Encrypt private key
+
+	if err != nil { // Check for errors
+		return err // Return found error
+	}
+
+	if err = common.CreateDirIfDoesNotExit(filepath.Dir(KeystorePath())); err != nil { // Create keystore dir
+		return err // Return found error
+	}
+
+	return ioutil.WriteFile(KeystorePath(), keystore.Bytes(), 0600) // Write keystore
+}
+
+/* END EXPORTED METHODS */
+
+/* BEGIN INTERNAL METHODS */
+
+// newFaucetKey gets the key to store in a new faucet keystore: the given import key if not nil,
+// the legacy faucet key if one exists, or a newly generated key.
+func newFaucetKey(accountsDB *accounts.DB, importKey *ecdsa.PrivateKey) (*ecdsa.PrivateKey, error) {
+	if importKey != nil { // Check has import key
+		return importKey, nil // Return import key
+	}
+
+	legacyPassword, err := ioutil.ReadFile(legacyKeystorePath()) // Read legacy faucet password
+
+	if os.IsNotExist(err) { // Check no legacy keystore
+		return ecdsa.GenerateKey(elliptic.P521(), rand.Reader) // Generate private key
+	} else if err != nil { // Check for errors
+		return nil, err // Return found error
+	}
+
+	password := strings.Replace(string(legacyPassword), ":", "", 1) // Get legacy password
+
+	privateKey, err := accountsDB.UnlockPrivateKey(FaucetUsername, password, accounts.OperationExportKey, "") // Unlock legacy faucet account key
+
+	if err == accounts.ErrPasswordInvalid && len(legacyPassword) < 512 { // Check password may have been rehashed from a claim
+		// Legacy claims read the password file into a zeroed 512-byte buffer, and passed the padding along with the password.
+		// bcrypt ignored the padding, but a transparent rehash on login will have hashed it into the new password hash.
+		privateKey, err = accountsDB.UnlockPrivateKey(FaucetUsername, password+strings.Repeat("\x00", 512-len(legacyPassword)), accounts.OperationExportKey, "") // Unlock with padded password
+	}
+
+	return privateKey, err // Return private key
+}
+
+// ensureFaucetAccount creates the faucet account for a given key if it does not already exist.
+func ensureFaucetAccount(accountsDB *accounts.DB, privateKey *ecdsa.PrivateKey) error {
+	address, err := summercashCommon.NewAddress(privateKey) // Get address
+
+	if err != nil { // Check for errors
+		return err // Return found error
+	}
+
+	account, err := accountsDB.QueryAccountByUsername(FaucetUsername) // Query faucet account
+
+	if err == nil { // Check already exists
+		if account.Address != address { // Check address mismatch
+			return ErrFaucetAccountMismatch // Return error
+		}
+
+		return nil // Nothing to do
+	} else if err != accounts.ErrAccountDoesNotExist { // Check for errors
+		return err // Return found error
+	}
+
+	if _, err = types.ReadChainFromMemory(address); err != nil { // Check no chain
+		chain, err := types.NewChain(address) // Init faucet chain
+
+		if err != nil { // Check for errors
+			return err // Return found error
+		}
+
+		if err = chain.WriteToMemory(); err != nil { // Write chain to memory
+			return err // Return found error
+		}
+	}
+
+	password, err := crypto.RandomBytes(32) // Generate unusable password; the faucet key is only unlocked by the keystore passphrase
+
+	if err != nil { // Check for errors
+		return err // Return found error
+	}
+
+	_, err = accountsDB.AddReservedAccount(FaucetUsername, hex.EncodeToString(password), address.String()) // Add faucet account
+
+	return err // Return error
+}
+
+// legacyKeystorePath gets the path of the legacy plaintext faucet password file.
+func legacyKeystorePath() string {
+	return filepath.FromSlash(fmt.Sprintf("%s/faucet/keystore/privateKey.key", common.DataDir)) // Return path
+}
+
+/* END INTERNAL METHODS */
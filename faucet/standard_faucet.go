@@ -3,15 +3,12 @@ package faucet
 
 import (
 	"bytes"
+	"crypto/ecdsa"
 	"fmt"
 	"math/big"
-	"os"
-	"path/filepath"
-	"strings"
 	"time"
 
 	"github.com/SummerCash/summercash-wallet-server/accounts"
-	"github.com/SummerCash/summercash-wallet-server/common"
 	"github.com/SummerCash/summercash-wallet-server/transactions"
 )
 
@@ -20,15 +17,18 @@ type StandardFaucet struct {
 	Ruleset Ruleset // Faucet ruleset
 
 	AccountsDatabase *accounts.DB // Accounts database
+
+	privateKey *ecdsa.PrivateKey // Faucet signing key
 }
 
 /* BEGIN EXPORTED METHODS */
 
-// NewStandardFaucet initializes a new standard faucet.
-func NewStandardFaucet(ruleset Ruleset, accountsDB *accounts.DB) *StandardFaucet {
+// NewStandardFaucet initializes a new standard faucet signing claims with a given private key (see LoadFaucetKey).
+func NewStandardFaucet(ruleset Ruleset, accountsDB *accounts.DB, privateKey *ecdsa.PrivateKey) *StandardFaucet {
 	return &StandardFaucet{
 		Ruleset:          ruleset,    // Set ruleset
 		AccountsDatabase: accountsDB, // Set DB
+		privateKey:       privateKey, // Set private key
 	} // Return new faucet
 }
 
@@ -45,6 +45,10 @@ func (faucet *StandardFaucet) AccountCanClaim(account *accounts.Account) bool {
 		return false // Cannot claim; does not exist
 	}
 
+	if updatedAccount.Frozen { // Check frozen
+		return false // Cannot claim
+	}
+
 	if updatedAccount.LastFaucetClaimTime.IsZero() { // Check not set
 		return true // Has not claimed yet
 	}
@@ -76,6 +80,10 @@ func (faucet *StandardFaucet) AmountCanClaim(account *accounts.Account) *big.Flo
 		return big.NewFloat(0)
 	}
 
+	if updatedAccount.Frozen { // Check frozen
+		return big.NewFloat(0) // Return zero
+	}
+
 	for _, user := range faucet.Ruleset.BannedUsers() { // Iterate through banned users
 		if bytes.Equal(user.Address.Bytes(), updatedAccount.Address.Bytes()) { // Check is banned user
 			return big.NewFloat(0) // Return zero
@@ -97,6 +105,10 @@ func (faucet *StandardFaucet) Claim(account *accounts.Account, amount *big.Float
 		return err // Return found error
 	}
 
+	if updatedAccount.Frozen { // Check frozen
+		return accounts.ErrAccountFrozen // Return error
+	}
+
 	if amountCanClaim := faucet.AmountCanClaim(updatedAccount); amountCanClaim.Cmp(amount) == -1 { // Check amount to claim less than can claim
 		amountFloatVal, _ := amount.Float64() // Get float value
 
@@ -111,25 +123,15 @@ func (faucet *StandardFaucet) Claim(account *accounts.Account, amount *big.Float
 		return err // Return found error
 	}
 
-	keystoreFile, err := os.OpenFile(filepath.FromSlash(fmt.Sprintf("%s/faucet/keystore/privateKey.key", common.DataDir)), os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666) // Open keystore dir
+	faucetAccount, err := faucet.WorkingDB().QueryAccountByUsername(FaucetUsername) // Query faucet account
 
 	if err != nil { // Check for errors
 		return err // Return found error
 	}
 
-	buffer := make([]byte, 512) // Initialize pwd buffer
-
-	_, err = keystoreFile.Read(buffer) // Read into buffer
-
-	if err != nil { // Check for errors
-		return err // Return found error
-	}
-
-	splitPassword := strings.Split(string(buffer), ":") // Split
-
 	floatVal, _ := amount.Float64() // Get float value
 
-	_, err = transactions.NewTransaction((*faucet).WorkingDB(), "faucet", string(splitPassword[0]+splitPassword[1]), &account.Address, floatVal, []byte("Faucet claim.")) // Initialize transaction
+	_, err = transactions.NewSignedTransaction(faucetAccount.Address, faucet.privateKey, &account.Address, floatVal, []byte("Faucet claim.")) // Initialize transaction
 
 	if err != nil { // Check for errors
 		return err // Return found error
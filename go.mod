@@ -21,13 +21,13 @@ require (
 	github.com/libp2p/go-libp2p-core v0.0.3 // indirect
 	github.com/libp2p/go-msgio v0.0.3 // indirect
 	github.com/lunixbochs/vtclean v1.0.0 // indirect
+	github.com/mattn/go-sqlite3 v1.14.0
 	github.com/olahol/melody v0.0.0-20180227134253-7bd65910e5ab
 	github.com/r3labs/sse v0.0.0-20190530104643-3c23fe8c6bd2
+	github.com/tyler-smith/go-bip39 v1.0.2
 	github.com/valyala/fasthttp v1.3.0
 	go.opencensus.io v0.22.0 // indirect
 	golang.org/x/crypto v0.0.0-20190611184440-5c40567a22f8
-	golang.org/x/net v0.0.0-20190611141213-3f473d35a33a // indirect
 	golang.org/x/oauth2 v0.0.0-20190604053449-0f29369cfe45
-	golang.org/x/sys v0.0.0-20190610200419-93c9922d18ae // indirect
 	gopkg.in/cenkalti/backoff.v1 v1.1.0 // indirect
 )
@@ -0,0 +1,87 @@
+// Package mail defines the interface through which the server sends email, along with its SMTP, file and in-memory implementations.
+package mail
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// FileMailer is a Mailer writing each message to its own .eml file in a directory, rather than delivering it (e.g. for development).
+type FileMailer struct {
+	Dir string // Directory messages are written to
+
+	From string // Sender address
+
+	mutex sync.Mutex // Guards sent
+
+	sent int // Number of messages written so far (disambiguates messages written in the same nanosecond)
+}
+
+// MemoryMailer is a Mailer keeping every message in memory, rather than delivering it (e.g. for tests).
+type MemoryMailer struct {
+	mutex sync.Mutex // Guards messages
+
+	messages []*Message // Sent messages
+}
+
+/* BEGIN EXPORTED METHODS */
+
+// NewFileMailer initializes a new file mailer writing messages from a given sender address to a given directory, creating it if it does not already exist.
+func NewFileMailer(dir string, from string) (*FileMailer, error) {
+	if err := ValidateAddress(from); err != nil { // Check invalid sender
+		return nil, err // Return found error
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil { // Make mail directory
+		return nil, err // Return found error
+	}
+
+	return &FileMailer{
+		Dir:  dir,  // Set directory
+		From: from, // Set sender
+	}, nil // Return mailer
+}
+
+// Send writes a given message to a new file in the mailer's directory.
+func (mailer *FileMailer) Send(message *Message) error {
+	messageBytes, err := message.Bytes(mailer.From) // Encode message
+
+	if err != nil { // Check for errors
+		return err // Return found error
+	}
+
+	mailer.mutex.Lock()         // Lock
+	defer mailer.mutex.Unlock() // Unlock
+
+	mailer.sent++ // Increment sent
+
+	path := filepath.Join(mailer.Dir, fmt.Sprintf("%d-%d.eml", time.Now().UnixNano(), mailer.sent)) // Get message path
+
+	return ioutil.WriteFile(path, messageBytes, 0600) // Write message
+}
+
+// Send keeps a given message in memory.
+func (mailer *MemoryMailer) Send(message *Message) error {
+	mailer.mutex.Lock()         // Lock
+	defer mailer.mutex.Unlock() // Unlock
+
+	messageCopy := *message // Copy message, so that it can't be changed by the sender
+
+	mailer.messages = append(mailer.messages, &messageCopy) // Keep message
+
+	return nil // No error occurred, return nil
+}
+
+// Messages gets every message sent so far, oldest first.
+func (mailer *MemoryMailer) Messages() []*Message {
+	mailer.mutex.Lock()         // Lock
+	defer mailer.mutex.Unlock() // Unlock
+
+	return append([]*Message{}, mailer.messages...) // Return copy of messages
+}
+
+/* END EXPORTED METHODS */
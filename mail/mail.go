@@ -0,0 +1,71 @@
+// Package mail defines the interface through which the server sends email, along with its SMTP, file and in-memory implementations.
+package mail
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	netMail "net/mail"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrAddressInvalid is an error definition describing a malformed email address.
+	ErrAddressInvalid = errors.New("invalid email address")
+
+	// ErrHeaderInvalid is an error definition describing a message header containing a line break.
+	ErrHeaderInvalid = errors.New("message header must not contain line breaks")
+)
+
+// Mailer defines the methods that a mail backend must implement.
+type Mailer interface {
+	Send(message *Message) error // Send delivers a given message.
+}
+
+// Message represents a single plain-text email.
+type Message struct {
+	To string `json:"to"` // Recipient address
+
+	Subject string `json:"subject"` // Subject line
+
+	Body string `json:"body"` // Plain-text body
+}
+
+/* BEGIN EXPORTED METHODS */
+
+// ValidateAddress checks that a given string is a bare email address (e.g. "alice@example.com", without a display name).
+func ValidateAddress(address string) error {
+	parsed, err := netMail.ParseAddress(address) // Parse address
+
+	if err != nil || parsed.Name != "" || parsed.Address != address { // Check malformed, or not bare
+		return ErrAddressInvalid // Return error
+	}
+
+	return nil // Valid
+}
+
+// Bytes encodes the message as an RFC 5322 message from a given sender address, as sent over SMTP or written to a file.
+func (message *Message) Bytes(from string) ([]byte, error) {
+	for _, header := range []string{from, message.To, message.Subject} { // Iterate through header values
+		if strings.ContainsAny(header, "\r\n") { // Check would inject headers
+			return nil, ErrHeaderInvalid // Return error
+		}
+	}
+
+	buffer := new(bytes.Buffer) // Init message buffer
+
+	fmt.Fprintf(buffer, "From: %s\r\n", from)                             // Write sender
+	fmt.Fprintf(buffer, "To: %s\r\n", message.To)                         // Write recipient
+	fmt.Fprintf(buffer, "Subject: %s\r\n", message.Subject)               // Write subject
+	fmt.Fprintf(buffer, "Date: %s\r\n", time.Now().Format(time.RFC1123Z)) // Write date
+	fmt.Fprintf(buffer, "MIME-Version: 1.0\r\n")                          // Write MIME version
+	fmt.Fprintf(buffer, "Content-Type: text/plain; charset=UTF-8\r\n")    // Write content type
+	fmt.Fprintf(buffer, "\r\n")                                           // End headers
+
+	buffer.WriteString(strings.Replace(message.Body, "\n", "\r\n", -1)) // Write body with CRLF line endings
+
+	return buffer.Bytes(), nil // Return message
+}
+
+/* END EXPORTED METHODS */
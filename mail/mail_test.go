@@ -0,0 +1,164 @@
+// Package mail defines the interface through which the server sends email, along with its SMTP, file and in-memory implementations.
+package mail
+
+import (
+	"bufio"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+/* BEGIN EXPORTED METHODS TESTS */
+
+// TestValidateAddress tests that ValidateAddress() only accepts bare email addresses.
+func TestValidateAddress(t *testing.T) {
+	if err := ValidateAddress("alice@example.com"); err != nil { // Validate bare address
+		t.Fatal(err) // Panic
+	}
+
+	for _, address := range []string{"", "alice", "Alice <alice@example.com>", "alice@example.com\r\nBcc: eve@example.com"} { // Iterate through invalid addresses
+		if err := ValidateAddress(address); err != ErrAddressInvalid { // Validate address
+			t.Fatalf("expected ErrAddressInvalid for %q, got %v", address, err) // Panic
+		}
+	}
+}
+
+// TestMailers tests that each Mailer implementation delivers a message, and that header injection is refused.
+func TestMailers(t *testing.T) {
+	message := &Message{To: "alice@example.com", Subject: "Test", Body: "line one\nline two"} // Init message
+
+	if _, err := (&Message{To: "alice@example.com", Subject: "Test\r\nBcc: eve@example.com"}).Bytes("server@example.com"); err != ErrHeaderInvalid { // Encode injected header
+		t.Fatalf("expected ErrHeaderInvalid, got %v", err) // Panic
+	}
+
+	memoryMailer := &MemoryMailer{} // Init memory mailer
+
+	if err := memoryMailer.Send(message); err != nil { // Send message
+		t.Fatal(err) // Panic
+	}
+
+	if messages := memoryMailer.Messages(); len(messages) != 1 || *messages[0] != *message { // Check kept
+		t.Fatalf("expected message to be kept in memory, got %d messages", len(messages)) // Panic
+	}
+
+	dir, err := ioutil.TempDir("", "summercash-mail") // Make temp dir
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	defer os.RemoveAll(dir) // Remove temp dir
+
+	fileMailer, err := NewFileMailer(filepath.Join(dir, "outbox"), "server@example.com") // Init file mailer
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	for i := 0; i < 2; i++ { // Send twice
+		if err = fileMailer.Send(message); err != nil { // Send message
+			t.Fatal(err) // Panic
+		}
+	}
+
+	files, err := ioutil.ReadDir(fileMailer.Dir) // List messages
+
+	if err != nil || len(files) != 2 { // Check both written
+		t.Fatalf("expected 2 message files, got %d (%v)", len(files), err) // Panic
+	}
+
+	written, err := ioutil.ReadFile(filepath.Join(fileMailer.Dir, files[0].Name())) // Read message
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	if !strings.Contains(string(written), "To: alice@example.com\r\n") || !strings.HasSuffix(string(written), "line one\r\nline two") { // Check encoded
+		t.Fatalf("unexpected message file %q", written) // Panic
+	}
+
+	listener, received := newTestSMTPServer(t) // Start SMTP server
+
+	defer listener.Close() // Stop SMTP server
+
+	smtpMailer, err := NewSMTPMailer(listener.Addr().String(), "server@example.com", "", "") // Init SMTP mailer
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	if err = smtpMailer.Send(message); err != nil { // Send message
+		t.Fatal(err) // Panic
+	}
+
+	if data := <-received; !strings.Contains(data, "Subject: Test\r\n") || !strings.Contains(data, "line two") { // Check delivered
+		t.Fatalf("unexpected SMTP message %q", data) // Panic
+	}
+}
+
+/* END EXPORTED METHODS TESTS */
+
+/* BEGIN TEST HELPERS */
+
+// newTestSMTPServer starts a minimal SMTP server on localhost, which accepts a single message and sends its data to the returned channel.
+func newTestSMTPServer(t *testing.T) (net.Listener, chan string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0") // Listen on random port
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	received := make(chan string, 1) // Init received channel
+
+	go func() {
+		conn, err := listener.Accept() // Accept connection
+
+		if err != nil { // Check for errors
+			return // Stop
+		}
+
+		defer conn.Close() // Close connection
+
+		reader := bufio.NewReader(conn) // Init reader
+
+		conn.Write([]byte("220 localhost\r\n")) // Greet
+
+		for {
+			line, err := reader.ReadString('\n') // Read command
+
+			if err != nil { // Check for errors
+				return // Stop
+			}
+
+			switch command := strings.ToUpper(strings.TrimSpace(line)); {
+			case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+				conn.Write([]byte("250 localhost\r\n")) // Greet back, without extensions
+			case command == "DATA":
+				conn.Write([]byte("354 go ahead\r\n")) // Accept data
+
+				data := "" // Init data buffer
+
+				for line, err = reader.ReadString('\n'); err == nil && line != ".\r\n"; line, err = reader.ReadString('\n') { // Read until terminator
+					data += line // Append line
+				}
+
+				received <- data // Send data
+
+				conn.Write([]byte("250 ok\r\n")) // Acknowledge message
+			case command == "QUIT":
+				conn.Write([]byte("221 bye\r\n")) // Say goodbye
+
+				return // Stop
+			default:
+				conn.Write([]byte("250 ok\r\n")) // Acknowledge command
+			}
+		}
+	}() // Serve single connection
+
+	return listener, received // Return listener and channel
+}
+
+/* END TEST HELPERS */
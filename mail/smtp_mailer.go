@@ -0,0 +1,56 @@
+// Package mail defines the interface through which the server sends email, along with its SMTP, file and in-memory implementations.
+package mail
+
+import (
+	"net"
+	"net/smtp"
+)
+
+// SMTPMailer is a Mailer delivering messages through an SMTP server.
+type SMTPMailer struct {
+	Addr string // SMTP server address (host:port)
+
+	From string // Sender address
+
+	auth smtp.Auth // Server credentials (nil if the server doesn't require authentication)
+}
+
+/* BEGIN EXPORTED METHODS */
+
+// NewSMTPMailer initializes a new SMTP mailer sending from a given address through the SMTP server at a given address (host:port).
+// If a username is given, the mailer authenticates with it and a given password (which net/smtp only sends over TLS, or to localhost).
+func NewSMTPMailer(addr string, from string, username string, password string) (*SMTPMailer, error) {
+	if err := ValidateAddress(from); err != nil { // Check invalid sender
+		return nil, err // Return found error
+	}
+
+	host, _, err := net.SplitHostPort(addr) // Get server host
+
+	if err != nil { // Check for errors
+		return nil, err // Return found error
+	}
+
+	mailer := &SMTPMailer{
+		Addr: addr, // Set address
+		From: from, // Set sender
+	} // Init mailer
+
+	if username != "" { // Check has credentials
+		mailer.auth = smtp.PlainAuth("", username, password, host) // Set auth
+	}
+
+	return mailer, nil // Return mailer
+}
+
+// Send delivers a given message through the SMTP server.
+func (mailer *SMTPMailer) Send(message *Message) error {
+	messageBytes, err := message.Bytes(mailer.From) // Encode message
+
+	if err != nil { // Check for errors
+		return err // Return found error
+	}
+
+	return smtp.SendMail(mailer.Addr, mailer.auth, mailer.From, []string{message.To}, messageBytes) // Send message
+}
+
+/* END EXPORTED METHODS */
@@ -2,13 +2,19 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"errors"
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"math/big"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strings"
 	"syscall"
 	"time"
 
@@ -22,20 +28,44 @@ import (
 	"github.com/SummerCash/go-summercash/validator"
 	"github.com/SummerCash/summercash-wallet-server/accounts"
 	"github.com/SummerCash/summercash-wallet-server/api/standardapi"
+	"github.com/SummerCash/summercash-wallet-server/backup"
 	"github.com/SummerCash/summercash-wallet-server/common"
+	"github.com/SummerCash/summercash-wallet-server/crypto"
 	"github.com/SummerCash/summercash-wallet-server/faucet"
+	"github.com/SummerCash/summercash-wallet-server/mail"
 )
 
 var (
-	nodeRPCPortFlag   = flag.Int("node-rpc-port", 8080, "starts the go-summercash RPC server on a given port")      // Init node rpc port flag
-	nodePortFlag      = flag.Int("node-port", 3000, "starts the go-summercash node on a given port")                // Init node port flag
-	networkFlag       = flag.String("network", "main_net", "starts the go-summercash node on a given network")      // Init network flag
-	apiPortFlag       = flag.Int("api-port", 2053, "starts api on given port")                                      // Init API port flag
-	contentDirFlag    = flag.String("content-dir", filepath.FromSlash("./app"), "serves a given content directory") // Init content dir flag
-	dataDirFlag       = flag.String("data-dir", common.DataDir, "starts node with given data directory")            // Init data dir flag
-	faucetRewardFlag  = flag.Float64("faucet-reward", 0.00001, "starts faucet api with a given reward amount")      // Init faucet reward flag
-	useRemoteNodeFlag = flag.Bool("use-remote-node", false, "skips node start, assumes remote node is up to date")  // Init remote node flag
-	useWebSocket      = flag.Bool("use-websocket", false, "uses websockets for the API")                            // Init use websocket flag
+	nodeRPCPortFlag   = flag.Int("node-rpc-port", 8080, "starts the go-summercash RPC server on a given port")                             // Init node rpc port flag
+	nodePortFlag      = flag.Int("node-port", 3000, "starts the go-summercash node on a given port")                                       // Init node port flag
+	networkFlag       = flag.String("network", "main_net", "starts the go-summercash node on a given network")                             // Init network flag
+	apiPortFlag       = flag.Int("api-port", 2053, "starts api on given port")                                                             // Init API port flag
+	contentDirFlag    = flag.String("content-dir", filepath.FromSlash("./app"), "serves a given content directory")                        // Init content dir flag
+	dataDirFlag       = flag.String("data-dir", common.DataDir, "starts node with given data directory")                                   // Init data dir flag
+	faucetRewardFlag  = flag.Float64("faucet-reward", 0.00001, "starts faucet api with a given reward amount")                             // Init faucet reward flag
+	useRemoteNodeFlag = flag.Bool("use-remote-node", false, "skips node start, assumes remote node is up to date")                         // Init remote node flag
+	useWebSocket      = flag.Bool("use-websocket", false, "uses websockets for the API")                                                   // Init use websocket flag
+	passwordHashFlag  = flag.String("password-hash", "argon2id", "hashes new passwords with a given algorithm (argon2id or bcrypt)")       // Init password hash flag
+	bcryptCostFlag    = flag.Int("bcrypt-cost", 12, "hashes new passwords with a given bcrypt cost")                                       // Init bcrypt cost flag
+	migrateDryRunFlag = flag.Bool("migrate-dry-run", false, "reports pending db migrations without applying them")                         // Init migrate dry run flag
+	masterKeyFileFlag = flag.String("master-key-file", "", "wraps private keys under the hex-encoded master key in a given file")          // Init master key file flag
+	faucetPassFlag    = flag.String("faucet-passphrase-file", "", "decrypts the faucet keystore with the passphrase in a given file")      // Init faucet passphrase file flag
+	faucetImportFlag  = flag.String("faucet-import-key", "", "creates the faucet keystore from the PEM private key in a given file")       // Init faucet import key flag
+	backupToFlag      = flag.String("backup-to", "", "writes a backup archive of the db and keystores to a given directory, then exits")   // Init backup flag
+	restoreFromFlag   = flag.String("restore-from", "", "restores the db and keystores from a given backup archive, then exits")           // Init restore flag
+	dbBackendFlag     = flag.String("db-backend", "bolt", "stores accounts in a given db backend (bolt or sqlite)")                        // Init db backend flag
+	reserveNamesFlag  = flag.String("reserve-usernames", "", "reserves a given comma-separated list of additional usernames")              // Init reserved usernames flag
+	twoFactorOpsFlag  = flag.String("2fa-operations", "send,export_key,delete_account", "requires a second factor for given operations")   // Init 2fa operations flag
+	deleteGraceFlag   = flag.Duration("deletion-grace", 30*24*time.Hour, "keeps deleted accounts restorable for a given duration")         // Init deletion grace flag
+	destroyKeysFlag   = flag.Bool("destroy-deleted-keys", false, "destroys the keystores of purged accounts instead of archiving them")    // Init destroy deleted keys flag
+	adminUsersFlag    = flag.String("admin-usernames", "", "grants the admin role to a given comma-separated list of usernames")           // Init admin usernames flag
+	migrateDBToFlag   = flag.String("migrate-db-to", "", "copies the db into an empty db of a given backend (bolt or sqlite), then exits") // Init migrate db flag
+	limitCooldownFlag = flag.Duration("spending-limit-cooldown", 48*time.Hour, "delays raised spending limits by a given duration")        // Init spending limit cooldown flag
+	smtpServerFlag    = flag.String("smtp-server", "", "sends email through the SMTP server at a given address (host:port)")               // Init SMTP server flag
+	smtpUserFlag      = flag.String("smtp-username", "", "authenticates with the SMTP server as a given username")                         // Init SMTP username flag
+	smtpPassFileFlag  = flag.String("smtp-password-file", "", "authenticates with the SMTP server with the password in a given file")      // Init SMTP password file flag
+	mailFromFlag      = flag.String("mail-from", "", "sends email from a given address")                                                   // Init mail sender flag
+	mailDirFlag       = flag.String("mail-dir", "", "writes email to a given directory instead of sending it")                             // Init mail dir flag
 
 	logger = loggo.GetLogger("") // Get logger
 
@@ -60,6 +90,88 @@ func main() {
 		panic(err) // Panic
 	}
 
+	crypto.DefaultPasswordHasher, err = crypto.NewPasswordHasher(*passwordHashFlag, *bcryptCostFlag) // Configure password hasher
+
+	if err != nil { // Check for errors
+		logger.Criticalf("main panicked: %s", err.Error()) // Log pending panic
+
+		os.Exit(1) // Return
+	}
+
+	err = configureMasterKey() // Configure master key
+
+	if err != nil { // Check for errors
+		logger.Criticalf("main panicked: %s", err.Error()) // Log pending panic
+
+		os.Exit(1) // Return
+	}
+
+	err = configureMailer() // Configure mailer
+
+	if err != nil { // Check for errors
+		logger.Criticalf("main panicked: %s", err.Error()) // Log pending panic
+
+		os.Exit(1) // Return
+	}
+
+	accounts.Backend = *dbBackendFlag // Set db backend
+
+	if *reserveNamesFlag != "" { // Check has extra reserved usernames
+		accounts.ReservedUsernames = append(accounts.ReservedUsernames, strings.Split(*reserveNamesFlag, ",")...) // Reserve usernames
+	}
+
+	accounts.SecondFactorOperations, err = accounts.ParseOperations(*twoFactorOpsFlag) // Set operations requiring a second factor
+
+	if err != nil { // Check for errors
+		logger.Criticalf("main panicked: %s", err.Error()) // Log pending panic
+
+		os.Exit(1) // Return
+	}
+
+	accounts.DeletionGracePeriod = *deleteGraceFlag      // Set deletion grace period
+	accounts.ArchiveDeletedKeystores = !*destroyKeysFlag // Set archive deleted keystores
+	accounts.SpendingLimitCooldown = *limitCooldownFlag  // Set spending limit cooldown
+
+	if *adminUsersFlag != "" { // Check has admin usernames
+		accounts.AdminUsernames = strings.Split(*adminUsersFlag, ",") // Set admin usernames
+	}
+
+	if *migrateDBToFlag != "" { // Check only migrating db backend
+		err = accounts.MigrateBackend(accounts.Backend, *migrateDBToFlag) // Migrate db
+
+		if err != nil { // Check for errors
+			logger.Criticalf("main panicked: %s", err.Error()) // Log pending panic
+
+			os.Exit(1) // Return
+		}
+
+		return // Done
+	}
+
+	if *migrateDryRunFlag { // Check only reporting migrations
+		err = dryRunMigrations() // Dry run migrations
+
+		if err != nil { // Check for errors
+			logger.Criticalf("main panicked: %s", err.Error()) // Log pending panic
+
+			os.Exit(1) // Return
+		}
+
+		return // Done
+	}
+
+	if *backupToFlag != "" || *restoreFromFlag != "" { // Check only backing up or restoring
+		err = backupOrRestore() // Back up or restore
+
+		if err != nil { // Check for errors
+			logger.Criticalf("main panicked: %s", err.Error()) // Log pending panic
+
+			os.Exit(1) // Return
+		}
+
+		return // Done
+	}
+
 	err = startSummercashRPCServer() // Start summercash RPC server
 
 	if err != nil { // Check for errors
@@ -106,6 +218,169 @@ func configureLogger() error {
 	return nil // No error occurred, return nil
 }
 
+// configureMasterKey loads the optional server master key from the WALLET_MASTER_KEY env variable, or the master key file.
+func configureMasterKey() error {
+	encodedKey := os.Getenv("WALLET_MASTER_KEY") // Get master key from env
+
+	if *masterKeyFileFlag != "" { // Check has master key file
+		keyBytes, err := ioutil.ReadFile(filepath.FromSlash(*masterKeyFileFlag)) // Read master key file
+
+		if err != nil { // Check for errors
+			return err // Return found error
+		}
+
+		encodedKey = string(keyBytes) // Set encoded key
+	}
+
+	if encodedKey = strings.TrimSpace(encodedKey); encodedKey == "" { // Check no master key
+		return nil // Nothing to configure
+	}
+
+	masterKey, err := hex.DecodeString(encodedKey) // Decode master key
+
+	if err != nil { // Check for errors
+		return err // Return found error
+	}
+
+	if len(masterKey) != crypto.KeySize { // Check invalid key size
+		return fmt.Errorf("master key must be %d bytes", crypto.KeySize) // Return error
+	}
+
+	accounts.MasterKey = masterKey // Set master key
+
+	return nil // No error occurred, return nil
+}
+
+// configureMailer configures the optional mailer verification and security emails are sent through: the SMTP server, authenticated
+// with the password in the SMTP_PASSWORD env variable or the SMTP password file, or the mail directory.
+func configureMailer() error {
+	if *smtpServerFlag == "" && *mailDirFlag == "" { // Check no mailer
+		return nil // Nothing to configure
+	}
+
+	if *smtpServerFlag != "" && *mailDirFlag != "" { // Check conflicting mailers
+		return errors.New("only one of --smtp-server and --mail-dir may be set") // Return error
+	}
+
+	if *mailDirFlag != "" { // Check writing mail to directory
+		mailer, err := mail.NewFileMailer(filepath.FromSlash(*mailDirFlag), *mailFromFlag) // Init file mailer
+
+		if err != nil { // Check for errors
+			return err // Return found error
+		}
+
+		accounts.Mailer = mailer // Set mailer
+
+		return nil // No error occurred, return nil
+	}
+
+	password := os.Getenv("SMTP_PASSWORD") // Get password from env
+
+	if *smtpPassFileFlag != "" { // Check has password file
+		passwordBytes, err := ioutil.ReadFile(filepath.FromSlash(*smtpPassFileFlag)) // Read password file
+
+		if err != nil { // Check for errors
+			return err // Return found error
+		}
+
+		password = strings.TrimRight(string(passwordBytes), "\r\n") // Set password
+	}
+
+	mailer, err := mail.NewSMTPMailer(*smtpServerFlag, *mailFromFlag, *smtpUserFlag, password) // Init SMTP mailer
+
+	if err != nil { // Check for errors
+		return err // Return found error
+	}
+
+	accounts.Mailer = mailer // Set mailer
+
+	return nil // No error occurred, return nil
+}
+
+// loadFaucetKey loads the faucet signing key, decrypting the faucet keystore with the passphrase in the FAUCET_PASSPHRASE env variable,
+// or the faucet passphrase file.
+func loadFaucetKey(db *accounts.DB) (*ecdsa.PrivateKey, error) {
+	passphrase := []byte(os.Getenv("FAUCET_PASSPHRASE")) // Get passphrase from env
+
+	if *faucetPassFlag != "" { // Check has passphrase file
+		passphraseBytes, err := ioutil.ReadFile(filepath.FromSlash(*faucetPassFlag)) // Read passphrase file
+
+		if err != nil { // Check for errors
+			return nil, err // Return found error
+		}
+
+		passphrase = bytes.TrimRight(passphraseBytes, "\r\n") // Set passphrase
+	}
+
+	if len(passphrase) == 0 { // Check no passphrase
+		return nil, errors.New("a faucet passphrase must be set with FAUCET_PASSPHRASE or --faucet-passphrase-file") // Return error
+	}
+
+	var importKey *ecdsa.PrivateKey // Init import key buffer
+
+	if *faucetImportFlag != "" { // Check has import key
+		keyBytes, err := ioutil.ReadFile(filepath.FromSlash(*faucetImportFlag)) // Read import key
+
+		if err != nil { // Check for errors
+			return nil, err // Return found error
+		}
+
+		if importKey, err = crypto.ParsePrivateKey(keyBytes); err != nil { // Parse import key
+			return nil, err // Return found error
+		}
+	}
+
+	return faucet.LoadFaucetKey(db, passphrase, importKey) // Load faucet key
+}
+
+// backupOrRestore restores a backup archive, or writes a new one, according to the backup flags.
+func backupOrRestore() error {
+	if *restoreFromFlag != "" { // Check should restore
+		manifest, err := backup.Restore(filepath.FromSlash(*restoreFromFlag)) // Restore
+
+		if err != nil { // Check for errors
+			return err // Return found error
+		}
+
+		logger.Infof("restored backup taken at %s (schema version %d)", manifest.CreatedAt, manifest.SchemaVersion) // Log restore
+
+		return nil // No error occurred, return nil
+	}
+
+	db, err := accounts.OpenDB() // Open db
+
+	if err != nil { // Check for errors
+		return err // Return found error
+	}
+
+	defer db.CloseDB() // Close db
+
+	_, _, err = backup.CreateFile(db, *backupToFlag) // Write backup
+
+	return err // Return error
+}
+
+// dryRunMigrations logs the db migrations that would be applied on the next start.
+func dryRunMigrations() error {
+	migrations, err := accounts.DryRunMigrations() // Dry run migrations
+
+	if err != nil { // Check for errors
+		return err // Return found error
+	}
+
+	if len(migrations) == 0 { // Check up to date
+		logger.Infof("db is up to date at schema version %d", accounts.CurrentSchemaVersion()) // Log up to date
+
+		return nil // No error occurred, return nil
+	}
+
+	for _, migration := range migrations { // Iterate through pending migrations
+		logger.Infof("pending migration %d: %s", migration.Version, migration.Description) // Log migration
+	}
+
+	return nil // No error occurred, return nil
+}
+
 // startSummercashRPCServer starts the go-summercash RPC server.
 func startSummercashRPCServer() error {
 	return rpc.StartRPCServer(*nodeRPCPortFlag) // Start RPC server
@@ -166,14 +441,20 @@ func startServingStandardHTTPJSONAPI() error {
 		return err // Return found error
 	}
 
-	c := make(chan os.Signal) // Get control c
+	err = db.BootstrapAdmins() // Grant the admin role to the configured admin usernames
+
+	if err != nil { // Check for errors
+		return err // Return found error
+	}
+
+	c := make(chan os.Signal, 1) // Get control c
 
 	signal.Notify(c, os.Interrupt, syscall.SIGTERM) // Notify
 
 	go func() {
 		<-c // Wait for ^c
 
-		err = db.DB.Close() // Close dag
+		err = db.CloseDB() // Close dag
 
 		if err != nil { // Check for errors
 			logger.Criticalf("db close errored: %s", err.Error()) // Return found error
@@ -186,9 +467,18 @@ func startServingStandardHTTPJSONAPI() error {
 		os.Exit(0) // Exit
 	}()
 
+	go db.StartIntermittentTokenCleanup(1 * time.Hour)  // Start purging expired tokens
+	go db.StartIntermittentDeletionPurge(1 * time.Hour) // Start purging deleted accounts
+
 	ruleset := faucet.NewStandardRuleset(big.NewFloat(*faucetRewardFlag), 6*time.Hour, []*accounts.Account{}) // Initialize ruleset
 
-	standardFaucet := faucet.NewStandardFaucet(ruleset, db) // Initialize faucet
+	faucetKey, err := loadFaucetKey(db) // Load faucet key
+
+	if err != nil { // Check for errors
+		return err // Return found error
+	}
+
+	standardFaucet := faucet.NewStandardFaucet(ruleset, db, faucetKey) // Initialize faucet
 
 	abstractFaucet := faucet.Faucet(standardFaucet) // Get interface
 
@@ -0,0 +1,133 @@
+// Package storage defines the transactional key-value store interface backing the accounts database,
+// along with its bolt and SQLite implementations.
+package storage
+
+import (
+	"time"
+
+	"github.com/boltdb/bolt"
+)
+
+// BoltStore is a Store backed by a boltdb file.
+type BoltStore struct {
+	db *bolt.DB // Underlying bolt db
+}
+
+// boltTx is a Tx backed by a bolt transaction.
+type boltTx struct {
+	tx *bolt.Tx // Underlying bolt transaction
+}
+
+// boltBucket is a Bucket backed by a bolt bucket.
+type boltBucket struct {
+	*bolt.Bucket // Underlying bolt bucket
+}
+
+/* BEGIN EXPORTED METHODS */
+
+// OpenBoltStore opens the bolt store at a given path, creating it if it does not already exist.
+func OpenBoltStore(path string) (*BoltStore, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second}) // Open db with timeout
+
+	if err != nil { // Check for errors
+		return nil, err // Return found error
+	}
+
+	return &BoltStore{
+		db: db, // Set db
+	}, nil // Return store
+}
+
+// View runs a given function in a read-only transaction.
+func (store *BoltStore) View(fn func(tx Tx) error) error {
+	return store.db.View(func(tx *bolt.Tx) error {
+		return fn(&boltTx{tx: tx}) // Run function
+	}) // Run in bolt transaction
+}
+
+// Update runs a given function in a read-write transaction, which is rolled back if the function errors.
+func (store *BoltStore) Update(fn func(tx Tx) error) error {
+	return store.db.Update(func(tx *bolt.Tx) error {
+		return fn(&boltTx{tx: tx}) // Run function
+	}) // Run in bolt transaction
+}
+
+// Check checks the consistency of the bolt file.
+func (store *BoltStore) Check() error {
+	return store.db.View(func(tx *bolt.Tx) error {
+		var checkErr error // Init check error buffer
+
+		for err := range tx.Check() { // Check consistency (the channel must be drained)
+			if checkErr == nil { // Check first error
+				checkErr = err // Set error
+			}
+		}
+
+		return checkErr // Return error
+	}) // Check db
+}
+
+// Close closes the bolt file.
+func (store *BoltStore) Close() error {
+	return store.db.Close() // Close db
+}
+
+// SetNoSync sets whether the bolt file is fsynced after each commit. Only disposable stores (e.g. in tests) should skip syncing.
+func (store *BoltStore) SetNoSync(noSync bool) {
+	store.db.NoSync = noSync // Set no sync
+}
+
+/* END EXPORTED METHODS */
+
+/* BEGIN INTERNAL METHODS */
+
+// Bucket gets the bucket with a given name, or nil if it does not exist.
+func (tx *boltTx) Bucket(name []byte) Bucket {
+	if bucket := tx.tx.Bucket(name); bucket != nil { // Check exists
+		return &boltBucket{Bucket: bucket} // Return bucket
+	}
+
+	return nil // Bucket does not exist
+}
+
+// CreateBucketIfNotExists creates a bucket with a given name if it does not already exist.
+func (tx *boltTx) CreateBucketIfNotExists(name []byte) (Bucket, error) {
+	bucket, err := tx.tx.CreateBucketIfNotExists(name) // Create bucket
+
+	if err != nil { // Check for errors
+		return nil, err // Return found error
+	}
+
+	return &boltBucket{Bucket: bucket}, nil // Return bucket
+}
+
+// DeleteBucket deletes the bucket with a given name, along with all of its keys.
+func (tx *boltTx) DeleteBucket(name []byte) error {
+	if err := tx.tx.DeleteBucket(name); err != bolt.ErrBucketNotFound { // Check bucket existed
+		return err // Return error
+	}
+
+	return ErrBucketNotFound // Return error
+}
+
+// ForEachBucket calls a given function for each bucket, in ascending name order.
+func (tx *boltTx) ForEachBucket(fn func(name []byte, bucket Bucket) error) error {
+	return tx.tx.ForEach(func(name []byte, bucket *bolt.Bucket) error {
+		return fn(name, &boltBucket{Bucket: bucket}) // Run function
+	}) // Iterate through buckets
+}
+
+// ForEachFrom calls a given function for each key at or after a given key, in ascending key order.
+func (bucket *boltBucket) ForEachFrom(start []byte, fn func(key []byte, value []byte) error) error {
+	cursor := bucket.Cursor() // Init cursor
+
+	for key, value := cursor.Seek(start); key != nil; key, value = cursor.Next() { // Iterate from start
+		if err := fn(key, value); err != nil { // Run function
+			return err // Return found error
+		}
+	}
+
+	return nil // No error occurred, return nil
+}
+
+/* END INTERNAL METHODS */
@@ -0,0 +1,303 @@
+// Package storage defines the transactional key-value store interface backing the accounts database,
+// along with its bolt and SQLite implementations.
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+
+	_ "github.com/mattn/go-sqlite3" // Register SQLite driver
+)
+
+// SQLiteStore is a Store backed by a SQLite database, holding every bucket in a single key-value table.
+type SQLiteStore struct {
+	db *sql.DB // Underlying SQLite db
+}
+
+// sqliteTx is a Tx backed by a SQLite transaction.
+type sqliteTx struct {
+	tx *sql.Tx // Underlying SQLite transaction
+
+	err error // First error encountered by a method unable to return it (e.g. Get); fails the transaction
+}
+
+// sqliteBucket is a Bucket backed by the rows of the key-value table with a given bucket name.
+type sqliteBucket struct {
+	tx *sqliteTx // Owning transaction
+
+	name []byte // Bucket name
+}
+
+// sqliteSchema creates the SQLite tables if they do not already exist.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS buckets (
+	name BLOB NOT NULL PRIMARY KEY
+) WITHOUT ROWID;
+
+CREATE TABLE IF NOT EXISTS kv (
+	bucket BLOB NOT NULL REFERENCES buckets (name) ON DELETE CASCADE,
+	key    BLOB NOT NULL,
+	value  BLOB NOT NULL,
+	PRIMARY KEY (bucket, key)
+) WITHOUT ROWID;
+`
+
+/* BEGIN EXPORTED METHODS */
+
+// OpenSQLiteStore opens the SQLite store at a given path, creating it if it does not already exist.
+func OpenSQLiteStore(path string) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?_busy_timeout=5000&_journal_mode=WAL&_foreign_keys=on", path)) // Open db
+
+	if err != nil { // Check for errors
+		return nil, err // Return found error
+	}
+
+	db.SetMaxOpenConns(1) // Serialize transactions, as with bolt's single writer
+
+	if _, err = db.Exec(sqliteSchema); err != nil { // Create tables
+		db.Close() // Close db
+
+		return nil, err // Return found error
+	}
+
+	return &SQLiteStore{
+		db: db, // Set db
+	}, nil // Return store
+}
+
+// View runs a given function in a read-only transaction.
+func (store *SQLiteStore) View(fn func(tx Tx) error) error {
+	return store.run(fn, false) // Run function, always rolling back
+}
+
+// Update runs a given function in a read-write transaction, which is rolled back if the function errors.
+func (store *SQLiteStore) Update(fn func(tx Tx) error) error {
+	return store.run(fn, true) // Run function, committing on success
+}
+
+// Check runs the SQLite integrity check.
+func (store *SQLiteStore) Check() error {
+	var result string // Init result buffer
+
+	if err := store.db.QueryRow("PRAGMA integrity_check").Scan(&result); err != nil { // Run integrity check
+		return err // Return found error
+	}
+
+	if result != "ok" { // Check failed
+		return ErrIntegrityCheckFailed // Return error
+	}
+
+	return nil // No error occurred, return nil
+}
+
+// Close closes the SQLite db.
+func (store *SQLiteStore) Close() error {
+	return store.db.Close() // Close db
+}
+
+/* END EXPORTED METHODS */
+
+/* BEGIN INTERNAL METHODS */
+
+// run runs a given function in a transaction, committing it if the function succeeds and commit is set.
+func (store *SQLiteStore) run(fn func(tx Tx) error, commit bool) error {
+	sqlTx, err := store.db.Begin() // Begin transaction
+
+	if err != nil { // Check for errors
+		return err // Return found error
+	}
+
+	tx := &sqliteTx{tx: sqlTx} // Init tx
+
+	if err = fn(tx); err == nil { // Run function
+		err = tx.err // Set deferred error
+	}
+
+	if err != nil || !commit { // Check should roll back
+		sqlTx.Rollback() // Roll back
+
+		return err // Return error
+	}
+
+	return sqlTx.Commit() // Commit
+}
+
+// fail records the first error encountered by a method unable to return it.
+func (tx *sqliteTx) fail(err error) {
+	if tx.err == nil { // Check first error
+		tx.err = err // Set error
+	}
+}
+
+// Bucket gets the bucket with a given name, or nil if it does not exist.
+func (tx *sqliteTx) Bucket(name []byte) Bucket {
+	var exists int // Init exists buffer
+
+	err := tx.tx.QueryRow("SELECT 1 FROM buckets WHERE name = ?", name).Scan(&exists) // Check bucket exists
+
+	if err == sql.ErrNoRows { // Check does not exist
+		return nil // Bucket does not exist
+	} else if err != nil { // Check for errors
+		tx.fail(err) // Fail transaction
+
+		return nil // Bucket could not be read
+	}
+
+	return &sqliteBucket{tx: tx, name: name} // Return bucket
+}
+
+// CreateBucketIfNotExists creates a bucket with a given name if it does not already exist.
+func (tx *sqliteTx) CreateBucketIfNotExists(name []byte) (Bucket, error) {
+	if _, err := tx.tx.Exec("INSERT OR IGNORE INTO buckets (name) VALUES (?)", name); err != nil { // Create bucket
+		return nil, err // Return found error
+	}
+
+	return &sqliteBucket{tx: tx, name: name}, nil // Return bucket
+}
+
+// DeleteBucket deletes the bucket with a given name, along with all of its keys.
+func (tx *sqliteTx) DeleteBucket(name []byte) error {
+	result, err := tx.tx.Exec("DELETE FROM buckets WHERE name = ?", name) // Delete bucket (keys cascade)
+
+	if err != nil { // Check for errors
+		return err // Return found error
+	}
+
+	if deleted, err := result.RowsAffected(); err != nil || deleted == 0 { // Check bucket did not exist
+		if err != nil { // Check for errors
+			return err // Return found error
+		}
+
+		return ErrBucketNotFound // Return error
+	}
+
+	return nil // No error occurred, return nil
+}
+
+// ForEachBucket calls a given function for each bucket, in ascending name order.
+func (tx *sqliteTx) ForEachBucket(fn func(name []byte, bucket Bucket) error) error {
+	names, err := tx.queryColumns("SELECT name, NULL FROM buckets ORDER BY name") // Read bucket names
+
+	if err != nil { // Check for errors
+		return err // Return found error
+	}
+
+	for _, name := range names { // Iterate through buckets
+		if err = fn(name[0], &sqliteBucket{tx: tx, name: name[0]}); err != nil { // Run function
+			return err // Return found error
+		}
+	}
+
+	return nil // No error occurred, return nil
+}
+
+// queryColumns runs a given query returning two blob columns, and reads every resulting row into memory,
+// so that callers may issue further statements while iterating over the results.
+func (tx *sqliteTx) queryColumns(query string, args ...interface{}) ([][2][]byte, error) {
+	rows, err := tx.tx.Query(query, args...) // Run query
+
+	if err != nil { // Check for errors
+		return nil, err // Return found error
+	}
+
+	defer rows.Close() // Close rows
+
+	var results [][2][]byte // Init results buffer
+
+	for rows.Next() { // Iterate through rows
+		var row [2][]byte // Init row buffer
+
+		if err = rows.Scan(&row[0], &row[1]); err != nil { // Scan row
+			return nil, err // Return found error
+		}
+
+		results = append(results, row) // Append row
+	}
+
+	return results, rows.Err() // Return results
+}
+
+// Get gets the value at a given key, or nil if it does not exist.
+func (bucket *sqliteBucket) Get(key []byte) []byte {
+	var value []byte // Init value buffer
+
+	err := bucket.tx.tx.QueryRow("SELECT value FROM kv WHERE bucket = ? AND key = ?", bucket.name, key).Scan(&value) // Read value
+
+	if err == sql.ErrNoRows { // Check does not exist
+		return nil // Key does not exist
+	} else if err != nil { // Check for errors
+		bucket.tx.fail(err) // Fail transaction
+
+		return nil // Value could not be read
+	}
+
+	if value == nil { // Check empty value
+		return []byte{} // Distinguish empty values from missing keys
+	}
+
+	return value // Return value
+}
+
+// Put sets the value at a given key.
+func (bucket *sqliteBucket) Put(key []byte, value []byte) error {
+	if value == nil { // Check nil value
+		value = []byte{} // Store as empty value
+	}
+
+	_, err := bucket.tx.tx.Exec("INSERT INTO kv (bucket, key, value) VALUES (?, ?, ?) ON CONFLICT (bucket, key) DO UPDATE SET value = excluded.value", bucket.name, key, value) // Upsert value
+
+	return err // Return error
+}
+
+// Delete deletes a given key.
+func (bucket *sqliteBucket) Delete(key []byte) error {
+	_, err := bucket.tx.tx.Exec("DELETE FROM kv WHERE bucket = ? AND key = ?", bucket.name, key) // Delete key
+
+	return err // Return error
+}
+
+// ForEach calls a given function for each key, in ascending key order.
+func (bucket *sqliteBucket) ForEach(fn func(key []byte, value []byte) error) error {
+	rows, err := bucket.tx.queryColumns("SELECT key, value FROM kv WHERE bucket = ? ORDER BY key", bucket.name) // Read keys
+
+	if err != nil { // Check for errors
+		return err // Return found error
+	}
+
+	for _, row := range rows { // Iterate through keys
+		if err = fn(row[0], row[1]); err != nil { // Run function
+			return err // Return found error
+		}
+	}
+
+	return nil // No error occurred, return nil
+}
+
+// ForEachFrom calls a given function for each key at or after a given key, in ascending key order.
+// Unlike ForEach, rows are read as they are iterated over, so that a function stopping early (e.g. once a page is full) doesn't
+// read the rest of the bucket. The function must not modify the bucket.
+func (bucket *sqliteBucket) ForEachFrom(start []byte, fn func(key []byte, value []byte) error) error {
+	rows, err := bucket.tx.tx.Query("SELECT key, value FROM kv WHERE bucket = ? AND key >= ? ORDER BY key", bucket.name, start) // Run query
+
+	if err != nil { // Check for errors
+		return err // Return found error
+	}
+
+	defer rows.Close() // Close rows
+
+	for rows.Next() { // Iterate through keys
+		var key, value []byte // Init row buffers
+
+		if err = rows.Scan(&key, &value); err != nil { // Scan row
+			return err // Return found error
+		}
+
+		if err = fn(key, value); err != nil { // Run function
+			return err // Return found error
+		}
+	}
+
+	return rows.Err() // Return error (if any)
+}
+
+/* END INTERNAL METHODS */
@@ -0,0 +1,150 @@
+// Package storage defines the transactional key-value store interface backing the accounts database,
+// along with its bolt and SQLite implementations.
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+const (
+	// BackendBolt is the name of the boltdb storage backend.
+	BackendBolt = "bolt"
+
+	// BackendSQLite is the name of the SQLite storage backend.
+	BackendSQLite = "sqlite"
+)
+
+var (
+	// ErrBucketNotFound is an error definition describing an attempt to delete a bucket that does not exist.
+	ErrBucketNotFound = errors.New("bucket not found")
+
+	// ErrUnknownBackend is an error definition describing an unsupported storage backend.
+	ErrUnknownBackend = errors.New("unknown storage backend")
+
+	// ErrIntegrityCheckFailed is an error definition describing a store that failed its integrity check.
+	ErrIntegrityCheckFailed = errors.New("store integrity check failed")
+)
+
+// Store defines the methods that a transactional, bucketed key-value store must implement.
+type Store interface {
+	View(fn func(tx Tx) error) error // View runs a given function in a read-only transaction.
+
+	Update(fn func(tx Tx) error) error // Update runs a given function in a read-write transaction, which is rolled back if the function errors.
+
+	Check() error // Check checks the integrity of the store.
+
+	Close() error // Close closes the store.
+}
+
+// Tx defines the methods that a store transaction must implement.
+type Tx interface {
+	Bucket(name []byte) Bucket // Bucket gets the bucket with a given name, or nil if it does not exist.
+
+	CreateBucketIfNotExists(name []byte) (Bucket, error) // CreateBucketIfNotExists creates a bucket with a given name if it does not already exist.
+
+	DeleteBucket(name []byte) error // DeleteBucket deletes the bucket with a given name, along with all of its keys.
+
+	ForEachBucket(fn func(name []byte, bucket Bucket) error) error // ForEachBucket calls a given function for each bucket, in ascending name order.
+}
+
+// Bucket defines the methods that a bucket of keys must implement.
+// Byte slices returned by a bucket are only valid for the lifetime of the transaction they were read in.
+type Bucket interface {
+	Get(key []byte) []byte // Get gets the value at a given key, or nil if it does not exist.
+
+	Put(key []byte, value []byte) error // Put sets the value at a given key.
+
+	Delete(key []byte) error // Delete deletes a given key.
+
+	ForEach(fn func(key []byte, value []byte) error) error // ForEach calls a given function for each key, in ascending key order.
+
+	ForEachFrom(start []byte, fn func(key []byte, value []byte) error) error // ForEachFrom calls a given function for each key at or after a given key, in ascending key order, stopping once it returns an error. The function must not modify the bucket.
+}
+
+// dumpRecord represents a single key in a store dump.
+type dumpRecord struct {
+	Bucket []byte `json:"bucket"` // Bucket name
+
+	Key []byte `json:"key"` // Key
+
+	Value []byte `json:"value"` // Value
+}
+
+/* BEGIN EXPORTED METHODS */
+
+// Open opens the store with a given backend at a given path, creating it if it does not already exist.
+func Open(backend string, path string) (Store, error) {
+	switch backend {
+	case BackendBolt:
+		return OpenBoltStore(path) // Open bolt store
+	case BackendSQLite:
+		return OpenSQLiteStore(path) // Open SQLite store
+	default:
+		return nil, ErrUnknownBackend // Return error
+	}
+}
+
+// Copy copies every bucket and key of a given source store into a given destination store, in a single transaction.
+func Copy(dst Store, src Store) error {
+	return src.View(func(srcTx Tx) error {
+		return dst.Update(func(dstTx Tx) error {
+			return srcTx.ForEachBucket(func(name []byte, srcBucket Bucket) error {
+				dstBucket, err := dstTx.CreateBucketIfNotExists(name) // Create bucket
+
+				if err != nil { // Check for errors
+					return err // Return found error
+				}
+
+				return srcBucket.ForEach(dstBucket.Put) // Copy keys
+			}) // Copy buckets
+		}) // Write destination
+	}) // Read source
+}
+
+// Dump writes every bucket and key visible in a given transaction to a given writer, as a stream of JSON records.
+func Dump(tx Tx, w io.Writer) error {
+	encoder := json.NewEncoder(w) // Init encoder
+
+	return tx.ForEachBucket(func(name []byte, bucket Bucket) error {
+		if err := encoder.Encode(&dumpRecord{Bucket: name}); err != nil { // Write bucket record, so that empty buckets are kept
+			return err // Return found error
+		}
+
+		return bucket.ForEach(func(key []byte, value []byte) error {
+			return encoder.Encode(&dumpRecord{Bucket: name, Key: key, Value: value}) // Write key record
+		}) // Write keys
+	}) // Write buckets
+}
+
+// Load reads a stream of JSON records written by Dump into a given transaction.
+func Load(tx Tx, r io.Reader) error {
+	decoder := json.NewDecoder(r) // Init decoder
+
+	for {
+		record := &dumpRecord{} // Init record buffer
+
+		if err := decoder.Decode(record); err == io.EOF { // Check done
+			return nil // No error occurred, return nil
+		} else if err != nil { // Check for errors
+			return err // Return found error
+		}
+
+		bucket, err := tx.CreateBucketIfNotExists(record.Bucket) // Create bucket
+
+		if err != nil { // Check for errors
+			return err // Return found error
+		}
+
+		if record.Key == nil { // Check is bucket record
+			continue // Next record
+		}
+
+		if err = bucket.Put(record.Key, record.Value); err != nil { // Put key
+			return err // Return found error
+		}
+	}
+}
+
+/* END EXPORTED METHODS */
@@ -0,0 +1,248 @@
+// Package storage defines the transactional key-value store interface backing the accounts database,
+// along with its bolt and SQLite implementations.
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+/* BEGIN EXPORTED METHODS TESTS */
+
+// TestStore tests the functionality of each backend's Store implementation.
+func TestStore(t *testing.T) {
+	for _, backend := range []string{BackendBolt, BackendSQLite} { // Iterate through backends
+		t.Run(backend, func(t *testing.T) {
+			store := newTestStore(t, backend) // Open store
+
+			err := store.Update(func(tx Tx) error {
+				bucket, err := tx.CreateBucketIfNotExists([]byte("test")) // Create bucket
+
+				if err != nil { // Check for errors
+					return err // Return found error
+				}
+
+				for _, key := range []string{"b", "c", "a"} { // Iterate through keys
+					if err = bucket.Put([]byte(key), []byte(key+"_value")); err != nil { // Put key
+						return err // Return found error
+					}
+				}
+
+				return bucket.Delete([]byte("c")) // Delete key
+			}) // Write keys
+
+			if err != nil { // Check for errors
+				t.Fatal(err) // Panic
+			}
+
+			errRollback := errors.New("rollback") // Init rollback error
+
+			err = store.Update(func(tx Tx) error {
+				if err := tx.Bucket([]byte("test")).Put([]byte("d"), []byte("d_value")); err != nil { // Put key
+					return err // Return found error
+				}
+
+				return errRollback // Roll back
+			}) // Write rolled back key
+
+			if err != errRollback { // Check error not returned
+				t.Fatalf("expected rollback error, got %v", err) // Panic
+			}
+
+			err = store.View(func(tx Tx) error {
+				if tx.Bucket([]byte("missing")) != nil { // Check missing bucket found
+					t.Fatal("missing bucket should be nil") // Panic
+				}
+
+				bucket := tx.Bucket([]byte("test")) // Get bucket
+
+				if value := bucket.Get([]byte("a")); string(value) != "a_value" { // Check value
+					t.Fatalf("unexpected value %s", value) // Panic
+				}
+
+				if bucket.Get([]byte("c")) != nil || bucket.Get([]byte("d")) != nil { // Check deleted and rolled back keys
+					t.Fatal("deleted and rolled back keys should not exist") // Panic
+				}
+
+				var keys []string // Init keys buffer
+
+				err := bucket.ForEach(func(key []byte, _ []byte) error {
+					keys = append(keys, string(key)) // Append key
+
+					return nil // No error occurred, return nil
+				}) // Collect keys
+
+				if len(keys) != 2 || keys[0] != "a" || keys[1] != "b" { // Check order
+					t.Fatalf("expected keys [a b], got %v", keys) // Panic
+				}
+
+				if err != nil { // Check for errors
+					return err // Return found error
+				}
+
+				keys = nil // Reset keys buffer
+
+				err = bucket.ForEachFrom([]byte("aa"), func(key []byte, _ []byte) error {
+					keys = append(keys, string(key)) // Append key
+
+					return nil // No error occurred, return nil
+				}) // Collect keys after start
+
+				if len(keys) != 1 || keys[0] != "b" { // Check seek
+					t.Fatalf("expected keys [b], got %v", keys) // Panic
+				}
+
+				if err != nil { // Check for errors
+					return err // Return found error
+				}
+
+				keys = nil // Reset keys buffer
+
+				errStop := errors.New("stop") // Init stop error
+
+				err = bucket.ForEachFrom([]byte("a"), func(key []byte, _ []byte) error {
+					keys = append(keys, string(key)) // Append key
+
+					return errStop // Stop
+				}) // Collect first key
+
+				if err != errStop || len(keys) != 1 || keys[0] != "a" { // Check stopped
+					t.Fatalf("expected iteration to stop after [a], got %v (%v)", keys, err) // Panic
+				}
+
+				return nil // No error occurred, return nil
+			}) // Read keys
+
+			if err != nil { // Check for errors
+				t.Fatal(err) // Panic
+			}
+
+			err = store.Update(func(tx Tx) error {
+				if err := tx.DeleteBucket([]byte("test")); err != nil { // Delete bucket
+					return err // Return found error
+				}
+
+				return tx.DeleteBucket([]byte("test")) // Delete missing bucket
+			}) // Delete bucket
+
+			if err != ErrBucketNotFound { // Check missing bucket deleted
+				t.Fatalf("expected ErrBucketNotFound, got %v", err) // Panic
+			}
+
+			if err = store.Check(); err != nil { // Check integrity
+				t.Fatal(err) // Panic
+			}
+		}) // Test backend
+	}
+}
+
+// TestCopy tests the functionality of the Copy() helper method.
+func TestCopy(t *testing.T) {
+	src, dst := newTestStore(t, BackendBolt), newTestStore(t, BackendSQLite) // Open stores
+
+	fillTestStore(t, src) // Fill source
+
+	if err := Copy(dst, src); err != nil { // Copy
+		t.Fatal(err) // Panic
+	}
+
+	if dump(t, dst) != dump(t, src) { // Check contents differ
+		t.Fatal("copied store should match its source") // Panic
+	}
+}
+
+// TestLoad tests the functionality of the Dump() and Load() helper methods.
+func TestLoad(t *testing.T) {
+	src, dst := newTestStore(t, BackendSQLite), newTestStore(t, BackendBolt) // Open stores
+
+	fillTestStore(t, src) // Fill source
+
+	dumped := dump(t, src) // Dump source
+
+	err := dst.Update(func(tx Tx) error {
+		return Load(tx, bytes.NewBufferString(dumped)) // Load dump
+	}) // Load dump
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	if dump(t, dst) != dumped { // Check contents differ
+		t.Fatal("loaded store should match its dump") // Panic
+	}
+}
+
+/* END EXPORTED METHODS TESTS */
+
+/* BEGIN TEST HELPERS */
+
+// newTestStore opens a store with a given backend in a temporary directory.
+// The store is closed, and the directory removed, once the test completes.
+func newTestStore(t *testing.T, backend string) Store {
+	dir, err := ioutil.TempDir("", "smc_wallet_storage_test") // Make temp dir
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	store, err := Open(backend, filepath.Join(dir, "store")) // Open store
+
+	if err != nil { // Check for errors
+		os.RemoveAll(dir) // Remove temp dir
+
+		t.Fatal(err) // Panic
+	}
+
+	t.Cleanup(func() {
+		store.Close()     // Close store
+		os.RemoveAll(dir) // Remove temp dir
+	}) // Clean up after test
+
+	return store // Return store
+}
+
+// fillTestStore writes a few buckets, including an empty one, to a given store.
+func fillTestStore(t *testing.T, store Store) {
+	err := store.Update(func(tx Tx) error {
+		if _, err := tx.CreateBucketIfNotExists([]byte("empty")); err != nil { // Create empty bucket
+			return err // Return found error
+		}
+
+		bucket, err := tx.CreateBucketIfNotExists([]byte("test")) // Create bucket
+
+		if err != nil { // Check for errors
+			return err // Return found error
+		}
+
+		if err = bucket.Put([]byte{0x00, 0xff}, []byte("binary")); err != nil { // Put binary key
+			return err // Return found error
+		}
+
+		return bucket.Put([]byte("key"), []byte{}) // Put empty value
+	}) // Fill store
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+}
+
+// dump dumps the contents of a given store to a string.
+func dump(t *testing.T, store Store) string {
+	buffer := new(bytes.Buffer) // Init dump buffer
+
+	err := store.View(func(tx Tx) error {
+		return Dump(tx, buffer) // Dump store
+	}) // Dump store
+
+	if err != nil { // Check for errors
+		t.Fatal(err) // Panic
+	}
+
+	return buffer.String() // Return dump
+}
+
+/* END TEST HELPERS */
@@ -3,10 +3,13 @@ package transactions
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
 	"errors"
 	"math/big"
 
-	summercashAccounts "github.com/SummerCash/go-summercash/accounts"
 	"github.com/SummerCash/go-summercash/common"
 	summercashCommon "github.com/SummerCash/go-summercash/common"
 	"github.com/SummerCash/go-summercash/config"
@@ -17,78 +20,227 @@ import (
 	"github.com/SummerCash/summercash-wallet-server/accounts"
 )
 
+// ErrTransactionIncomplete is an error definition describing a submitted transaction missing its sender, recipient, amount or hash.
+var ErrTransactionIncomplete = errors.New("transaction must have a sender, recipient, amount and hash")
+
 /* BEGIN EXPORTED METHODS */
 
-// NewTransaction creates, signs, and publishes a new transaction from a given user to a given address.
-func NewTransaction(accountsDB *accounts.DB, username string, password string, recipientAddress *common.Address, amount float64, payload []byte) (*types.Transaction, error) {
+// NewTransaction creates, signs, and publishes a new transaction from a given user to a given recipient (a hex address, a label in the user's
+// address book, or a username; see accounts.DB.ResolveRecipient). The recipient is only resolved once the user has been authenticated.
+// The transaction is sent from the user's primary address, or from the sub-address with a given name or hex address (see accounts.SubAddress).
+// A second factor must be given if the user has enabled two-factor authentication (see accounts.SecondFactorOperations).
+// Transactions from frozen accounts are refused with accounts.ErrAccountFrozen, and transactions exceeding the
+// user's spending limits with accounts.ErrTransactionLimitExceeded or accounts.ErrDailyLimitExceeded.
+func NewTransaction(accountsDB *accounts.DB, username string, password string, secondFactor string, from string, recipient string, amount float64, payload []byte) (*types.Transaction, error) {
 	summercashCommon.DataDir = common.DataDir // Set data dir
 
-	account, err := accountsDB.QueryAccountByUsername(username) // Query account
+	err := accountsDB.AuthenticateScoped(username, password, accounts.ScopeSend) // Authenticate
+
+	if err == accounts.ErrPasswordInvalid { // Check could not authenticate
+		return &types.Transaction{}, errors.New("invalid username or password") // Return found error
+	} else if err != nil { // Check for errors
+		return &types.Transaction{}, err // Return found error
+	}
+
+	recipientAddress, err := accountsDB.ResolveRecipient(username, recipient) // Resolve address, contact label or username
 
 	if err != nil { // Check for errors
 		return &types.Transaction{}, err // Return found error
 	}
 
-	if authenticated := accountsDB.Auth(username, password); !authenticated { // Check could not authenticate
+	defer accounts.LockSpending(username)() // Hold spending limits until published
+
+	if err = accountsDB.CheckSpendingLimits(username, amount); err != nil { // Check spending limits (before the second factor is verified, so that a refused send doesn't use it up)
+		return &types.Transaction{}, err // Return found error
+	}
+
+	sender, privateKey, err := accountsDB.UnlockAddressPrivateKey(username, from, password, accounts.OperationSend, secondFactor) // Decrypt private key in memory
+
+	if err == accounts.ErrPasswordInvalid { // Check could not authenticate
 		return &types.Transaction{}, errors.New("invalid username or password") // Return found error
+	} else if err != nil { // Check for errors
+		return &types.Transaction{}, err // Return found error
 	}
 
-	accountChain, err := types.ReadChainFromMemory(account.Address) // Read chain
+	return NewSignedTransaction(sender, privateKey, &recipientAddress, amount, payload) // Sign and publish transaction
+}
+
+// NewSignedTransaction creates, signs, and publishes a new transaction from a given address to a given address,
+// using a given, already unlocked private key.
+func NewSignedTransaction(sender common.Address, privateKey *ecdsa.PrivateKey, recipientAddress *common.Address, amount float64, payload []byte) (*types.Transaction, error) {
+	transaction, err := newTransaction(sender, recipientAddress, amount, payload) // Initialize transaction
 
 	if err != nil { // Check for errors
-		accountChain, err = types.NewChain(account.Address) // Initialize chain
+		return &types.Transaction{}, err // Return found error
+	}
 
-		if err != nil { // Check for errors
-			return &types.Transaction{}, err // Return found error
-		}
+	err = types.SignTransaction(transaction, privateKey) // Sign transaction
+
+	if err != nil { // Check for errors
+		return &types.Transaction{}, err // Return found error
 	}
 
-	var parentTransaction *types.Transaction // Init parent tx buffer
+	if err = publishTransaction(transaction); err != nil { // Publish transaction
+		return &types.Transaction{}, err // Return found error
+	}
 
-	targetNonce := uint64(0.0) // Init target nonce
+	return transaction, nil // Return tx
+}
 
-	if len(accountChain.Transactions) > 0 { // Check has txs
-		parentTransaction = accountChain.Transactions[len(accountChain.Transactions)-1] // Set parent transaction
+// NewUnsignedTransaction creates a new, unsigned transaction from a given user to a given recipient (resolved as by NewTransaction), so that it can be signed
+// outside of the server (e.g. for a watch-only account, see accounts.ErrWatchOnlyAccount), and submitted with SubmitSignedTransaction.
+// The transaction is created from the user's primary address, or from the sub-address with a given name or hex address.
+// The user must be authenticated with their password, or with an access token carrying the read scope.
+func NewUnsignedTransaction(accountsDB *accounts.DB, username string, password string, from string, recipient string, amount float64, payload []byte) (*types.Transaction, error) {
+	if err := accountsDB.AuthenticateScoped(username, password, accounts.ScopeRead); err != nil { // Authenticate
+		return &types.Transaction{}, err // Return found error
+	}
 
-		targetNonce = accountChain.CalculateTargetNonce() // Set nonce
+	if err := accountsDB.CheckAccountNotFrozen(username); err != nil { // Check frozen
+		return &types.Transaction{}, err // Return found error
 	}
 
-	transaction, err := types.NewTransaction(targetNonce, parentTransaction, &account.Address, recipientAddress, big.NewFloat(amount), payload) // Initialize transaction
+	sender, err := accountsDB.ResolveSendingAddress(username, from) // Resolve sending address
 
 	if err != nil { // Check for errors
 		return &types.Transaction{}, err // Return found error
 	}
 
-	summercashAccount, err := summercashAccounts.ReadAccountFromMemory(account.Address) // Read account
+	recipientAddress, err := accountsDB.ResolveRecipient(username, recipient) // Resolve address, contact label or username
 
 	if err != nil { // Check for errors
 		return &types.Transaction{}, err // Return found error
 	}
 
-	config, err := config.ReadChainConfigFromMemory() // Read config from memory
+	return newTransaction(sender, &recipientAddress, amount, payload) // Initialize transaction
+}
 
-	if err != nil { // Check for errors
+// SubmitSignedTransaction validates and publishes a transaction that was signed outside of the server.
+// The transaction must be sent from one of the user's addresses, and the user must be authenticated with their password,
+// or with an access token carrying the send scope. Transactions from frozen accounts, or exceeding the user's spending limits, are refused.
+// Every attempt is recorded in the audit log.
+func SubmitSignedTransaction(accountsDB *accounts.DB, username string, password string, transaction *types.Transaction) (err error) {
+	defer func() {
+		accountsDB.RecordAuditEvent(username, accounts.AuditActionSubmitTransaction, transactionHash(transaction), err)
+	}() // Record attempt
+
+	if err = accountsDB.AuthenticateScoped(username, password, accounts.ScopeSend); err != nil { // Authenticate
+		return err // Return found error
+	}
+
+	if err = accountsDB.CheckAccountNotFrozen(username); err != nil { // Check frozen
+		return err // Return found error
+	}
+
+	if transaction.Sender == nil || transaction.Recipient == nil || transaction.Amount == nil || transaction.Hash == nil { // Check incomplete
+		return ErrTransactionIncomplete // Return error
+	}
+
+	if transaction.Signature == nil { // Check unsigned
+		return types.ErrNilSignature // Return error
+	}
+
+	if _, err = accountsDB.ResolveSendingAddress(username, transaction.Sender.String()); err != nil { // Check sent from one of the user's addresses
+		return err // Return found error
+	}
+
+	amount, _ := transaction.Amount.Float64() // Get amount
+
+	defer accounts.LockSpending(username)() // Hold spending limits until published
+
+	if err = accountsDB.CheckSpendingLimits(username, amount); err != nil { // Check spending limits
+		return err // Return found error
+	}
+
+	return publishTransaction(transaction) // Publish transaction
+}
+
+// ParseSignedTransaction decodes a transaction signed outside of the server from its JSON encoding.
+func ParseSignedTransaction(b []byte) (*types.Transaction, error) {
+	probe := &types.Transaction{} // Init probe buffer
+
+	if err := json.Unmarshal(b, probe); err != nil { // Decode transaction
 		return &types.Transaction{}, err // Return found error
 	}
 
-	validator := validator.Validator(validator.NewStandardValidator(config)) // Initialize validator
+	if probe.Signature == nil { // Check unsigned
+		return &types.Transaction{}, types.ErrNilSignature // Return error
+	}
+
+	block, _ := pem.Decode([]byte(probe.Signature.SerializedPublicKey)) // Decode public key
 
-	err = types.SignTransaction(transaction, summercashAccount.PrivateKey) // Sign transaction
+	if block == nil { // Check public key not PEM-encoded (which TransactionFromBytes doesn't handle)
+		return &types.Transaction{}, types.ErrInvalidSignature // Return error
+	}
+
+	if publicKey, err := x509.ParsePKIXPublicKey(block.Bytes); err != nil { // Check public key not x509-encoded (likewise)
+		return &types.Transaction{}, types.ErrInvalidSignature // Return error
+	} else if _, ok := publicKey.(*ecdsa.PublicKey); !ok { // Check public key not ecdsa (likewise)
+		return &types.Transaction{}, types.ErrInvalidSignature // Return error
+	}
+
+	transaction, err := types.TransactionFromBytes(b) // Decode transaction and public key
 
 	if err != nil { // Check for errors
 		return &types.Transaction{}, err // Return found error
 	}
 
+	return transaction, nil // Return transaction
+}
+
+/* END EXPORTED METHODS */
+
+/* BEGIN INTERNAL METHODS */
+
+// newTransaction creates a new, unsigned transaction from a given address to a given address, following the sender's latest transaction.
+func newTransaction(sender common.Address, recipientAddress *common.Address, amount float64, payload []byte) (*types.Transaction, error) {
+	summercashCommon.DataDir = common.DataDir // Set data dir
+
+	accountChain, err := types.ReadChainFromMemory(sender) // Read chain
+
+	if err != nil { // Check for errors
+		accountChain, err = types.NewChain(sender) // Initialize chain
+
+		if err != nil { // Check for errors
+			return &types.Transaction{}, err // Return found error
+		}
+	}
+
+	var parentTransaction *types.Transaction // Init parent tx buffer
+
+	targetNonce := uint64(0.0) // Init target nonce
+
+	if len(accountChain.Transactions) > 0 { // Check has txs
+		parentTransaction = accountChain.Transactions[len(accountChain.Transactions)-1] // Set parent transaction
+
+		targetNonce = accountChain.CalculateTargetNonce() // Set nonce
+	}
+
+	return types.NewTransaction(targetNonce, parentTransaction, &sender, recipientAddress, big.NewFloat(amount), payload) // Initialize transaction
+}
+
+// publishTransaction validates a given signed transaction, writes it to the mempool, and publishes it.
+func publishTransaction(transaction *types.Transaction) error {
+	summercashCommon.DataDir = common.DataDir // Set data dir
+
+	config, err := config.ReadChainConfigFromMemory() // Read config from memory
+
+	if err != nil { // Check for errors
+		return err // Return found error
+	}
+
+	validator := validator.Validator(validator.NewStandardValidator(config)) // Initialize validator
+
 	err = validator.ValidateTransaction(transaction) // Validate transaction
 
 	if err != nil { // Check for errors
-		return &types.Transaction{}, err // Return found error
+		return err // Return found error
 	}
 
 	err = transaction.WriteToMemory() // Write tx to mempool
 
 	if err != nil { // Check for errors
-		return &types.Transaction{}, err // Return found error
+		return err // Return found error
 	}
 
 	rpcServer := new(transactionServer.Server) // Initialize mock RPC server
@@ -99,11 +251,16 @@ func NewTransaction(accountsDB *accounts.DB, username string, password string, r
 
 	_, err = rpcServer.Publish(publishCtx, &transactionProto.GeneralRequest{Address: transaction.Hash.String()}) // Publish
 
-	if err != nil { // Check for errors
-		return &types.Transaction{}, err // Return found error
+	return err // Return error (if any)
+}
+
+// transactionHash gets the hex hash of a given transaction, or an empty string if it has none.
+func transactionHash(transaction *types.Transaction) string {
+	if transaction == nil || transaction.Hash == nil { // Check no hash
+		return "" // No hash
 	}
 
-	return transaction, nil // Return tx
+	return transaction.Hash.String() // Return hash
 }
 
-/* END EXPORTED METHODS */
+/* END INTERNAL METHODS */